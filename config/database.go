@@ -10,28 +10,45 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func ConnectDB() *mongo.Client {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(EnvMongoURI()))
+// Client dibuat tanpa menghubungi server, sehingga package yang menyimpan collection di variabel
+// package tetap bisa dimuat tanpa database (misalnya saat unit test). Nil jika MONGOURI kosong.
+func newClient() *mongo.Client {
+	uri := EnvMongoURI()
+	if uri == "" {
+		return nil
+	}
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
 	if err != nil {
 		log.Fatal(err)
 	}
+	return client
+}
+
+// Pastikan database bisa dijangkau, dipanggil sekali sebelum server menerima request
+func ConnectDB() *mongo.Client {
+	if DB == nil {
+		log.Fatal("MONGOURI is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
 	//ping the database
-	err = client.Ping(ctx, nil)
+	err := DB.Ping(ctx, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Println("Connected to MongoDB")
-	return client
+	return DB
 }
 
 // Client instance
-var DB *mongo.Client = ConnectDB()
+var DB *mongo.Client = newClient()
 
 // getting database collections
 func GetCollection(client *mongo.Client, collectionName string) *mongo.Collection {
+	if client == nil {
+		return nil
+	}
 	collection := client.Database("unairsatu").Collection(collectionName)
 	return collection
 }
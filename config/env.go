@@ -8,9 +8,10 @@ import (
 )
 
 func EnvMongoURI() string {
+	// Tanpa file .env, MONGOURI dibaca dari environment proses
 	err := godotenv.Load()
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Println("No .env file found, reading MONGOURI from the environment")
 	}
 	return os.Getenv("MONGOURI")
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"demoapp/model"
+	"errors"
 	"net/http"
 	"time"
 
@@ -27,7 +28,7 @@ func CreateUserModule(c *fiber.Ctx) error {
 
 	// Parsing dan validasi
 	if err := parseAndValidateRequest(c, &req); err != nil {
-		return err
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// Proses pembuatan modul untuk setiap user
@@ -35,28 +36,30 @@ func CreateUserModule(c *fiber.Ctx) error {
 		// Ambil data user
 		var user model.User
 		oid, _ := primitive.ObjectIDFromHex(userID)
-		err := UserCollection.FindOne(c.Context(), bson.M{"_id": oid}).Decode(&user)
+		err := UserCollection.FindOne(c.Context(), bson.M{"_id": oid, "deleted_at": nil}).Decode(&user)
 		if err != nil {
 			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 		}
 
 		// Insert atau Update modul
+		filter := bson.M{"jenis_user": user.JenisUser, "catatan": CatatanUserKhusus, "user_id": bson.M{"$in": []primitive.ObjectID{oid}}}
 		_, err = UserModulCollection.UpdateOne(
 			c.Context(),
-			bson.M{"jenis_user": user.JenisUser, "user_id": bson.M{"$in": []primitive.ObjectID{oid}}},
+			filter,
 			bson.M{
 				"$addToSet": bson.M{"modul_id": bson.M{"$each": parseObjectIDs(req.ModulIDs)}},
 				"$setOnInsert": bson.M{
 					"user_id":    []primitive.ObjectID{oid},
-					"catatan":    "userkhusus",
 					"created_at": time.Now(),
 				},
+				"$inc": naikkanVersi,
 			},
 			options.Update().SetUpsert(true),
 		)
 		if err != nil {
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create user module"})
 		}
+		catatHistori(c.Context(), UserModulCollection, AksiUpdate, aktorDari(c), idDokumen(c.Context(), UserModulCollection, filter)...)
 	}
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User modules successfully created"})
@@ -68,18 +71,21 @@ func UpdateUserModule(c *fiber.Ctx) error {
 
 	// Parsing dan validasi
 	if err := parseAndValidateRequest(c, &req); err != nil {
-		return err
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Update modul untuk user
+	// Update modul untuk user, bundle jenis_user hanya diubah lewat katalog
+	filter := bson.M{"user_id": bson.M{"$in": parseObjectIDs(req.UserIDs)}, "catatan": bson.M{"$ne": CatatanBundle}}
+	ids := idDokumen(c.Context(), UserModulCollection, filter)
 	_, err := UserModulCollection.UpdateMany(
 		c.Context(),
-		bson.M{"user_id": bson.M{"$in": parseObjectIDs(req.UserIDs)}},
-		bson.M{"$addToSet": bson.M{"modul_id": bson.M{"$each": parseObjectIDs(req.ModulIDs)}}},
+		filter,
+		bson.M{"$addToSet": bson.M{"modul_id": bson.M{"$each": parseObjectIDs(req.ModulIDs)}}, "$inc": naikkanVersi},
 	)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update user modules"})
 	}
+	catatHistori(c.Context(), UserModulCollection, AksiUpdate, aktorDari(c), ids...)
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User modules successfully updated"})
 }
@@ -90,18 +96,21 @@ func DeleteUserModule(c *fiber.Ctx) error {
 
 	// Parsing dan validasi
 	if err := parseAndValidateRequest(c, &req); err != nil {
-		return err
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Hapus modul dari user
+	// Hapus modul dari user, bundle jenis_user hanya diubah lewat katalog
+	filter := bson.M{"user_id": bson.M{"$in": parseObjectIDs(req.UserIDs)}, "catatan": bson.M{"$ne": CatatanBundle}}
+	ids := idDokumen(c.Context(), UserModulCollection, filter)
 	_, err := UserModulCollection.UpdateMany(
 		c.Context(),
-		bson.M{"user_id": bson.M{"$in": parseObjectIDs(req.UserIDs)}},
-		bson.M{"$pull": bson.M{"modul_id": bson.M{"$in": parseObjectIDs(req.ModulIDs)}}},
+		filter,
+		bson.M{"$pull": bson.M{"modul_id": bson.M{"$in": parseObjectIDs(req.ModulIDs)}}, "$inc": naikkanVersi},
 	)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete user modules"})
 	}
+	catatHistori(c.Context(), UserModulCollection, AksiUpdate, aktorDari(c), ids...)
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User modules successfully deleted"})
 }
@@ -110,24 +119,24 @@ func DeleteUserModule(c *fiber.Ctx) error {
 // Fungsi Utility (Helper)
 // ------------------------------
 
-// Fungsi untuk parsing dan validasi request
+// Fungsi untuk parsing dan validasi request, response 400 ditulis oleh pemanggil
 func parseAndValidateRequest(c *fiber.Ctx, req *UserModuleRequest) error {
 	// Parsing request
 	if err := c.BodyParser(req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+		return errors.New("invalid request")
 	}
 
 	// Validasi User IDs
 	for _, id := range req.UserIDs {
 		if _, err := primitive.ObjectIDFromHex(id); err != nil {
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID: " + id})
+			return errors.New("invalid user ID: " + id)
 		}
 	}
 
 	// Validasi Modul IDs
 	for _, id := range req.ModulIDs {
 		if _, err := primitive.ObjectIDFromHex(id); err != nil {
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID: " + id})
+			return errors.New("invalid modul ID: " + id)
 		}
 	}
 
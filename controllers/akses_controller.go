@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Tipe sumber hak akses modul
+const (
+	SumberJenisUser  = "jenis_user" // Bundle bawaan dari katalog jenis_user
+	SumberUserKhusus = "userkhusus" // Modul tambahan khusus user
+	SumberUserModul  = "usermodul"  // Dokumen usermodul lain yang mencantumkan user
+	SumberGrup       = "grup"       // Grup yang beranggotakan user
+)
+
+// Satu alasan mengapa user mendapatkan sebuah modul
+type sumberAkses struct {
+	Tipe         string     `json:"tipe"`
+	JenisUser    string     `json:"jenis_user,omitempty"`
+	DiwarisiDari string     `json:"diwarisi_dari,omitempty"` // Jenis_user induk yang mendefinisikan modul
+	UserModulID  string     `json:"usermodul_id,omitempty"`
+	GrupID       string     `json:"grup_id,omitempty"`
+	NmGrup       string     `json:"nm_grup,omitempty"`
+	Catatan      string     `json:"catatan,omitempty"`
+	ExpiredAt    *time.Time `json:"expired_at,omitempty"`
+	Kedaluwarsa  bool       `json:"kedaluwarsa"`
+}
+
+// Hasil resolusi akses untuk satu modul
+type aksesModul struct {
+	Modul     model.Modul          `json:"modul"`
+	Efektif   bool                 `json:"efektif"`
+	Sumber    []sumberAkses        `json:"sumber"`
+	Kebijakan []string             `json:"kebijakan,omitempty"` // Alasan grant tidak berlaku
+	Deny      *model.UserModulDeny `json:"deny,omitempty"`      // Pengecualian eksplisit untuk user
+}
+
+// Cari user berdasarkan ID, user yang sudah dihapus (soft delete) dianggap tidak ada
+func cariUserByID(ctx context.Context, userID primitive.ObjectID) (model.User, error) {
+	var user model.User
+	err := UserCollection.FindOne(ctx, bson.M{"_id": userID, "deleted_at": nil}).Decode(&user)
+	return user, err
+}
+
+// Kumpulkan semua grant modul milik user beserta sumbernya, diurutkan berdasarkan urutan modul.
+// Modul yang ditolak, tidak aktif, atau grant yang sudah kedaluwarsa tetap dikembalikan dengan Efektif = false.
+func resolveAkses(ctx context.Context, user model.User) ([]aksesModul, error) {
+	now := time.Now()
+	sumberPerModul := map[primitive.ObjectID][]sumberAkses{}
+	var urutanID []primitive.ObjectID
+	tambah := func(modulID primitive.ObjectID, sumber sumberAkses) {
+		if _, ok := sumberPerModul[modulID]; !ok {
+			urutanID = append(urutanID, modulID)
+		}
+		sumberPerModul[modulID] = append(sumberPerModul[modulID], sumber)
+	}
+
+	// Bundle bawaan jenis_user dari katalog, termasuk yang diwarisi dari induknya
+	bundle, err := bundleJenisUser(ctx, user.JenisUser)
+	if err != nil && !errors.Is(err, errJenisUserTidakDikenal) {
+		return nil, err
+	}
+	for _, modul := range bundle {
+		sumber := sumberAkses{Tipe: SumberJenisUser, JenisUser: user.JenisUser}
+		if modul.Dari != user.JenisUser {
+			sumber.DiwarisiDari = modul.Dari
+		}
+		tambah(modul.ModulID, sumber)
+	}
+
+	// Grant dari dokumen usermodul yang mencantumkan user, selain bundle
+	cursor, err := UserModulCollection.Find(ctx, bson.M{"user_id": user.ID, "catatan": bson.M{"$ne": CatatanBundle}})
+	if err != nil {
+		return nil, err
+	}
+	var userModuls []model.UserModul
+	if err := cursor.All(ctx, &userModuls); err != nil {
+		return nil, err
+	}
+	for _, userModul := range userModuls {
+		tipe := SumberUserModul
+		if userModul.Catatan == CatatanUserKhusus {
+			tipe = SumberUserKhusus
+		}
+		for _, modulID := range userModul.ModulID {
+			tambah(modulID, sumberAkses{
+				Tipe:        tipe,
+				JenisUser:   userModul.JenisUser,
+				UserModulID: userModul.ID.Hex(),
+				Catatan:     userModul.Catatan,
+				ExpiredAt:   userModul.ExpiredAt,
+				Kedaluwarsa: userModul.ExpiredAt != nil && userModul.ExpiredAt.Before(now),
+			})
+		}
+	}
+
+	// Grant dari grup yang beranggotakan user
+	grups, err := grupUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, grup := range grups {
+		for _, modulID := range grup.ModulID {
+			tambah(modulID, sumberAkses{Tipe: SumberGrup, GrupID: grup.ID.Hex(), NmGrup: grup.NmGrup})
+		}
+	}
+
+	if len(urutanID) == 0 {
+		return []aksesModul{}, nil
+	}
+
+	// Ambil detail modul, modul yang sudah dihapus diabaikan
+	modulCursor, err := ModulCollection.Find(ctx, bson.M{"_id": bson.M{"$in": urutanID}})
+	if err != nil {
+		return nil, err
+	}
+	var moduls []model.Modul
+	if err := modulCursor.All(ctx, &moduls); err != nil {
+		return nil, err
+	}
+
+	// Deny eksplisit selalu mengalahkan grant apa pun
+	denies, err := denyUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	hasil := make([]aksesModul, 0, len(moduls))
+	for _, modul := range moduls {
+		akses := aksesModul{Modul: modul, Sumber: sumberPerModul[modul.ID]}
+
+		if deny, ok := denies[modul.ID]; ok {
+			akses.Deny = &deny
+			akses.Kebijakan = append(akses.Kebijakan, "module is explicitly denied for this user")
+		}
+
+		berlaku := false
+		for _, sumber := range akses.Sumber {
+			if !sumber.Kedaluwarsa {
+				berlaku = true
+			}
+		}
+		if !berlaku {
+			akses.Kebijakan = append(akses.Kebijakan, "all grants for this module have expired")
+		}
+		if !modul.IsAktif {
+			akses.Kebijakan = append(akses.Kebijakan, "module is not active (is_aktif = false)")
+		}
+		if !model.StatusBolehLogin(user.StatusAkun()) {
+			akses.Kebijakan = append(akses.Kebijakan, fmt.Sprintf("account status is %q", user.StatusAkun()))
+		}
+		akses.Efektif = len(akses.Kebijakan) == 0
+		hasil = append(hasil, akses)
+	}
+
+	sort.SliceStable(hasil, func(i, j int) bool {
+		return hasil[i].Modul.Urutan < hasil[j].Modul.Urutan
+	})
+	return hasil, nil
+}
+
+// Modul yang benar-benar dapat diakses user
+func modulEfektif(ctx context.Context, user model.User) ([]model.Modul, error) {
+	aksesList, err := resolveAkses(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	moduls := []model.Modul{}
+	for _, akses := range aksesList {
+		if akses.Efektif {
+			moduls = append(moduls, akses.Modul)
+		}
+	}
+	return moduls, nil
+}
+
+// Modul yang diberikan ke user (tidak ditolak dan grant-nya belum kedaluwarsa) tanpa memandang is_aktif,
+// misalnya untuk pengumuman pemeliharaan modul yang sedang dinonaktifkan
+func modulDiberikan(ctx context.Context, user model.User) ([]model.Modul, error) {
+	aksesList, err := resolveAkses(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	moduls := []model.Modul{}
+	for _, akses := range aksesList {
+		if akses.Deny != nil {
+			continue
+		}
+		berlaku := false
+		for _, sumber := range akses.Sumber {
+			if !sumber.Kedaluwarsa {
+				berlaku = true
+			}
+		}
+		if berlaku {
+			moduls = append(moduls, akses.Modul)
+		}
+	}
+	return moduls, nil
+}
+
+// Jelaskan mengapa user tidak (atau bisa) mengakses sebuah modul
+func jelaskanAksesModul(ctx context.Context, user model.User, modulID primitive.ObjectID, aksesList []aksesModul) ([]string, error) {
+	for _, akses := range aksesList {
+		if akses.Modul.ID == modulID {
+			if akses.Efektif {
+				return []string{"module is granted"}, nil
+			}
+			return akses.Kebijakan, nil
+		}
+	}
+
+	var modul model.Modul
+	err := ModulCollection.FindOne(ctx, bson.M{"_id": modulID}).Decode(&modul)
+	if err == mongo.ErrNoDocuments {
+		return []string{"module does not exist"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	alasan := []string{}
+	denies, err := denyUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := denies[modulID]; ok {
+		alasan = append(alasan, "module is explicitly denied for this user")
+	}
+	if _, err := cariJenisUser(ctx, user.JenisUser); errors.Is(err, errJenisUserTidakDikenal) {
+		alasan = append(alasan, fmt.Sprintf("jenis_user %q is not registered in the catalog", user.JenisUser))
+	} else {
+		alasan = append(alasan, fmt.Sprintf("module is not in the bundle of jenis_user %q or any of its parents", user.JenisUser))
+	}
+	alasan = append(alasan, "no userkhusus, usermodul or grup grant lists this user for the module")
+	if !modul.IsAktif {
+		alasan = append(alasan, "module is not active (is_aktif = false)")
+	}
+	if !model.StatusBolehLogin(user.StatusAkun()) {
+		alasan = append(alasan, fmt.Sprintf("account status is %q", user.StatusAkun()))
+	}
+	return alasan, nil
+}
+
+// GetUserAccess - Tampilkan akses modul efektif user beserta alasannya.
+// Dengan query ?modul_id=... menjelaskan mengapa user (tidak) bisa mengakses modul tersebut.
+func GetUserAccess(c *fiber.Ctx) error {
+	userID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	user, err := cariUserByID(c.Context(), userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user"})
+	}
+
+	aksesList, err := resolveAkses(c.Context(), user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resolve user access"})
+	}
+
+	// Mode "why not" untuk satu modul
+	if modulParam := c.Query("modul_id"); modulParam != "" {
+		modulID, err := primitive.ObjectIDFromHex(modulParam)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID"})
+		}
+
+		alasan, err := jelaskanAksesModul(c.Context(), user, modulID, aksesList)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to explain module access"})
+		}
+
+		var detail *aksesModul
+		for i := range aksesList {
+			if aksesList[i].Modul.ID == modulID {
+				detail = &aksesList[i]
+			}
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"user_id":    user.ID,
+			"jenis_user": user.JenisUser,
+			"status":     user.StatusAkun(),
+			"modul_id":   modulID,
+			"granted":    detail != nil && detail.Efektif,
+			"reasons":    alasan,
+			"detail":     detail,
+		})
+	}
+
+	efektif := []aksesModul{}
+	tidakEfektif := []aksesModul{}
+	for _, akses := range aksesList {
+		if akses.Efektif {
+			efektif = append(efektif, akses)
+		} else {
+			tidakEfektif = append(tidakEfektif, akses)
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"user_id":     user.ID,
+		"jenis_user":  user.JenisUser,
+		"status":      user.StatusAkun(),
+		"modules":     efektif,
+		"inactive":    tidakEfektif,
+		"total_count": len(efektif),
+	})
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var UserModulPrefCollection = config.GetCollection(config.DB, "usermodul_pref")
+
+// Satu kartu modul di dashboard
+type dashboardModul struct {
+	ID         primitive.ObjectID `json:"id"`
+	NmModul    string             `json:"nm_modul"`
+	KetModul   string             `json:"ket_modul"`
+	Urutan     int                `json:"urutan"`
+	IconURL    string             `json:"icon_url,omitempty"`
+	Alamat     string             `json:"alamat"` // Tujuan link, buka lewat POST /me/moduls/:id/launch agar tercatat di riwayat
+	Pinned     bool               `json:"pinned"`
+	DibukaPada *time.Time         `json:"dibuka_pada,omitempty"`
+}
+
+// Ambil preferensi modul user, dokumen kosong jika belum pernah disimpan
+func prefModulUser(ctx context.Context, userID primitive.ObjectID) (model.UserModulPref, error) {
+	pref := model.UserModulPref{UserID: userID}
+	err := UserModulPrefCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&pref)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return pref, err
+	}
+	return pref, nil
+}
+
+// Bentuk kartu modul beserta URL icon dan alamat modul
+func kartuModul(c *fiber.Ctx, modul model.Modul) dashboardModul {
+	kartu := dashboardModul{
+		ID:       modul.ID,
+		NmModul:  modul.NmModul,
+		KetModul: modul.KetModul,
+		Urutan:   modul.Urutan,
+		Alamat:   modul.Alamat,
+	}
+	if modul.Gbr_Icon != "" {
+		kartu.IconURL = c.BaseURL() + "/uploads/" + modul.Gbr_Icon
+	}
+	return kartu
+}
+
+// GetMyDashboard - Modul aktif yang dapat diakses user beserta favorit dan riwayat terakhir
+func GetMyDashboard(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	// Modul efektif sudah hanya berisi modul aktif dan terurut berdasarkan urutan
+	moduls, err := modulEfektif(ctx, user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
+	}
+
+	pref, err := prefModulUser(ctx, user.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch module preferences"})
+	}
+
+	pinned := map[primitive.ObjectID]bool{}
+	for _, modulID := range pref.Favorit {
+		pinned[modulID] = true
+	}
+
+	kartuPerID := map[primitive.ObjectID]dashboardModul{}
+	semua := make([]dashboardModul, 0, len(moduls))
+	for _, modul := range moduls {
+		kartu := kartuModul(c, modul)
+		kartu.Pinned = pinned[modul.ID]
+		kartuPerID[modul.ID] = kartu
+		semua = append(semua, kartu)
+	}
+
+	// Favorit dan riwayat hanya menampilkan modul yang masih bisa diakses
+	favorit := []dashboardModul{}
+	for _, modulID := range pref.Favorit {
+		if kartu, ok := kartuPerID[modulID]; ok {
+			favorit = append(favorit, kartu)
+		}
+	}
+	terakhir := []dashboardModul{}
+	for _, entri := range pref.Terakhir {
+		if kartu, ok := kartuPerID[entri.ModulID]; ok {
+			dibukaPada := entri.DibukaPada
+			kartu.DibukaPada = &dibukaPada
+			terakhir = append(terakhir, kartu)
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"user": fiber.Map{
+			"id":         user.ID,
+			"nm_user":    user.NmUser,
+			"jenis_user": user.JenisUser,
+			"photo":      user.Photo,
+		},
+		"modules":     semua,
+		"favorit":     favorit,
+		"terakhir":    terakhir,
+		"total_count": len(semua),
+	})
+}
+
+// Modul dari parameter :modulId yang boleh dibuka user, dicatat sebagai modul terakhir
+func bukaModul(ctx context.Context, c *fiber.Ctx, user model.User) (model.Modul, int, error) {
+	modulID, err := primitive.ObjectIDFromHex(c.Params("modulId"))
+	if err != nil {
+		return model.Modul{}, http.StatusBadRequest, fmt.Errorf("Invalid modul ID")
+	}
+
+	moduls, err := modulEfektif(ctx, user)
+	if err != nil {
+		return model.Modul{}, http.StatusInternalServerError, fmt.Errorf("Failed to fetch user modules")
+	}
+
+	for _, modul := range moduls {
+		if modul.ID == modulID {
+			if modul.Alamat == "" {
+				return modul, http.StatusNotFound, fmt.Errorf("Modul has no launch address")
+			}
+			// Riwayat hanya pelengkap, kegagalan mencatat tidak menghalangi launch
+			if err := catatModulTerakhir(ctx, user.ID, modul.ID); err != nil {
+				fmt.Println("Error recording recent module:", err)
+			}
+			return modul, http.StatusOK, nil
+		}
+	}
+
+	return model.Modul{}, http.StatusForbidden, fmt.Errorf("Access denied: module is not granted")
+}
+
+// LaunchMyModule - Buka modul: pastikan user berhak lalu redirect ke alamat modul.
+// Untuk klien API yang mengirim header Authorization sendiri.
+func LaunchMyModule(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+	modul, status, err := bukaModul(ctx, c, user)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Redirect(modul.Alamat, http.StatusFound)
+}
+
+// RecordMyModuleLaunch - Catat modul yang dibuka dari link dashboard lalu kembalikan alamatnya.
+// Dashboard memakai alamat modul sebagai link biasa dan memanggil endpoint ini saat link diklik.
+func RecordMyModuleLaunch(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+	modul, status, err := bukaModul(ctx, c, user)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Module launch recorded", "id": modul.ID, "alamat": modul.Alamat})
+}
@@ -0,0 +1,549 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"demoapp/responses"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Skor bawaan agar pasangan user masuk laporan duplikat
+	skorMinimumDuplikat = 0.5
+	// Blok nama yang lebih besar dari ini (nama yang sangat umum) tidak dibandingkan
+	batasBlokDuplikat = 200
+	// Kemiripan nama minimum agar nama ikut menambah skor
+	kemiripanNamaMinimum = 0.5
+)
+
+// Bobot setiap kecocokan. Email saja sudah cukup kuat, telepon atau nama perlu saling menguatkan.
+const (
+	bobotEmail   = 0.6
+	bobotTelepon = 0.4
+	bobotNama    = 0.4
+)
+
+// Field milik user yang dihapus yang boleh disalin ke user yang dipertahankan saat merge
+var fieldMergeUser = map[string]bool{"nm_user": true, "phone": true, "photo": true, "jenis_kelamin": true, "jenis_user": true}
+
+// Satu pasangan user yang kemungkinan adalah orang yang sama
+type pasanganDuplikat struct {
+	Users         []interface{} `json:"users"`
+	Skor          float64       `json:"score"`
+	Alasan        []string      `json:"reasons"` // email, phone, nm_user
+	KemiripanNama float64       `json:"name_similarity"`
+	SaranKeep     string        `json:"suggested_keep"` // ID user yang disarankan dipertahankan
+}
+
+// Email untuk perbandingan: huruf kecil, tanpa tag +..., titik di gmail diabaikan
+func normalisasiEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	lokal, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	lokal, _, _ = strings.Cut(lokal, "+")
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		lokal = strings.ReplaceAll(lokal, ".", "")
+		domain = "gmail.com"
+	}
+	return lokal + "@" + domain
+}
+
+// Koefisien Dice dari trigram nama fonetik, 1 berarti nama sama persis
+func kemiripanNama(a, b string) float64 {
+	gramA := trigram(fonetikNama(a))
+	gramB := trigram(fonetikNama(b))
+	if len(gramA) == 0 || len(gramB) == 0 {
+		return 0
+	}
+	ada := map[string]bool{}
+	for _, gram := range gramA {
+		ada[gram] = true
+	}
+	sama := 0
+	for _, gram := range gramB {
+		if ada[gram] {
+			sama++
+		}
+	}
+	return 2 * float64(sama) / float64(len(gramA)+len(gramB))
+}
+
+// Skor kemiripan dua user beserta alasannya
+func skorDuplikat(a, b model.User) (float64, []string, float64) {
+	skor := 0.0
+	alasan := []string{}
+	if email := normalisasiEmail(a.Email); email != "" && email == normalisasiEmail(b.Email) {
+		skor += bobotEmail
+		alasan = append(alasan, "email")
+	}
+	if telepon := normalisasiTelepon(a.Phone); len(telepon) >= 8 && telepon == normalisasiTelepon(b.Phone) {
+		skor += bobotTelepon
+		alasan = append(alasan, "phone")
+	}
+	nama := kemiripanNama(a.NmUser, b.NmUser)
+	if nama >= kemiripanNamaMinimum {
+		skor += bobotNama * nama
+		alasan = append(alasan, "nm_user")
+	}
+	return math.Min(1, math.Round(skor*100)/100), alasan, math.Round(nama*100) / 100
+}
+
+// Akun yang disarankan dipertahankan: akun buatan admin (bukan jenis_user bawaan registrasi),
+// jika sama-sama bukan maka akun yang lebih dulu dibuat
+func saranKeep(a, b model.User) model.User {
+	if (a.JenisUser == DefaultJenisUser) != (b.JenisUser == DefaultJenisUser) {
+		if a.JenisUser == DefaultJenisUser {
+			return b
+		}
+		return a
+	}
+	if b.CreatedAt < a.CreatedAt {
+		return b
+	}
+	return a
+}
+
+// Kunci blok untuk satu user. Hanya user dengan kunci yang sama yang dibandingkan,
+// sehingga tidak perlu membandingkan setiap pasangan user.
+func kunciBlokDuplikat(user model.User) []string {
+	kunci := []string{}
+	if email := normalisasiEmail(user.Email); email != "" {
+		kunci = append(kunci, "email:"+email)
+	}
+	if telepon := normalisasiTelepon(user.Phone); len(telepon) >= 8 {
+		kunci = append(kunci, "phone:"+telepon)
+	}
+	for _, kata := range strings.Fields(fonetikNama(user.NmUser)) {
+		if len([]rune(kata)) >= 3 {
+			kunci = append(kunci, "nama:"+kata)
+		}
+	}
+	return kunci
+}
+
+// Cari pasangan user aktif yang kemungkinan duplikat dengan skor minimal skorMinimum
+func cariDuplikat(ctx context.Context, skorMinimum float64) ([]model.User, [][2]int, error) {
+	opts := options.Find().SetProjection(bson.M{"pass": 0, "token": 0, "pass_2": 0, "search_ngram": 0})
+	cursor, err := userCollection.Find(ctx, bson.M{"deleted_at": nil}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	users := []model.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, nil, err
+	}
+
+	blok := map[string][]int{}
+	for i, user := range users {
+		for _, kunci := range kunciBlokDuplikat(user) {
+			blok[kunci] = append(blok[kunci], i)
+		}
+	}
+
+	sudah := map[[2]int]bool{}
+	pasangan := [][2]int{}
+	for kunci, anggota := range blok {
+		if strings.HasPrefix(kunci, "nama:") && len(anggota) > batasBlokDuplikat {
+			continue
+		}
+		for x := 0; x < len(anggota); x++ {
+			for y := x + 1; y < len(anggota); y++ {
+				pasang := [2]int{anggota[x], anggota[y]}
+				if sudah[pasang] {
+					continue
+				}
+				sudah[pasang] = true
+				if skor, _, _ := skorDuplikat(users[pasang[0]], users[pasang[1]]); skor >= skorMinimum {
+					pasangan = append(pasangan, pasang)
+				}
+			}
+		}
+	}
+	return users, pasangan, nil
+}
+
+// GetDuplicateUsers - Laporan pasangan user aktif yang kemungkinan adalah orang yang sama,
+// berdasarkan email, nomor telepon dan kemiripan nama
+func GetDuplicateUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	skorMinimum := skorMinimumDuplikat
+	if nilai := c.Query("min_score"); nilai != "" {
+		skor, err := strconv.ParseFloat(nilai, 64)
+		if err != nil || skor <= 0 || skor > 1 {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "min_score must be a number between 0 and 1"})
+		}
+		skorMinimum = skor
+	}
+	limit := limitHalamanDefault
+	if nilai := c.Query("limit"); nilai != "" {
+		n, err := strconv.Atoi(nilai)
+		if err != nil || n < 1 {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "limit must be a positive number"})
+		}
+		if n > limitHalamanMaks {
+			n = limitHalamanMaks
+		}
+		limit = n
+	}
+	opsi, err := parseOpsiUser(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	users, pasangan, err := cariDuplikat(ctx, skorMinimum)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch users: " + err.Error()})
+	}
+
+	hasil := make([]pasanganDuplikat, 0, len(pasangan))
+	anggota := make([][2]model.User, 0, len(pasangan))
+	for _, pasang := range pasangan {
+		a, b := users[pasang[0]], users[pasang[1]]
+		skor, alasan, nama := skorDuplikat(a, b)
+		hasil = append(hasil, pasanganDuplikat{Skor: skor, Alasan: alasan, KemiripanNama: nama, SaranKeep: saranKeep(a, b).ID.Hex()})
+		anggota = append(anggota, [2]model.User{a, b})
+	}
+	urutan := make([]int, len(hasil))
+	for i := range urutan {
+		urutan[i] = i
+	}
+	sort.SliceStable(urutan, func(i, j int) bool {
+		if hasil[urutan[i]].Skor != hasil[urutan[j]].Skor {
+			return hasil[urutan[i]].Skor > hasil[urutan[j]].Skor
+		}
+		return hasil[urutan[i]].SaranKeep < hasil[urutan[j]].SaranKeep
+	})
+
+	total := len(urutan)
+	if len(urutan) > limit {
+		urutan = urutan[:limit]
+	}
+
+	// Representasi user hanya dibuat untuk pasangan yang dikirim
+	halaman := make([]pasanganDuplikat, 0, len(urutan))
+	for _, i := range urutan {
+		item := hasil[i]
+		for _, user := range anggota[i] {
+			dto, err := opsi.representasi(ctx, user)
+			if err != nil {
+				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+			item.Users = append(item.Users, dto)
+		}
+		halaman = append(halaman, item)
+	}
+	return c.JSON(fiber.Map{
+		"duplicates":  halaman,
+		"total_count": total,
+		"min_score":   skorMinimum,
+	})
+}
+
+// Ringkasan perubahan saat dua user digabung
+type hasilMerge struct {
+	UserModul        int64    `json:"usermodul"`         // Dokumen usermodul yang dipindahkan ke user yang dipertahankan
+	Grup             int64    `json:"grup"`              // Keanggotaan grup yang dipindahkan
+	DenyDihapus      int64    `json:"deny_removed"`      // Deny milik user yang digabung, tidak dibawa agar grant tetap gabungan keduanya
+	PengumumanStatus int64    `json:"pengumuman_status"` // Status baca pengumuman yang dipindahkan
+	Favorit          int      `json:"favorit"`           // Modul favorit yang ditambahkan
+	Preferensi       bool     `json:"preferensi"`        // Preferensi dipindahkan karena user yang dipertahankan belum punya
+	Fields           []string `json:"fields"`            // Field yang disalin dari user yang digabung
+}
+
+// Rencana merge dihitung lebih dulu, dipakai untuk dry_run maupun merge sebenarnya
+func rencanaMerge(ctx context.Context, keep, merge model.User, fields []string) (hasilMerge, error) {
+	hasil := hasilMerge{Fields: []string{}}
+	var err error
+	if hasil.UserModul, err = UserModulCollection.CountDocuments(ctx, bson.M{"user_id": merge.ID, "catatan": bson.M{"$ne": CatatanBundle}}); err != nil {
+		return hasil, err
+	}
+	if hasil.Grup, err = GrupCollection.CountDocuments(ctx, bson.M{"user_id": merge.ID}); err != nil {
+		return hasil, err
+	}
+	if hasil.DenyDihapus, err = UserModulDenyCollection.CountDocuments(ctx, bson.M{"user_id": merge.ID}); err != nil {
+		return hasil, err
+	}
+	if hasil.PengumumanStatus, err = PengumumanStatusCollection.CountDocuments(ctx, bson.M{"user_id": merge.ID}); err != nil {
+		return hasil, err
+	}
+
+	var prefKeep, prefMerge model.UserModulPref
+	if err := UserModulPrefCollection.FindOne(ctx, bson.M{"user_id": keep.ID}).Decode(&prefKeep); err != nil && err != mongo.ErrNoDocuments {
+		return hasil, err
+	}
+	if err := UserModulPrefCollection.FindOne(ctx, bson.M{"user_id": merge.ID}).Decode(&prefMerge); err != nil && err != mongo.ErrNoDocuments {
+		return hasil, err
+	}
+	for _, modulID := range prefMerge.Favorit {
+		if !slices.Contains(prefKeep.Favorit, modulID) {
+			hasil.Favorit++
+		}
+	}
+
+	adaKeep, err := PreferensiCollection.CountDocuments(ctx, bson.M{"user_id": keep.ID})
+	if err != nil {
+		return hasil, err
+	}
+	adaMerge, err := PreferensiCollection.CountDocuments(ctx, bson.M{"user_id": merge.ID})
+	if err != nil {
+		return hasil, err
+	}
+	hasil.Preferensi = adaKeep == 0 && adaMerge > 0
+
+	setKeep := nilaiFieldMerge(merge)
+	lamaKeep := nilaiFieldMerge(keep)
+	for _, field := range fields {
+		if setKeep[field] != lamaKeep[field] {
+			hasil.Fields = append(hasil.Fields, field)
+		}
+	}
+	return hasil, nil
+}
+
+// Nilai field yang bisa disalin saat merge, berdasarkan nama field bson
+func nilaiFieldMerge(user model.User) map[string]interface{} {
+	return map[string]interface{}{
+		"nm_user":       user.NmUser,
+		"phone":         user.Phone,
+		"photo":         user.Photo,
+		"jenis_kelamin": user.JenisKelamin,
+		"jenis_user":    user.JenisUser,
+	}
+}
+
+// Pindahkan semua referensi dari user yang digabung ke user yang dipertahankan.
+// Setiap langkah idempoten sehingga merge yang gagal di tengah bisa diulang,
+// user yang digabung baru diarsipkan di langkah terakhir.
+func jalankanMerge(ctx context.Context, keep, merge model.User, rencana hasilMerge, actor string) error {
+	// jenis_user dipindahkan lebih dulu karena pindahkanJenisUser mengeluarkan user
+	// dari dokumen usermodul jenis lama, grant dari user yang digabung harus masuk setelahnya
+	for _, field := range rencana.Fields {
+		if field != "jenis_user" {
+			continue
+		}
+		jenisUser, err := cariJenisUser(ctx, merge.JenisUser)
+		if err != nil {
+			return err
+		}
+		if err := pindahkanJenisUser(ctx, keep.ID, jenisUser, actor); err != nil {
+			return err
+		}
+	}
+
+	// Grant usermodul: user yang dipertahankan ditambahkan ke setiap dokumen milik user yang digabung,
+	// lalu user yang digabung dikeluarkan dari semua dokumen termasuk bundle jenis_user-nya
+	filterGrant := bson.M{"user_id": merge.ID, "catatan": bson.M{"$ne": CatatanBundle}}
+	idGrant := idDokumen(ctx, UserModulCollection, bson.M{"user_id": merge.ID})
+	if _, err := UserModulCollection.UpdateMany(ctx, filterGrant, bson.M{"$addToSet": bson.M{"user_id": keep.ID}, "$inc": naikkanVersi}); err != nil {
+		return fmt.Errorf("failed to copy module grants: %w", err)
+	}
+	if _, err := UserModulCollection.UpdateMany(ctx, bson.M{"user_id": merge.ID}, bson.M{"$pull": bson.M{"user_id": merge.ID}, "$inc": naikkanVersi}); err != nil {
+		return fmt.Errorf("failed to remove merged user from module grants: %w", err)
+	}
+	catatHistori(ctx, UserModulCollection, AksiMerge, actor, idGrant...)
+
+	// Keanggotaan grup
+	now := time.Now()
+	if _, err := GrupCollection.UpdateMany(ctx, bson.M{"user_id": merge.ID}, bson.M{"$addToSet": bson.M{"user_id": keep.ID}, "$set": bson.M{"updated_at": now}}); err != nil {
+		return fmt.Errorf("failed to copy group memberships: %w", err)
+	}
+	if _, err := GrupCollection.UpdateMany(ctx, bson.M{"user_id": merge.ID}, bson.M{"$pull": bson.M{"user_id": merge.ID}}); err != nil {
+		return fmt.Errorf("failed to remove merged user from groups: %w", err)
+	}
+
+	// Deny tidak dibawa, user yang dipertahankan mendapat gabungan grant keduanya
+	if _, err := UserModulDenyCollection.DeleteMany(ctx, bson.M{"user_id": merge.ID}); err != nil {
+		return fmt.Errorf("failed to remove denies: %w", err)
+	}
+
+	// Status pengumuman: yang sudah dimiliki user yang dipertahankan tidak ditimpa
+	sudahDibaca, err := PengumumanStatusCollection.Distinct(ctx, "pengumuman_id", bson.M{"user_id": keep.ID})
+	if err != nil {
+		return err
+	}
+	if _, err := PengumumanStatusCollection.DeleteMany(ctx, bson.M{"user_id": merge.ID, "pengumuman_id": bson.M{"$in": sudahDibaca}}); err != nil {
+		return err
+	}
+	if _, err := PengumumanStatusCollection.UpdateMany(ctx, bson.M{"user_id": merge.ID}, bson.M{"$set": bson.M{"user_id": keep.ID}}); err != nil {
+		return fmt.Errorf("failed to move announcement status: %w", err)
+	}
+
+	// Favorit: modul favorit user yang digabung ditambahkan di belakang favorit user yang dipertahankan
+	var prefMerge model.UserModulPref
+	err = UserModulPrefCollection.FindOne(ctx, bson.M{"user_id": merge.ID}).Decode(&prefMerge)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if err == nil {
+		_, err = UserModulPrefCollection.UpdateOne(
+			ctx,
+			bson.M{"user_id": keep.ID},
+			bson.M{
+				"$addToSet":    bson.M{"favorit": bson.M{"$each": prefMerge.Favorit}},
+				"$set":         bson.M{"updated_at": now},
+				"$setOnInsert": bson.M{"terakhir": []model.ModulTerakhir{}},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to move favorites: %w", err)
+		}
+		if _, err := UserModulPrefCollection.DeleteOne(ctx, bson.M{"user_id": merge.ID}); err != nil {
+			return err
+		}
+	}
+
+	// Preferensi hanya dipindahkan jika user yang dipertahankan belum pernah menyimpannya
+	if rencana.Preferensi {
+		if _, err := PreferensiCollection.UpdateOne(ctx, bson.M{"user_id": merge.ID}, bson.M{"$set": bson.M{"user_id": keep.ID}}); err != nil {
+			return fmt.Errorf("failed to move preferences: %w", err)
+		}
+	}
+	if _, err := PreferensiCollection.DeleteMany(ctx, bson.M{"user_id": merge.ID}); err != nil {
+		return err
+	}
+
+	// Field yang dipilih disalin ke user yang dipertahankan
+	set := bson.M{}
+	nilai := nilaiFieldMerge(merge)
+	for _, field := range rencana.Fields {
+		if field != "jenis_user" {
+			set[field] = nilai[field]
+		}
+	}
+	if len(set) > 0 {
+		var user model.User
+		err := userCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"_id": keep.ID},
+			bson.M{"$set": set, "$inc": naikkanVersi},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if err != nil {
+			return fmt.Errorf("failed to update kept user: %w", err)
+		}
+		if err := perbaruiNgramUser(ctx, user); err != nil {
+			fmt.Println("Error updating search index:", err)
+		}
+		catatHistori(ctx, userCollection, AksiMerge, actor, keep.ID)
+	}
+
+	// Arsipkan user yang digabung
+	deletedAt := primitive.NewDateTimeFromTime(now)
+	_, err = userCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": merge.ID, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{"deleted_at": deletedAt, "deleted_by": actor, "merged_into": keep.ID},
+			"$inc": naikkanVersi,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to archive merged user: %w", err)
+	}
+	catatHistori(ctx, userCollection, AksiMerge, actor, merge.ID)
+	return nil
+}
+
+// MergeUsers - Gabungkan dua akun milik orang yang sama. User "merge" diarsipkan (soft delete
+// dengan merged_into), grant usermodul, grup, status pengumuman dan favoritnya dipindahkan
+// ke user "keep". Dengan dry_run=true hanya ringkasan perubahan yang dikembalikan.
+func MergeUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var body struct {
+		Keep   string   `json:"keep" validate:"required"`
+		Merge  string   `json:"merge" validate:"required"`
+		Fields []string `json:"fields"` // Field user "merge" yang menggantikan milik user "keep"
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	keepID, err := primitive.ObjectIDFromHex(body.Keep)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid keep user ID"})
+	}
+	mergeID, err := primitive.ObjectIDFromHex(body.Merge)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid merge user ID"})
+	}
+	if keepID == mergeID {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Cannot merge a user into itself"})
+	}
+	for _, field := range body.Fields {
+		if !fieldMergeUser[field] {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Field cannot be merged: " + field})
+		}
+	}
+
+	dryRun := false
+	if nilai := c.Query("dry_run"); nilai != "" {
+		if dryRun, err = strconv.ParseBool(nilai); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "dry_run must be true or false"})
+		}
+	}
+
+	var keep, merge model.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": keepID, "deleted_at": nil}).Decode(&keep); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Keep user not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := userCollection.FindOne(ctx, bson.M{"_id": mergeID, "deleted_at": nil}).Decode(&merge); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Merge user not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rencana, err := rencanaMerge(ctx, keep, merge, body.Fields)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to plan merge: " + err.Error()})
+	}
+	skor, alasan, _ := skorDuplikat(keep, merge)
+	if dryRun {
+		return c.JSON(fiber.Map{
+			"dry_run": true,
+			"score":   skor,
+			"reasons": alasan,
+			"merged":  rencana,
+		})
+	}
+
+	if err := jalankanMerge(ctx, keep, merge, rencana, aktorDari(c)); err != nil {
+		return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": "Failed to merge users: " + err.Error()})
+	}
+
+	var user model.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": keepID}).Decode(&user); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching merged user: " + err.Error()})
+	}
+	c.Set(fiber.HeaderETag, etagVersi(user.Version))
+	return c.JSON(fiber.Map{
+		"message": "Users merged successfully",
+		"user":    responses.NewUserDTO(user),
+		"merged":  rencana,
+	})
+}
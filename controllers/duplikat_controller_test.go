@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"demoapp/model"
+	"reflect"
+	"testing"
+)
+
+func TestSkorDuplikat(t *testing.T) {
+	tests := []struct {
+		nama       string
+		a, b       model.User
+		wantSkor   float64
+		wantAlasan []string
+		wantNama   float64
+	}{
+		{
+			nama:       "email sama beda huruf besar",
+			a:          model.User{Email: "Budi@Unair.ac.id", NmUser: "Budi Santoso"},
+			b:          model.User{Email: " budi@unair.ac.id", NmUser: "Wati Lestari"},
+			wantSkor:   0.6,
+			wantAlasan: []string{"email"},
+			wantNama:   0,
+		},
+		{
+			nama:       "email gmail dengan titik dan tag",
+			a:          model.User{Email: "budi.santoso+kampus@gmail.com"},
+			b:          model.User{Email: "budisantoso@googlemail.com"},
+			wantSkor:   0.6,
+			wantAlasan: []string{"email"},
+		},
+		{
+			nama:       "titik di domain lain tetap dibedakan",
+			a:          model.User{Email: "budi.santoso@unair.ac.id"},
+			b:          model.User{Email: "budisantoso@unair.ac.id"},
+			wantSkor:   0,
+			wantAlasan: []string{},
+		},
+		{
+			nama:       "telepon dengan awalan 62",
+			a:          model.User{Phone: "+62 812-3456-7890"},
+			b:          model.User{Phone: "081234567890"},
+			wantSkor:   0.4,
+			wantAlasan: []string{"phone"},
+		},
+		{
+			nama:       "telepon terlalu pendek diabaikan",
+			a:          model.User{Phone: "12345"},
+			b:          model.User{Phone: "12345"},
+			wantSkor:   0,
+			wantAlasan: []string{},
+		},
+		{
+			nama:       "nama dengan ejaan lama",
+			a:          model.User{NmUser: "Yusuf Hidayat"},
+			b:          model.User{NmUser: "Jusuf Hidayat"},
+			wantSkor:   0.4,
+			wantAlasan: []string{"nm_user"},
+			wantNama:   1,
+		},
+		{
+			nama:       "semua cocok dibatasi 1",
+			a:          model.User{Email: "budi@unair.ac.id", Phone: "081234567890", NmUser: "Budi Santoso"},
+			b:          model.User{Email: "budi@unair.ac.id", Phone: "6281234567890", NmUser: "budi santoso"},
+			wantSkor:   1,
+			wantAlasan: []string{"email", "phone", "nm_user"},
+			wantNama:   1,
+		},
+		{
+			nama:       "email kosong tidak dianggap sama",
+			a:          model.User{NmUser: "Budi Santoso"},
+			b:          model.User{NmUser: "Wati Lestari"},
+			wantSkor:   0,
+			wantAlasan: []string{},
+			wantNama:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			skor, alasan, nama := skorDuplikat(tt.a, tt.b)
+			if skor != tt.wantSkor {
+				t.Errorf("skor = %v, want %v", skor, tt.wantSkor)
+			}
+			if !reflect.DeepEqual(alasan, tt.wantAlasan) {
+				t.Errorf("alasan = %v, want %v", alasan, tt.wantAlasan)
+			}
+			if nama != tt.wantNama {
+				t.Errorf("kemiripan nama = %v, want %v", nama, tt.wantNama)
+			}
+		})
+	}
+}
+
+func TestSkorDuplikatSimetris(t *testing.T) {
+	a := model.User{Email: "budi@unair.ac.id", Phone: "081234567890", NmUser: "Budi Santoso"}
+	b := model.User{Email: "budi@gmail.com", Phone: "081234567890", NmUser: "Budi Santosa"}
+	skorAB, alasanAB, namaAB := skorDuplikat(a, b)
+	skorBA, alasanBA, namaBA := skorDuplikat(b, a)
+	if skorAB != skorBA || namaAB != namaBA || !reflect.DeepEqual(alasanAB, alasanBA) {
+		t.Errorf("skorDuplikat not symmetric: %v %v %v vs %v %v %v", skorAB, alasanAB, namaAB, skorBA, alasanBA, namaBA)
+	}
+}
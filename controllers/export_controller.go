@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"demoapp/model"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Batas waktu satu export, termasuk waktu streaming ke client
+const batasWaktuEkspor = 10 * time.Minute
+
+// Field user yang boleh diexport, dalam urutan kolom bawaan.
+// Password, token dan pass_2 sengaja tidak ada di daftar ini.
+var fieldEkspor = []string{"id", "username", "nm_user", "email", "role", "status", "jenis_user", "jenis_kelamin", "phone", "photo", "created_at"}
+
+// Nama field export ke nama field di dokumen MongoDB
+func bsonFieldEkspor(field string) string {
+	if field == "id" {
+		return "_id"
+	}
+	return field
+}
+
+// Baca daftar field dari ?fields=, hanya field di fieldEkspor yang diterima
+func parseFieldEkspor(nilai string) ([]string, error) {
+	if strings.TrimSpace(nilai) == "" {
+		return fieldEkspor, nil
+	}
+	diizinkan := map[string]bool{}
+	for _, field := range fieldEkspor {
+		diizinkan[field] = true
+	}
+
+	fields := []string{}
+	sudah := map[string]bool{}
+	for _, field := range strings.Split(nilai, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || sudah[field] {
+			continue
+		}
+		if !diizinkan[field] {
+			return nil, fmt.Errorf("field cannot be exported: %s", field)
+		}
+		sudah[field] = true
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields selected")
+	}
+	return fields, nil
+}
+
+// Nilai field dalam bentuk yang bisa ditulis ke JSON, CSV maupun XLSX
+func nilaiEkspor(dokumen bson.Raw, field string) interface{} {
+	rv, err := dokumen.LookupErr(bsonFieldEkspor(field))
+	if err != nil {
+		// User lama tanpa field status dianggap active
+		if field == "status" {
+			return model.StatusActive
+		}
+		return nil
+	}
+	switch rv.Type {
+	case bsontype.ObjectID:
+		return rv.ObjectID().Hex()
+	case bsontype.DateTime:
+		return rv.Time().UTC().Format(time.RFC3339)
+	case bsontype.String:
+		return rv.StringValue()
+	case bsontype.Int32:
+		return rv.Int32()
+	case bsontype.Int64:
+		return rv.Int64()
+	case bsontype.Double:
+		return rv.Double()
+	case bsontype.Boolean:
+		return rv.Boolean()
+	case bsontype.Null, bsontype.Undefined:
+		return nil
+	}
+	return rv.String()
+}
+
+// Teks sel CSV. Nilai yang diawali karakter formula diberi tanda kutip
+// agar tidak dijalankan sebagai rumus saat dibuka di spreadsheet.
+func selCSV(nilai interface{}) string {
+	if nilai == nil {
+		return ""
+	}
+	teks := fmt.Sprint(nilai)
+	if teks != "" && strings.ContainsRune("=+-@", rune(teks[0])) {
+		if _, err := strconv.ParseFloat(teks, 64); err != nil {
+			return "'" + teks
+		}
+	}
+	return teks
+}
+
+// Tulis hasil cursor sebagai CSV
+func tulisCSV(ctx context.Context, w *bufio.Writer, cursor *mongo.Cursor, fields []string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(fields); err != nil {
+		return err
+	}
+	baris := make([]string, len(fields))
+	for cursor.Next(ctx) {
+		for i, field := range fields {
+			baris[i] = selCSV(nilaiEkspor(cursor.Current, field))
+		}
+		if err := writer.Write(baris); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return cursor.Err()
+}
+
+// Tulis hasil cursor sebagai NDJSON, satu user per baris
+func tulisNDJSON(ctx context.Context, w *bufio.Writer, cursor *mongo.Cursor, fields []string) error {
+	encoder := json.NewEncoder(w)
+	for cursor.Next(ctx) {
+		baris := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			baris[field] = nilaiEkspor(cursor.Current, field)
+		}
+		if err := encoder.Encode(baris); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Tulis hasil cursor sebagai XLSX. StreamWriter excelize menyimpan baris ke berkas sementara
+// saat data besar, sehingga memori tetap terbatas.
+func tulisXLSX(ctx context.Context, w *bufio.Writer, cursor *mongo.Cursor, fields []string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(fields))
+	for i, field := range fields {
+		header[i] = field
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	nomor := 2
+	for cursor.Next(ctx) {
+		baris := make([]interface{}, len(fields))
+		for i, field := range fields {
+			baris[i] = nilaiEkspor(cursor.Current, field)
+		}
+		sel, err := excelize.CoordinatesToCellName(1, nomor)
+		if err != nil {
+			return err
+		}
+		if err := stream.SetRow(sel, baris); err != nil {
+			return err
+		}
+		nomor++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// ExportUsers - Export user dengan filter dan sort yang sama seperti daftar user.
+// Format: ?format=csv|xlsx|ndjson, kolom dipilih lewat ?fields=username,nm_user,...
+// Data dikirim secara streaming langsung dari cursor MongoDB.
+func ExportUsers(c *fiber.Ctx) error {
+	format := strings.ToLower(c.Query("format", "csv"))
+	var contentType string
+	var tulis func(context.Context, *bufio.Writer, *mongo.Cursor, []string) error
+	switch format {
+	case "csv":
+		contentType, tulis = "text/csv; charset=utf-8", tulisCSV
+	case "ndjson":
+		contentType, tulis = "application/x-ndjson", tulisNDJSON
+	case "xlsx":
+		contentType, tulis = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", tulisXLSX
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "format must be csv, xlsx or ndjson"})
+	}
+
+	fields, err := parseFieldEkspor(c.Query("fields"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	halaman, err := parseHalaman(c, userSortFields, "_id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Hanya field yang dipilih yang diambil dari database
+	projection := bson.M{"_id": 0}
+	for _, field := range fields {
+		projection[bsonFieldEkspor(field)] = 1
+	}
+
+	// Context dibatalkan oleh stream writer setelah selesai menulis, bukan saat handler kembali
+	ctx, cancel := context.WithTimeout(context.Background(), batasWaktuEkspor)
+	opts := options.Find().
+		SetSort(bsonUrutan(halaman.Urutan, false)).
+		SetProjection(projection).
+		SetBatchSize(500)
+	cursor, err := userCollection.Find(ctx, filter, opts)
+	if err != nil {
+		cancel()
+		fmt.Println("Error exporting users:", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch users from DB"})
+	}
+
+	namaBerkas := fmt.Sprintf("users-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+namaBerkas+`"`)
+	c.Status(http.StatusOK)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer cursor.Close(ctx)
+
+		// Status sudah terkirim, error di tengah stream hanya bisa dicatat
+		if err := tulis(ctx, w, cursor, fields); err != nil {
+			fmt.Println("Error streaming user export:", err)
+		}
+		if err := w.Flush(); err != nil {
+			fmt.Println("Error flushing user export:", err)
+		}
+	})
+	return nil
+}
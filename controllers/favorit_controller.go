@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Jumlah maksimum riwayat modul terakhir yang disimpan per user
+const batasModulTerakhir = 10
+
+// Catat modul yang baru dibuka di posisi paling depan riwayat. Entri lama modul yang sama dibuang
+// dalam satu update pipeline, sehingga dua pembukaan bersamaan tidak bisa menghasilkan entri dobel.
+func catatModulTerakhir(ctx context.Context, userID primitive.ObjectID, modulID primitive.ObjectID) error {
+	now := time.Now()
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"terakhir": bson.M{"$slice": bson.A{
+				bson.M{"$concatArrays": bson.A{
+					bson.A{model.ModulTerakhir{ModulID: modulID, DibukaPada: now}},
+					bson.M{"$filter": bson.M{
+						"input": bson.M{"$ifNull": bson.A{"$terakhir", bson.A{}}},
+						"cond":  bson.M{"$ne": bson.A{"$$this.modul_id", modulID}},
+					}},
+				}},
+				batasModulTerakhir,
+			}},
+			"favorit":    bson.M{"$ifNull": bson.A{"$favorit", bson.A{}}},
+			"updated_at": now,
+		}}},
+	}
+
+	_, err := UserModulPrefCollection.UpdateOne(ctx, bson.M{"user_id": userID}, pipeline, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// Upsert bersamaan kalah oleh index user_id_unique, dokumennya sekarang sudah ada
+		_, err = UserModulPrefCollection.UpdateOne(ctx, bson.M{"user_id": userID}, pipeline)
+	}
+	return err
+}
+
+// Urutkan modul: favorit lebih dulu sesuai urutan pin, sisanya tetap sesuai urutan modul
+func urutkanFavorit(moduls []model.Modul, favorit []primitive.ObjectID) []model.Modul {
+	perID := map[primitive.ObjectID]model.Modul{}
+	for _, modul := range moduls {
+		perID[modul.ID] = modul
+	}
+
+	hasil := make([]model.Modul, 0, len(moduls))
+	sudah := map[primitive.ObjectID]bool{}
+	for _, modulID := range favorit {
+		if modul, ok := perID[modulID]; ok && !sudah[modulID] {
+			hasil = append(hasil, modul)
+			sudah[modulID] = true
+		}
+	}
+	for _, modul := range moduls {
+		if !sudah[modul.ID] {
+			hasil = append(hasil, modul)
+		}
+	}
+	return hasil
+}
+
+// Pastikan modul termasuk modul efektif milik user
+func modulDiizinkan(ctx context.Context, user model.User, modulIDs []primitive.ObjectID) (bool, error) {
+	moduls, err := modulEfektif(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	efektif := map[primitive.ObjectID]bool{}
+	for _, modul := range moduls {
+		efektif[modul.ID] = true
+	}
+	for _, modulID := range modulIDs {
+		if !efektif[modulID] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// GetMyFavorites - Daftar modul favorit sesuai urutan pin
+func GetMyFavorites(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	pref, err := prefModulUser(ctx, user.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch module preferences"})
+	}
+
+	moduls, err := modulEfektif(ctx, user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
+	}
+
+	// Favorit yang modulnya sudah tidak bisa diakses tidak ditampilkan
+	perID := map[primitive.ObjectID]model.Modul{}
+	for _, modul := range moduls {
+		perID[modul.ID] = modul
+	}
+	favorit := []model.Modul{}
+	for _, modulID := range pref.Favorit {
+		if modul, ok := perID[modulID]; ok {
+			favorit = append(favorit, modul)
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"favorit":     favorit,
+		"total_count": len(favorit),
+	})
+}
+
+// PinMyModule - Tambahkan modul ke favorit, diletakkan di urutan terakhir
+func PinMyModule(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modulID, err := primitive.ObjectIDFromHex(c.Params("modulId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID"})
+	}
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	ok, err := modulDiizinkan(ctx, user, []primitive.ObjectID{modulID})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
+	}
+	if !ok {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "Access denied: module is not granted"})
+	}
+
+	_, err = UserModulPrefCollection.UpdateOne(
+		ctx,
+		bson.M{"user_id": user.ID},
+		bson.M{
+			"$addToSet":    bson.M{"favorit": modulID},
+			"$set":         bson.M{"updated_at": time.Now()},
+			"$setOnInsert": bson.M{"terakhir": []model.ModulTerakhir{}},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to pin module"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Module pinned successfully"})
+}
+
+// UnpinMyModule - Hapus modul dari favorit
+func UnpinMyModule(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modulID, err := primitive.ObjectIDFromHex(c.Params("modulId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID"})
+	}
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	_, err = UserModulPrefCollection.UpdateOne(
+		ctx,
+		bson.M{"user_id": user.ID},
+		bson.M{
+			"$pull": bson.M{"favorit": modulID},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to unpin module"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Module unpinned successfully"})
+}
+
+// ReorderMyFavorites - Atur ulang urutan favorit, body berisi seluruh modul favorit dalam urutan baru
+func ReorderMyFavorites(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var req UserModuleRequest
+	if err := parseAndValidateRequest(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	urutan := parseObjectIDs(req.ModulIDs)
+	if urutan == nil {
+		urutan = []primitive.ObjectID{}
+	}
+
+	// Tidak boleh ada duplikat
+	sudah := map[primitive.ObjectID]bool{}
+	for _, modulID := range urutan {
+		if sudah[modulID] {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Duplicate modul ID: " + modulID.Hex()})
+		}
+		sudah[modulID] = true
+	}
+
+	ok, err := modulDiizinkan(ctx, user, urutan)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
+	}
+	if !ok {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "Access denied: module is not granted"})
+	}
+
+	_, err = UserModulPrefCollection.UpdateOne(
+		ctx,
+		bson.M{"user_id": user.ID},
+		bson.M{
+			"$set":         bson.M{"favorit": urutan, "updated_at": time.Now()},
+			"$setOnInsert": bson.M{"terakhir": []model.ModulTerakhir{}},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reorder favorites"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Favorites reordered successfully", "favorit": urutan})
+}
+
+// GetMyRecentModules - Modul yang terakhir dibuka user, terbaru lebih dulu
+func GetMyRecentModules(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	pref, err := prefModulUser(ctx, user.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch module preferences"})
+	}
+
+	moduls, err := modulEfektif(ctx, user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
+	}
+	perID := map[primitive.ObjectID]model.Modul{}
+	for _, modul := range moduls {
+		perID[modul.ID] = modul
+	}
+
+	terakhir := []fiber.Map{}
+	for _, entri := range pref.Terakhir {
+		if modul, ok := perID[entri.ModulID]; ok {
+			terakhir = append(terakhir, fiber.Map{"modul": modul, "dibuka_pada": entri.DibukaPada})
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"terakhir":    terakhir,
+		"total_count": len(terakhir),
+	})
+}
@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var GrupCollection = config.GetCollection(config.DB, "grup")
+
+// Pastikan semua user_id benar-benar ada di koleksi users
+func cekUserAda(ctx context.Context, userIDs []primitive.ObjectID) error {
+	unik := map[primitive.ObjectID]bool{}
+	for _, id := range userIDs {
+		unik[id] = true
+	}
+	count, err := UserCollection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": userIDs}, "deleted_at": nil})
+	if err != nil {
+		return err
+	}
+	if int(count) != len(unik) {
+		return errors.New("one or more user_id do not exist")
+	}
+	return nil
+}
+
+// Grup yang beranggotakan user tertentu
+func grupUser(ctx context.Context, userID primitive.ObjectID) ([]model.Grup, error) {
+	cursor, err := GrupCollection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	grups := []model.Grup{}
+	if err := cursor.All(ctx, &grups); err != nil {
+		return nil, err
+	}
+	return grups, nil
+}
+
+// Create Grup
+func CreateGrup(c *fiber.Ctx) error {
+	grup := new(model.Grup)
+
+	if err := c.BodyParser(grup); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	// Validasi data
+	if err := validate.Struct(grup); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if grup.UserID == nil {
+		grup.UserID = []primitive.ObjectID{}
+	}
+	if grup.ModulID == nil {
+		grup.ModulID = []primitive.ObjectID{}
+	}
+	if err := cekUserAda(c.Context(), grup.UserID); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := cekModulAda(c.Context(), grup.ModulID); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	grup.ID = primitive.NewObjectID()
+	grup.CreatedAt = time.Now()
+	grup.UpdatedAt = time.Now()
+
+	result, err := GrupCollection.InsertOne(c.Context(), grup)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create grup"})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"message": "Grup created successfully",
+		"id":      result.InsertedID,
+	})
+}
+
+// Get All Grup
+func GetAllGrup(c *fiber.Ctx) error {
+	cursor, err := GrupCollection.Find(c.Context(), bson.M{})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch grup"})
+	}
+	defer cursor.Close(c.Context())
+
+	grups := []model.Grup{}
+	if err := cursor.All(c.Context(), &grups); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse grup"})
+	}
+
+	return c.JSON(grups)
+}
+
+// Get Grup by ID
+func GetGrupByID(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("grupId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var grup model.Grup
+	err = GrupCollection.FindOne(c.Context(), bson.M{"_id": objectID}).Decode(&grup)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Grup not found"})
+	}
+
+	return c.JSON(grup)
+}
+
+// Get grup milik user tertentu
+func GetUserGrups(c *fiber.Ctx) error {
+	userID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	grups, err := grupUser(c.Context(), userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user grup"})
+	}
+
+	return c.JSON(fiber.Map{
+		"user_id":     userID,
+		"grup":        grups,
+		"total_count": len(grups),
+	})
+}
+
+// Update Grup, hanya nama dan keterangan. Anggota dan modul diatur lewat endpoint tersendiri
+func UpdateGrup(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("grupId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var body struct {
+		NmGrup  *string `json:"nm_grup"`
+		KetGrup *string `json:"ket_grup"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	update := bson.M{"updated_at": time.Now()}
+	if body.NmGrup != nil {
+		if *body.NmGrup == "" {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "nm_grup cannot be empty"})
+		}
+		update["nm_grup"] = *body.NmGrup
+	}
+	if body.KetGrup != nil {
+		update["ket_grup"] = *body.KetGrup
+	}
+
+	result, err := GrupCollection.UpdateOne(c.Context(), bson.M{"_id": objectID}, bson.M{"$set": update})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update grup"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Grup not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Grup updated successfully"})
+}
+
+// Delete Grup
+func DeleteGrup(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("grupId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	result, err := GrupCollection.DeleteOne(c.Context(), bson.M{"_id": objectID})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete grup"})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Grup not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Grup deleted successfully"})
+}
+
+// ------------------------------
+// Anggota dan modul grup
+// ------------------------------
+
+// Jalankan $addToSet / $pull pada satu field array grup
+func ubahArrayGrup(c *fiber.Ctx, operator string, field string, ids []primitive.ObjectID) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("grupId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var value interface{} = bson.M{"$each": ids}
+	if operator == "$pull" {
+		value = bson.M{"$in": ids}
+	}
+
+	result, err := GrupCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": objectID},
+		bson.M{
+			operator: bson.M{field: value},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update grup"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Grup not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Grup successfully updated"})
+}
+
+// Tambah anggota grup
+func AddGrupMembers(c *fiber.Ctx) error {
+	var req UserModuleRequest
+	if err := parseAndValidateRequest(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(req.UserIDs) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "user_ids is required"})
+	}
+
+	userIDs := parseObjectIDs(req.UserIDs)
+	if err := cekUserAda(c.Context(), userIDs); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ubahArrayGrup(c, "$addToSet", "user_id", userIDs)
+}
+
+// Keluarkan anggota dari grup
+func RemoveGrupMembers(c *fiber.Ctx) error {
+	var req UserModuleRequest
+	if err := parseAndValidateRequest(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(req.UserIDs) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "user_ids is required"})
+	}
+	return ubahArrayGrup(c, "$pull", "user_id", parseObjectIDs(req.UserIDs))
+}
+
+// Berikan modul ke semua anggota grup
+func AddGrupModules(c *fiber.Ctx) error {
+	var req UserModuleRequest
+	if err := parseAndValidateRequest(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(req.ModulIDs) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "modul_ids is required"})
+	}
+
+	modulIDs := parseObjectIDs(req.ModulIDs)
+	if err := cekModulAda(c.Context(), modulIDs); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ubahArrayGrup(c, "$addToSet", "modul_id", modulIDs)
+}
+
+// Cabut modul dari grup
+func RemoveGrupModules(c *fiber.Ctx) error {
+	var req UserModuleRequest
+	if err := parseAndValidateRequest(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(req.ModulIDs) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "modul_ids is required"})
+	}
+	return ubahArrayGrup(c, "$pull", "modul_id", parseObjectIDs(req.ModulIDs))
+}
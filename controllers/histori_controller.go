@@ -0,0 +1,488 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"demoapp/config"
+	"demoapp/model"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var HistoriCollection = config.GetCollection(config.DB, "histori")
+
+// Actor untuk perubahan dari proses latar seperti purge terjadwal
+const aktorSistem = "system"
+
+// Aksi yang dicatat di histori
+const (
+	AksiCreate   = "create"
+	AksiUpdate   = "update"
+	AksiDelete   = "delete"
+	AksiRestore  = "restore"
+	AksiPassword = "password"
+	AksiPhoto    = "photo"
+	AksiStatus   = "status"
+	AksiPurge    = "purge"
+	AksiRevert   = "revert"
+	AksiMerge    = "merge"
+	AksiBaseline = "baseline" // Keadaan dokumen yang sudah ada sebelum histori dicatat
+)
+
+// Field yang tidak pernah disimpan apa adanya di histori, hanya sidik jarinya
+var fieldRahasiaHistori = map[string]bool{"pass": true, "token": true, "pass_2": true}
+
+// Field turunan yang tidak dicatat di histori
+var fieldAbaikanHistori = map[string]bool{"_id": true, "version": true, "search_ngram": true}
+
+// Koleksi yang memiliki histori beserta field yang boleh dikembalikan lewat revert
+type koleksiHistori struct {
+	Collection *mongo.Collection
+	Revert     map[string]bool
+}
+
+func daftarKoleksiHistori() map[string]koleksiHistori {
+	return map[string]koleksiHistori{
+		"users":          {Collection: userCollection, Revert: userPatchFields},
+		"modul":          {Collection: modulCollection, Revert: modulPatchFields},
+		"usermodul":      {Collection: UserModulCollection, Revert: map[string]bool{"jenis_user": true, "user_id": true, "modul_id": true, "catatan": true, "expired_at": true}},
+		"usermodul_deny": {Collection: UserModulDenyCollection, Revert: map[string]bool{"alasan": true}},
+	}
+}
+
+// Nilai rahasia diganti sidik jari agar perubahan tetap terdeteksi tanpa menyimpan isinya
+func sidikJari(nilai interface{}) string {
+	jumlah := sha256.Sum256([]byte(fmt.Sprint(nilai)))
+	return "[redacted:" + hex.EncodeToString(jumlah[:4]) + "]"
+}
+
+// Isi dokumen yang aman disimpan di histori
+func dataHistori(dokumen bson.M) bson.M {
+	data := bson.M{}
+	for field, nilai := range dokumen {
+		if fieldAbaikanHistori[field] {
+			continue
+		}
+		if fieldRahasiaHistori[field] && nilai != nil && nilai != "" {
+			nilai = sidikJari(nilai)
+		}
+		data[field] = nilai
+	}
+	return data
+}
+
+// Bandingkan dua isi dokumen, field diurutkan agar diff stabil
+func diffHistori(lama, baru bson.M) []model.HistoriDiff {
+	fields := map[string]bool{}
+	for field := range lama {
+		fields[field] = true
+	}
+	for field := range baru {
+		fields[field] = true
+	}
+	urutan := make([]string, 0, len(fields))
+	for field := range fields {
+		urutan = append(urutan, field)
+	}
+	sort.Strings(urutan)
+
+	diff := []model.HistoriDiff{}
+	for _, field := range urutan {
+		if !reflect.DeepEqual(lama[field], baru[field]) {
+			diff = append(diff, model.HistoriDiff{Field: field, Lama: lama[field], Baru: baru[field]})
+		}
+	}
+	return diff
+}
+
+// Versi terakhir yang tercatat untuk satu dokumen
+func historiTerakhir(ctx context.Context, koleksi string, id primitive.ObjectID) (*model.Histori, error) {
+	var histori model.Histori
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	err := HistoriCollection.FindOne(ctx, bson.M{"koleksi": koleksi, "dokumen_id": id}, opts).Decode(&histori)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &histori, nil
+}
+
+// Nilai field version dokumen, dokumen lama tanpa version dianggap versi 0
+func versiDokumen(dokumen bson.M) int64 {
+	switch v := dokumen["version"].(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// Catat keadaan terbaru dokumen ke histori, dipanggil setelah setiap penulisan.
+// Histori hanya pelengkap, kegagalan dicatat ke log tanpa membatalkan penulisan.
+func catatHistori(ctx context.Context, collection *mongo.Collection, aksi string, actor string, ids ...primitive.ObjectID) {
+	if actor == "" {
+		actor = aktorSistem
+	}
+	for _, id := range ids {
+		if err := catatSatuHistori(ctx, collection, aksi, actor, id); err != nil {
+			fmt.Println("Error writing history for", collection.Name(), id.Hex(), ":", err)
+		}
+	}
+}
+
+func catatSatuHistori(ctx context.Context, collection *mongo.Collection, aksi string, actor string, id primitive.ObjectID) error {
+	sebelumnya, err := historiTerakhir(ctx, collection.Name(), id)
+	if err != nil {
+		return err
+	}
+
+	histori := model.Histori{
+		Koleksi:   collection.Name(),
+		DokumenID: id,
+		Aksi:      aksi,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+	}
+	var lama bson.M
+	if sebelumnya != nil {
+		lama = sebelumnya.Data
+		histori.Version = sebelumnya.Version
+	}
+
+	var dokumen bson.M
+	err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&dokumen)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		// Dokumen sudah dihapus permanen, cukup dicatat sekali
+		if sebelumnya != nil && sebelumnya.Dihapus {
+			return nil
+		}
+		histori.Dihapus = true
+		histori.Diff = diffHistori(lama, nil)
+	case err != nil:
+		return err
+	default:
+		histori.Data = dataHistori(dokumen)
+		histori.Version = versiDokumen(dokumen)
+		histori.Diff = diffHistori(lama, histori.Data)
+		// Penulisan yang tidak mengubah isi dokumen tidak perlu versi baru di histori
+		if sebelumnya != nil && !sebelumnya.Dihapus && len(histori.Diff) == 0 {
+			return nil
+		}
+	}
+
+	_, err = HistoriCollection.InsertOne(ctx, histori)
+	return err
+}
+
+// Catat versi baseline untuk dokumen koleksi yang belum punya histori sama sekali, sehingga
+// perubahan pertamanya punya keadaan lama untuk diff dan bisa di-revert. Mengembalikan jumlah baseline.
+func catatBaselineHistori(ctx context.Context, nama string, collection *mongo.Collection) (int, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from": HistoriCollection.Name(),
+			"let":  bson.M{"id": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"koleksi": nama, "$expr": bson.M{"$eq": bson.A{"$dokumen_id", "$$id"}}}},
+				bson.M{"$limit": 1},
+				bson.M{"$project": bson.M{"_id": 1}},
+			},
+			"as": "histori_ada",
+		}}},
+		{{Key: "$match", Value: bson.M{"histori_ada": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"histori_ada": 0}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	jumlah := 0
+	batch := []interface{}{}
+	simpan := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := HistoriCollection.InsertMany(ctx, batch); err != nil {
+			return err
+		}
+		jumlah += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for cursor.Next(ctx) {
+		var dokumen bson.M
+		if err := cursor.Decode(&dokumen); err != nil {
+			return jumlah, err
+		}
+		id, ok := dokumen["_id"].(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		batch = append(batch, model.Histori{
+			Koleksi:   nama,
+			DokumenID: id,
+			Version:   versiDokumen(dokumen),
+			Aksi:      AksiBaseline,
+			Diff:      []model.HistoriDiff{},
+			Data:      dataHistori(dokumen),
+			Actor:     aktorSistem,
+			CreatedAt: time.Now(),
+		})
+		if len(batch) >= 500 {
+			if err := simpan(); err != nil {
+				return jumlah, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return jumlah, err
+	}
+	return jumlah, simpan()
+}
+
+// ID dokumen yang cocok dengan filter, dipakai sebelum update banyak dokumen sekaligus
+func idDokumen(ctx context.Context, collection *mongo.Collection, filter bson.M) []primitive.ObjectID {
+	raw, err := collection.Distinct(ctx, "_id", filter)
+	if err != nil {
+		fmt.Println("Error collecting", collection.Name(), "IDs for history:", err)
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(raw))
+	for _, nilai := range raw {
+		if id, ok := nilai.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Isi histori yang dikirim ke client, field rahasia tidak ikut ditampilkan
+func tampilkanDataHistori(data bson.M) bson.M {
+	if data == nil {
+		return nil
+	}
+	hasil := bson.M{}
+	for field, nilai := range data {
+		if !fieldRahasiaHistori[field] {
+			hasil[field] = nilai
+		}
+	}
+	return hasil
+}
+
+// Baca parameter :koleksi dan :id
+func parseDokumenHistori(c *fiber.Ctx) (string, koleksiHistori, primitive.ObjectID, error) {
+	nama := c.Params("koleksi")
+	koleksi, ok := daftarKoleksiHistori()[nama]
+	if !ok {
+		return nama, koleksi, primitive.NilObjectID, fmt.Errorf("history is only kept for users, modul, usermodul and usermodul_deny")
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nama, koleksi, id, fmt.Errorf("invalid ID")
+	}
+	return nama, koleksi, id, nil
+}
+
+// GetHistori - Riwayat perubahan satu dokumen, terbaru lebih dulu
+func GetHistori(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	nama, _, id, err := parseDokumenHistori(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(200)
+	cursor, err := HistoriCollection.Find(ctx, bson.M{"koleksi": nama, "dokumen_id": id}, opts)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch history"})
+	}
+	historis := []model.Histori{}
+	if err := cursor.All(ctx, &historis); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode history"})
+	}
+	for i := range historis {
+		historis[i].Data = tampilkanDataHistori(historis[i].Data)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"koleksi": nama, "dokumen_id": id, "histori": historis, "total_count": len(historis)})
+}
+
+// GetHistoriAsOf - Isi dokumen pada waktu tertentu, ?at=2024-01-31T10:00:00Z
+func GetHistoriAsOf(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	nama, _, id, err := parseDokumenHistori(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "at must be an RFC3339 timestamp"})
+	}
+
+	var histori model.Histori
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	err = HistoriCollection.FindOne(ctx, bson.M{"koleksi": nama, "dokumen_id": id, "created_at": bson.M{"$lte": at}}, opts).Decode(&histori)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "No recorded version at or before the given time"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch history"})
+	}
+	if histori.Dihapus {
+		return c.Status(http.StatusGone).JSON(fiber.Map{"error": "Record was deleted at the given time", "deleted_at": histori.CreatedAt})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"koleksi":    nama,
+		"dokumen_id": id,
+		"at":         at,
+		"version":    histori.Version,
+		"recorded":   histori.CreatedAt,
+		"data":       tampilkanDataHistori(histori.Data),
+	})
+}
+
+// RevertHistori - Kembalikan field yang bisa diubah ke isi versi tertentu, body {"version": 3}.
+// Hasil revert dicatat sebagai versi baru sehingga revert juga bisa dibatalkan.
+func RevertHistori(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	nama, koleksi, id, err := parseDokumenHistori(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req struct {
+		Version *int64 `json:"version"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Version == nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "version is required"})
+	}
+
+	var target model.Histori
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	err = HistoriCollection.FindOne(ctx, bson.M{"koleksi": nama, "dokumen_id": id, "version": *req.Version, "dihapus": false}, opts).Decode(&target)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Version " + strconv.FormatInt(*req.Version, 10) + " not found in history"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch history"})
+	}
+
+	var sekarang bson.M
+	err = koleksi.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&sekarang)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Record no longer exists and cannot be reverted"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch record"})
+	}
+	versi := versiDokumen(sekarang)
+	if !cocokIfMatch(c, versi) {
+		c.Set(fiber.HeaderETag, etagVersi(versi))
+		return responsVersiBerubah(c)
+	}
+	if nama == "users" && sekarang["deleted_at"] != nil {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "User is deleted, restore it before reverting"})
+	}
+	if nama == "usermodul" && sekarang["catatan"] == CatatanBundle {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Bundle documents are managed by the jenis_user catalog"})
+	}
+
+	set, unset := bson.M{}, bson.M{}
+	for field := range koleksi.Revert {
+		nilai, ada := target.Data[field]
+		if !ada {
+			if _, adaSekarang := sekarang[field]; adaSekarang {
+				unset[field] = ""
+			}
+			continue
+		}
+		if !reflect.DeepEqual(sekarang[field], nilai) {
+			set[field] = nilai
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Record already matches the requested version", "version": versi})
+	}
+
+	// jenis_user user harus terdaftar dan keanggotaan usermodul ikut dipindahkan
+	var jenisUser *model.JenisUser
+	if kode, ok := set["jenis_user"].(string); ok && nama == "users" {
+		found, err := cariJenisUser(ctx, kode)
+		if err != nil {
+			return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		jenisUser = &found
+	}
+
+	update := bson.M{"$inc": naikkanVersi}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	result, err := koleksi.Collection.UpdateOne(ctx, bson.M{"_id": id, "version": syaratVersi(versi)}, update)
+	if field := fieldDuplikatUser(err); field != "" {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": pesanDuplikatUser(field)})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revert record: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		if _, err := versiBerubah(ctx, c, koleksi.Collection, bson.M{"_id": id}); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch record"})
+		}
+		return responsVersiBerubah(c)
+	}
+
+	if jenisUser != nil {
+		if err := pindahkanJenisUser(ctx, id, *jenisUser, aktorDari(c)); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if nama == "users" {
+		var user model.User
+		if err := userCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&user); err == nil {
+			if err := perbaruiNgramUser(ctx, user); err != nil {
+				fmt.Println("Error updating search index:", err)
+			}
+		}
+	}
+	catatHistori(ctx, koleksi.Collection, AksiRevert, aktorDari(c), id)
+
+	terbaru, err := historiTerakhir(ctx, nama, id)
+	if err != nil || terbaru == nil {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Record reverted", "reverted_to": *req.Version})
+	}
+	c.Set(fiber.HeaderETag, etagVersi(terbaru.Version))
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":     "Record reverted",
+		"reverted_to": *req.Version,
+		"version":     terbaru.Version,
+		"data":        tampilkanDataHistori(terbaru.Data),
+	})
+}
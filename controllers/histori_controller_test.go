@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"demoapp/model"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiffHistori(t *testing.T) {
+	modulID := primitive.NewObjectID()
+	tests := []struct {
+		nama string
+		lama bson.M
+		baru bson.M
+		want []model.HistoriDiff
+	}{
+		{
+			nama: "dokumen sama",
+			lama: bson.M{"username": "budi", "version": int64(1)},
+			baru: bson.M{"username": "budi", "version": int64(1)},
+			want: []model.HistoriDiff{},
+		},
+		{
+			nama: "dokumen baru dibuat",
+			lama: nil,
+			baru: bson.M{"username": "budi", "email": "budi@unair.ac.id"},
+			want: []model.HistoriDiff{
+				{Field: "email", Baru: "budi@unair.ac.id"},
+				{Field: "username", Baru: "budi"},
+			},
+		},
+		{
+			nama: "field berubah diurutkan berdasarkan nama",
+			lama: bson.M{"version": int64(1), "nm_user": "Budi", "email": "budi@unair.ac.id"},
+			baru: bson.M{"version": int64(2), "nm_user": "Budi Santoso", "email": "budi@unair.ac.id"},
+			want: []model.HistoriDiff{
+				{Field: "nm_user", Lama: "Budi", Baru: "Budi Santoso"},
+				{Field: "version", Lama: int64(1), Baru: int64(2)},
+			},
+		},
+		{
+			nama: "field dihapus",
+			lama: bson.M{"username": "budi", "phone": "0812"},
+			baru: bson.M{"username": "budi"},
+			want: []model.HistoriDiff{{Field: "phone", Lama: "0812"}},
+		},
+		{
+			nama: "field null sama dengan field yang tidak ada",
+			lama: bson.M{"username": "budi"},
+			baru: bson.M{"username": "budi", "deleted_at": nil},
+			want: []model.HistoriDiff{},
+		},
+		{
+			nama: "array dibandingkan isinya",
+			lama: bson.M{"modul_id": bson.A{modulID}},
+			baru: bson.M{"modul_id": bson.A{modulID}},
+			want: []model.HistoriDiff{},
+		},
+		{
+			nama: "array berubah",
+			lama: bson.M{"modul_id": bson.A{modulID}},
+			baru: bson.M{"modul_id": bson.A{}},
+			want: []model.HistoriDiff{{Field: "modul_id", Lama: bson.A{modulID}, Baru: bson.A{}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := diffHistori(tt.lama, tt.baru); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffHistori() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersiDokumen(t *testing.T) {
+	tests := []struct {
+		nama    string
+		dokumen bson.M
+		want    int64
+	}{
+		{"int32", bson.M{"version": int32(3)}, 3},
+		{"int64", bson.M{"version": int64(4)}, 4},
+		{"float64", bson.M{"version": float64(5)}, 5},
+		{"tanpa version", bson.M{"username": "budi"}, 0},
+		{"version null", bson.M{"version": nil}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := versiDokumen(tt.dokumen); got != tt.want {
+				t.Errorf("versiDokumen() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
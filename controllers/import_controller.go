@@ -0,0 +1,973 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"demoapp/config"
+	"demoapp/model"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ImportJobCollection = config.GetCollection(config.DB, "import_job")
+
+// Status job import
+const (
+	StatusImportPending   = "pending"
+	StatusImportRunning   = "running"
+	StatusImportCompleted = "completed"
+	StatusImportFailed    = "failed"
+)
+
+const (
+	direktoriImport     = "./storage/import"
+	panjangPasswordAwal = 10
+	// Jumlah maksimum error baris yang disimpan di dokumen job
+	batasErrorImport = 1000
+	// Role untuk baris yang tidak punya kolom role
+	roleImportDefault = "user"
+	// Lama password awal disimpan di dokumen job sebelum dihapus walaupun belum diambil
+	masaKredensialImport = 7 * 24 * time.Hour
+)
+
+// Karakter password awal, tanpa huruf/angka yang mudah tertukar (0/O, 1/l/I)
+const karakterPassword = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Field user yang bisa diisi dari berkas import
+var fieldImport = map[string]bool{
+	"username":      true,
+	"nm_user":       true,
+	"password":      true,
+	"email":         true,
+	"role":          true,
+	"jenis_kelamin": true,
+	"phone":         true,
+	"jenis_user":    true,
+}
+
+// Kolom yang wajib ada di berkas, field lain punya nilai bawaan
+var fieldImportWajib = []string{"username", "nm_user", "email", "phone", "jenis_kelamin"}
+
+// Nama kolom yang sering dipakai di berkas dari bagian akademik
+var aliasKolomImport = map[string]string{
+	"nim":          "username",
+	"nama":         "nm_user",
+	"nama_lengkap": "nm_user",
+	"pass":         "password",
+	"hp":           "phone",
+	"no_hp":        "phone",
+	"telepon":      "phone",
+	"no_telepon":   "phone",
+	"jk":           "jenis_kelamin",
+	"gender":       "jenis_kelamin",
+}
+
+// Job yang sedang berjalan di proses ini, mencegah satu job dijalankan dua kali
+var importBerjalan sync.Map
+
+// Satu baris berkas yang sudah dipetakan ke user
+type barisImport struct {
+	Baris          int
+	User           model.User
+	PasswordDibuat bool
+	Errors         []model.ImportError
+}
+
+// Format berkas dari ekstensi
+func formatImport(namaBerkas string) (string, error) {
+	switch strings.ToLower(filepath.Ext(namaBerkas)) {
+	case ".csv":
+		return "csv", nil
+	case ".xlsx":
+		return "xlsx", nil
+	}
+	return "", fmt.Errorf("unsupported file type, use .csv or .xlsx")
+}
+
+// Baca seluruh baris berkas, baris pertama adalah header
+func bacaBerkasImport(path string, format string) ([][]string, error) {
+	if format == "xlsx" {
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("workbook has no sheets")
+		}
+		return f.GetRows(sheets[0])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	// Excel berbahasa Indonesia menyimpan CSV dengan pemisah titik koma
+	reader := csv.NewReader(bytes.NewReader(data))
+	if baris, _ := bufio.NewReader(bytes.NewReader(data)).ReadString('\n'); strings.Count(baris, ";") > strings.Count(baris, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+// Petakan header berkas ke field user. Mapping dari request didahulukan,
+// lalu nama field json, lalu alias kolom.
+func petaKolom(header []string, mapping map[string]string) ([]string, []string, error) {
+	for kolom, field := range mapping {
+		if !fieldImport[field] {
+			return nil, nil, fmt.Errorf("mapping for column %q targets unknown field %q", kolom, field)
+		}
+	}
+
+	kolom := make([]string, len(header))
+	diabaikan := []string{}
+	dipakai := map[string]string{}
+	for i, h := range header {
+		kunci := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(h)), " ", "_")
+		field, ok := mapping[h]
+		if !ok {
+			field, ok = mapping[kunci]
+		}
+		if !ok && fieldImport[kunci] {
+			field, ok = kunci, true
+		}
+		if !ok {
+			field, ok = aliasKolomImport[kunci]
+		}
+		if !ok {
+			diabaikan = append(diabaikan, h)
+			continue
+		}
+		if sebelumnya, ada := dipakai[field]; ada {
+			return nil, nil, fmt.Errorf("columns %q and %q both map to field %q", sebelumnya, h, field)
+		}
+		dipakai[field] = h
+		kolom[i] = field
+	}
+
+	for _, field := range fieldImportWajib {
+		if _, ada := dipakai[field]; !ada {
+			return nil, nil, fmt.Errorf("missing required column: %s", field)
+		}
+	}
+	return kolom, diabaikan, nil
+}
+
+// Baris tanpa isi sama sekali dilewati
+func barisKosong(row []string) bool {
+	for _, sel := range row {
+		if strings.TrimSpace(sel) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Jenis kelamin boleh angka (1/2) atau teks (L/P, laki-laki/perempuan)
+func parseJenisKelamin(nilai string) (int, bool) {
+	switch strings.ToLower(strings.TrimSpace(nilai)) {
+	case "1", "l", "laki-laki", "laki laki", "pria", "m", "male":
+		return 1, true
+	case "2", "p", "perempuan", "wanita", "f", "female":
+		return 2, true
+	}
+	return 0, false
+}
+
+// Password awal acak untuk baris tanpa kolom password
+func buatPasswordAwal() (string, error) {
+	hasil := make([]byte, panjangPasswordAwal)
+	for i := range hasil {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(karakterPassword))))
+		if err != nil {
+			return "", err
+		}
+		hasil[i] = karakterPassword[n.Int64()]
+	}
+	return string(hasil), nil
+}
+
+// ID user untuk satu baris job import. ID yang sama dipakai saat job dilanjutkan sehingga
+// baris yang user-nya sudah dibuat sebelum job terhenti tidak dibuat ulang.
+func idUserImport(jobID primitive.ObjectID, baris int) primitive.ObjectID {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", jobID.Hex(), baris)))
+	var id primitive.ObjectID
+	copy(id[:4], jobID[:4]) // Timestamp tetap dari job agar urutan _id masuk akal
+	copy(id[4:], hash[:8])
+	return id
+}
+
+// Cipher untuk password awal di dokumen job. Kunci dari env IMPORT_CREDENTIAL_KEY,
+// jika kosong diturunkan dari JWT_SECRET.
+func cipherKredensialImport() (cipher.AEAD, error) {
+	rahasia := os.Getenv("IMPORT_CREDENTIAL_KEY")
+	if rahasia == "" {
+		rahasia = os.Getenv("JWT_SECRET")
+	}
+	if rahasia == "" {
+		return nil, fmt.Errorf("IMPORT_CREDENTIAL_KEY or JWT_SECRET must be set to store generated passwords")
+	}
+	kunci := sha256.Sum256([]byte("import-kredensial:" + rahasia))
+	block, err := aes.NewCipher(kunci[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Enkripsi password awal, hasilnya nonce+ciphertext dalam base64
+func enkripsiKredensial(password string) (string, error) {
+	aead, err := cipherKredensialImport()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(password), nil)), nil
+}
+
+func dekripsiKredensial(nilai string) (string, error) {
+	aead, err := cipherKredensialImport()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(nilai)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted credential")
+	}
+	password, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// Nama json field user dari nama field struct, untuk pesan error per kolom
+func fieldJSONUser(namaStruct string) string {
+	if field, ok := reflect.TypeOf(model.User{}).FieldByName(namaStruct); ok {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	}
+	return namaStruct
+}
+
+// Ubah error validator menjadi error per field
+func errorValidasiImport(err error, baris int) []model.ImportError {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []model.ImportError{{Baris: baris, Pesan: err.Error()}}
+	}
+	hasil := []model.ImportError{}
+	for _, fe := range validationErrors {
+		hasil = append(hasil, model.ImportError{
+			Baris: baris,
+			Field: fieldJSONUser(fe.StructField()),
+			Pesan: fmt.Sprintf("failed on the '%s' validation", fe.Tag()),
+		})
+	}
+	return hasil
+}
+
+// Baris pertama tiap username dan email di berkas, untuk mendeteksi duplikat di dalam berkas
+type nilaiDiBerkas struct {
+	Username map[string]int
+	Email    map[string]int
+}
+
+func nilaiDiBerkasBaru() nilaiDiBerkas {
+	return nilaiDiBerkas{Username: map[string]int{}, Email: map[string]int{}}
+}
+
+// Catat baris pertama nilai, bernilai baris pertama tersebut jika nilai sudah dipakai sebelumnya
+func catatDiBerkas(peta map[string]int, nilai string, baris int) (int, bool) {
+	if nilai == "" {
+		return 0, false
+	}
+	if pertama, ada := peta[nilai]; ada {
+		return pertama, true
+	}
+	peta[nilai] = baris
+	return 0, false
+}
+
+// Petakan satu baris ke user lalu validasi dengan tag validator model.User dan katalog jenis_user.
+// diBerkas mencatat username dan email yang sudah dipakai baris sebelumnya.
+func barisKeUser(kolom []string, row []string, baris int, katalog map[string]model.JenisUser, diBerkas nilaiDiBerkas) barisImport {
+	nilai := map[string]string{}
+	for i, field := range kolom {
+		if field != "" && i < len(row) {
+			nilai[field] = strings.TrimSpace(row[i])
+		}
+	}
+
+	hasil := barisImport{Baris: baris}
+	user := model.User{
+		Username:  nilai["username"],
+		NmUser:    nilai["nm_user"],
+		Password:  nilai["password"],
+		Email:     nilai["email"],
+		Role:      nilai["role"],
+		Phone:     nilai["phone"],
+		JenisUser: nilai["jenis_user"],
+	}
+	if user.Role == "" {
+		user.Role = roleImportDefault
+	}
+	if user.JenisUser == "" {
+		user.JenisUser = DefaultJenisUser
+	}
+	if nilai["jenis_kelamin"] != "" {
+		jenisKelamin, ok := parseJenisKelamin(nilai["jenis_kelamin"])
+		if !ok {
+			hasil.Errors = append(hasil.Errors, model.ImportError{Baris: baris, Field: "jenis_kelamin", Pesan: "must be 1/2, L/P or laki-laki/perempuan"})
+		}
+		user.JenisKelamin = jenisKelamin
+	}
+	if user.Password == "" {
+		password, err := buatPasswordAwal()
+		if err != nil {
+			hasil.Errors = append(hasil.Errors, model.ImportError{Baris: baris, Field: "password", Pesan: "failed to generate password"})
+		}
+		user.Password = password
+		hasil.PasswordDibuat = true
+	}
+
+	if err := validate.Struct(&user); err != nil {
+		for _, e := range errorValidasiImport(err, baris) {
+			// Jenis kelamin yang tidak dikenali sudah dilaporkan di atas
+			if e.Field == "jenis_kelamin" && nilai["jenis_kelamin"] != "" {
+				continue
+			}
+			hasil.Errors = append(hasil.Errors, e)
+		}
+	}
+	if _, ok := katalog[user.JenisUser]; !ok {
+		hasil.Errors = append(hasil.Errors, model.ImportError{Baris: baris, Field: "jenis_user", Pesan: fmt.Sprintf("%v: %s", errJenisUserTidakDikenal, user.JenisUser)})
+	}
+	if pertama, ada := catatDiBerkas(diBerkas.Username, user.Username, baris); ada {
+		hasil.Errors = append(hasil.Errors, model.ImportError{Baris: baris, Field: "username", Pesan: fmt.Sprintf("duplicate username, first used on row %d", pertama)})
+	}
+	if pertama, ada := catatDiBerkas(diBerkas.Email, user.Email, baris); ada {
+		hasil.Errors = append(hasil.Errors, model.ImportError{Baris: baris, Field: "email", Pesan: fmt.Sprintf("duplicate email, first used on row %d", pertama)})
+	}
+
+	hasil.User = user
+	return hasil
+}
+
+// Dry run: validasi seluruh baris tanpa menyimpan apa pun
+func periksaImport(ctx context.Context, rows [][]string, kolom []string) (fiber.Map, error) {
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	diBerkas := nilaiDiBerkasBaru()
+	hasilBaris := []barisImport{}
+	usernames := []string{}
+	emails := []string{}
+	for i, row := range rows[1:] {
+		if barisKosong(row) {
+			continue
+		}
+		hasil := barisKeUser(kolom, row, i+2, katalog, diBerkas)
+		hasilBaris = append(hasilBaris, hasil)
+		if hasil.User.Username != "" {
+			usernames = append(usernames, hasil.User.Username)
+		}
+		if hasil.User.Email != "" {
+			emails = append(emails, hasil.User.Email)
+		}
+	}
+
+	// Username dan email yang sudah terdaftar dicek sekaligus. Email hanya unik di antara user aktif.
+	usernameAda := map[string]bool{}
+	emailAda := map[string]bool{}
+	cursor, err := userCollection.Find(
+		ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"username": bson.M{"$in": usernames}},
+			bson.M{"email": bson.M{"$in": emails}, "deleted_at": nil},
+		}},
+		options.Find().SetProjection(bson.M{"username": 1, "email": 1, "deleted_at": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var existing []model.User
+	if err := cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+	for _, user := range existing {
+		usernameAda[user.Username] = true
+		if user.DeletedAt == nil {
+			emailAda[user.Email] = true
+		}
+	}
+
+	errors := []model.ImportError{}
+	valid, invalid, passwordDibuat := 0, 0, 0
+	for _, hasil := range hasilBaris {
+		if usernameAda[hasil.User.Username] {
+			hasil.Errors = append(hasil.Errors, model.ImportError{Baris: hasil.Baris, Field: "username", Pesan: "username already exists"})
+		}
+		if emailAda[hasil.User.Email] {
+			hasil.Errors = append(hasil.Errors, model.ImportError{Baris: hasil.Baris, Field: "email", Pesan: "email is already used by another account"})
+		}
+		if len(hasil.Errors) > 0 {
+			invalid++
+			errors = append(errors, hasil.Errors...)
+			continue
+		}
+		valid++
+		if hasil.PasswordDibuat {
+			passwordDibuat++
+		}
+	}
+
+	return fiber.Map{
+		"dry_run":             true,
+		"total":               len(hasilBaris),
+		"valid":               valid,
+		"invalid":             invalid,
+		"generated_passwords": passwordDibuat,
+		"errors":              errors,
+	}, nil
+}
+
+// Tandai job gagal, job bisa dilanjutkan lagi lewat endpoint resume
+func gagalkanImport(ctx context.Context, jobID primitive.ObjectID, pesan string) {
+	fmt.Println("Import job", jobID.Hex(), "failed:", pesan)
+	_, err := ImportJobCollection.UpdateOne(ctx, bson.M{"_id": jobID}, bson.M{"$set": bson.M{
+		"status":     StatusImportFailed,
+		"pesan":      pesan,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		fmt.Println("Error updating import job:", err)
+	}
+}
+
+// Simpan satu baris valid sebagai user baru dengan ID dari idUserImport. tersimpan bernilai true
+// jika user sudah dibuat, termasuk oleh percobaan sebelumnya sebelum job terhenti, walaupun masih
+// ada catatan error (misalnya gagal masuk bundle jenis_user).
+func simpanBarisImport(ctx context.Context, jobID primitive.ObjectID, hasil barisImport, jenisUser model.JenisUser, actor string) (bool, []model.ImportError) {
+	newUser := hasil.User
+	newUser.ID = idUserImport(jobID, hasil.Baris)
+
+	sudahDibuat, err := userCollection.CountDocuments(ctx, bson.M{"_id": newUser.ID})
+	if err != nil {
+		return false, []model.ImportError{{Baris: hasil.Baris, Pesan: "failed to check user: " + err.Error()}}
+	}
+	if sudahDibuat == 0 {
+		jumlah, err := userCollection.CountDocuments(ctx, bson.M{"username": newUser.Username})
+		if err != nil {
+			return false, []model.ImportError{{Baris: hasil.Baris, Pesan: "failed to check username: " + err.Error()}}
+		}
+		if jumlah > 0 {
+			return false, []model.ImportError{{Baris: hasil.Baris, Field: "username", Pesan: "username already exists"}}
+		}
+		jumlah, err = userCollection.CountDocuments(ctx, bson.M{"email": newUser.Email, "deleted_at": nil})
+		if err != nil {
+			return false, []model.ImportError{{Baris: hasil.Baris, Pesan: "failed to check email: " + err.Error()}}
+		}
+		if jumlah > 0 {
+			return false, []model.ImportError{{Baris: hasil.Baris, Field: "email", Pesan: strings.ToLower(pesanDuplikatUser("email"))}}
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return false, []model.ImportError{{Baris: hasil.Baris, Field: "password", Pesan: "failed to hash password"}}
+		}
+		newUser.Password = string(hashedPassword)
+		newUser.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+		newUser.Status = model.StatusActive
+		newUser.SearchNgram = ngramUser(newUser)
+		newUser.Version = 1
+		if _, err := userCollection.InsertOne(ctx, newUser); err != nil {
+			if field := fieldDuplikatUser(err); field != "" {
+				return false, []model.ImportError{{Baris: hasil.Baris, Field: field, Pesan: strings.ToLower(pesanDuplikatUser(field))}}
+			}
+			return false, []model.ImportError{{Baris: hasil.Baris, Pesan: "failed to create user: " + err.Error()}}
+		}
+		catatHistori(ctx, userCollection, AksiCreate, actor, newUser.ID)
+	}
+
+	// Aman diulang untuk user yang sudah dibuat sebelumnya, user_id ditambahkan dengan $addToSet
+	if err := masukkanKeBundle(ctx, newUser.ID, jenisUser, actor); err != nil {
+		return true, []model.ImportError{{Baris: hasil.Baris, Field: "jenis_user", Pesan: "user created but not added to the jenis_user bundle: " + err.Error()}}
+	}
+	return true, nil
+}
+
+// Simpan password awal satu baris (terenkripsi) ke dokumen job sebelum user-nya dibuat,
+// agar password tidak hilang jika job terhenti sebelum progress baris tersimpan
+func simpanKredensialImport(ctx context.Context, jobID primitive.ObjectID, hasil barisImport) error {
+	password, err := enkripsiKredensial(hasil.User.Password)
+	if err != nil {
+		return err
+	}
+	_, err = ImportJobCollection.UpdateOne(ctx, bson.M{"_id": jobID}, bson.M{
+		"$push": bson.M{"kredensial": model.ImportKredensial{
+			Baris:    hasil.Baris,
+			Username: hasil.User.Username,
+			Password: password,
+		}},
+		"$set": bson.M{"kedaluwarsa_pada": time.Now().Add(masaKredensialImport)},
+	})
+	return err
+}
+
+// Jalankan job import mulai dari baris yang belum diproses. Progress disimpan per baris
+// sehingga job yang terhenti (server restart, error database) bisa dilanjutkan.
+func jalankanImport(jobID primitive.ObjectID) {
+	if _, berjalan := importBerjalan.LoadOrStore(jobID, true); berjalan {
+		return
+	}
+	defer importBerjalan.Delete(jobID)
+
+	ctx := context.Background()
+
+	var job model.ImportJob
+	if err := ImportJobCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": jobID, "status": bson.M{"$in": bson.A{StatusImportPending, StatusImportRunning}}},
+		bson.M{"$set": bson.M{"status": StatusImportRunning, "updated_at": time.Now()}, "$unset": bson.M{"pesan": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job); err != nil {
+		fmt.Println("Error starting import job", jobID.Hex(), ":", err)
+		return
+	}
+
+	rows, err := bacaBerkasImport(job.Berkas, job.Format)
+	if err != nil {
+		gagalkanImport(ctx, jobID, "failed to read file: "+err.Error())
+		return
+	}
+	kolom, _, err := petaKolom(rows[0], job.Mapping)
+	if err != nil {
+		gagalkanImport(ctx, jobID, err.Error())
+		return
+	}
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		gagalkanImport(ctx, jobID, "failed to load jenis_user catalog: "+err.Error())
+		return
+	}
+
+	// Username dan email dari baris yang sudah diproses tetap dihitung untuk deteksi duplikat
+	diBerkas := nilaiDiBerkasBaru()
+	for i := 0; i < job.Diproses && i+1 < len(rows); i++ {
+		for k, field := range kolom {
+			if k >= len(rows[i+1]) {
+				continue
+			}
+			switch field {
+			case "username":
+				catatDiBerkas(diBerkas.Username, strings.TrimSpace(rows[i+1][k]), i+2)
+			case "email":
+				catatDiBerkas(diBerkas.Email, strings.TrimSpace(rows[i+1][k]), i+2)
+			}
+		}
+	}
+
+	// Password awal yang sudah disimpan dipakai lagi untuk baris yang sama saat job dilanjutkan
+	kredensial := map[int]string{}
+	for _, item := range job.Kredensial {
+		password, err := dekripsiKredensial(item.Password)
+		if err != nil {
+			gagalkanImport(ctx, jobID, "failed to decrypt stored credentials: "+err.Error())
+			return
+		}
+		kredensial[item.Baris] = password
+	}
+
+	for i := job.Diproses; i+1 < len(rows); i++ {
+		row := rows[i+1]
+		update := bson.M{"$set": bson.M{"diproses": i + 1, "updated_at": time.Now()}}
+
+		if !barisKosong(row) {
+			hasil := barisKeUser(kolom, row, i+2, katalog, diBerkas)
+			errs := hasil.Errors
+			tersimpan := false
+			if len(errs) == 0 && hasil.PasswordDibuat {
+				if password, ada := kredensial[hasil.Baris]; ada {
+					hasil.User.Password = password
+				} else if err := simpanKredensialImport(ctx, jobID, hasil); err != nil {
+					gagalkanImport(ctx, jobID, "failed to save credentials: "+err.Error())
+					return
+				}
+			}
+			if len(errs) == 0 {
+				tersimpan, errs = simpanBarisImport(ctx, jobID, hasil, katalog[hasil.User.JenisUser], job.CreatedBy)
+			}
+
+			// Baris yang user-nya sudah tersimpan dihitung berhasil walau ada catatan error
+			if tersimpan {
+				update["$inc"] = bson.M{"berhasil": 1}
+			} else {
+				update["$inc"] = bson.M{"gagal": 1}
+				// Password awal untuk user yang tidak jadi dibuat tidak perlu disimpan
+				if hasil.PasswordDibuat {
+					update["$pull"] = bson.M{"kredensial": bson.M{"baris": hasil.Baris}}
+				}
+			}
+			if len(errs) > 0 {
+				update["$push"] = bson.M{"errors": bson.M{"$each": errs, "$slice": batasErrorImport}}
+			}
+		}
+
+		if _, err := ImportJobCollection.UpdateOne(ctx, bson.M{"_id": jobID}, update); err != nil {
+			gagalkanImport(ctx, jobID, "failed to save progress: "+err.Error())
+			return
+		}
+	}
+
+	selesai := time.Now()
+	if _, err := ImportJobCollection.UpdateOne(ctx, bson.M{"_id": jobID}, bson.M{"$set": bson.M{
+		"status":       StatusImportCompleted,
+		"selesai_pada": selesai,
+		"updated_at":   selesai,
+	}}); err != nil {
+		fmt.Println("Error completing import job:", err)
+		return
+	}
+
+	// Berkas berisi data pribadi, tidak disimpan setelah job selesai
+	if err := os.Remove(job.Berkas); err != nil {
+		fmt.Println("Error removing import file:", err)
+	}
+}
+
+// LanjutkanImportTertunda menjalankan kembali job yang terhenti saat server mati.
+// Dipanggil sekali saat aplikasi mulai.
+func LanjutkanImportTertunda() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := ImportJobCollection.Find(ctx, bson.M{"status": bson.M{"$in": bson.A{StatusImportPending, StatusImportRunning}}})
+	if err != nil {
+		fmt.Println("Error fetching pending import jobs:", err)
+		return
+	}
+	var jobs []model.ImportJob
+	if err := cursor.All(ctx, &jobs); err != nil {
+		fmt.Println("Error decoding pending import jobs:", err)
+		return
+	}
+	for _, job := range jobs {
+		fmt.Println("Resuming import job", job.ID.Hex(), "from row", job.Diproses+2)
+		go jalankanImport(job.ID)
+	}
+}
+
+// ImportUsers - Upload berkas CSV/XLSX berisi user baru.
+// Dengan dry_run=true seluruh baris hanya divalidasi, selain itu dibuat job import di background.
+func ImportUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Failed to retrieve file"})
+	}
+	format, err := formatImport(file.Filename)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	dryRun := false
+	if nilai := c.FormValue("dry_run", c.Query("dry_run")); nilai != "" {
+		if dryRun, err = strconv.ParseBool(nilai); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "dry_run must be true or false"})
+		}
+	}
+
+	// Mapping opsional berupa JSON {"Kolom di berkas": "field_user"}
+	mapping := map[string]string{}
+	if nilai := c.FormValue("mapping"); nilai != "" {
+		if err := json.Unmarshal([]byte(nilai), &mapping); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid mapping: " + err.Error()})
+		}
+	}
+
+	if err := os.MkdirAll(direktoriImport, os.ModePerm); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create import directory"})
+	}
+	jobID := primitive.NewObjectID()
+	path := filepath.Join(direktoriImport, jobID.Hex()+"."+format)
+	if err := c.SaveFile(file, path); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save file"})
+	}
+
+	rows, err := bacaBerkasImport(path, format)
+	if err != nil {
+		os.Remove(path)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Failed to read file: " + err.Error()})
+	}
+	if len(rows) < 2 {
+		os.Remove(path)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "File has no data rows"})
+	}
+	kolom, diabaikan, err := petaKolom(rows[0], mapping)
+	if err != nil {
+		os.Remove(path)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if dryRun {
+		defer os.Remove(path)
+		hasil, err := periksaImport(ctx, rows, kolom)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to validate file: " + err.Error()})
+		}
+		hasil["ignored_columns"] = diabaikan
+		return c.Status(http.StatusOK).JSON(hasil)
+	}
+
+	total := 0
+	for _, row := range rows[1:] {
+		if !barisKosong(row) {
+			total++
+		}
+	}
+	job := model.ImportJob{
+		ID:         jobID,
+		Status:     StatusImportPending,
+		NamaBerkas: file.Filename,
+		Format:     format,
+		Berkas:     path,
+		Mapping:    mapping,
+		Total:      total,
+		Errors:     []model.ImportError{},
+		CreatedBy:  aktorDari(c),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if _, err := ImportJobCollection.InsertOne(ctx, job); err != nil {
+		os.Remove(path)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create import job"})
+	}
+
+	go jalankanImport(job.ID)
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"message":         "Import job created",
+		"job":             job,
+		"ignored_columns": diabaikan,
+	})
+}
+
+// GetImportJobs - Daftar job import terbaru
+func GetImportJobs(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(50).
+		SetProjection(bson.M{"errors": 0, "kredensial": 0})
+	cursor, err := ImportJobCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch import jobs"})
+	}
+	jobs := []model.ImportJob{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode import jobs"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"jobs": jobs, "total_count": len(jobs)})
+}
+
+// Ambil job import dari parameter :jobId beserta status HTTP jika gagal
+func cariImportJob(ctx context.Context, c *fiber.Ctx) (model.ImportJob, int, error) {
+	var job model.ImportJob
+	jobID, err := primitive.ObjectIDFromHex(c.Params("jobId"))
+	if err != nil {
+		return job, http.StatusBadRequest, fmt.Errorf("Invalid job ID")
+	}
+	err = ImportJobCollection.FindOne(ctx, bson.M{"_id": jobID}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return job, http.StatusNotFound, fmt.Errorf("Import job not found")
+	}
+	if err != nil {
+		return job, http.StatusInternalServerError, fmt.Errorf("Failed to fetch import job")
+	}
+	return job, http.StatusOK, nil
+}
+
+// GetImportJob - Status dan progress satu job import
+func GetImportJob(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, status, err := cariImportJob(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	progress := 0.0
+	if job.Total > 0 {
+		progress = float64(job.Diproses) / float64(job.Total) * 100
+		if progress > 100 {
+			progress = 100
+		}
+	}
+	_, berjalan := importBerjalan.Load(job.ID)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"job":      job,
+		"progress": progress,
+		"berjalan": berjalan,
+	})
+}
+
+// ResumeImportJob - Lanjutkan job yang gagal atau terhenti dari baris terakhir yang diproses
+func ResumeImportJob(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, status, err := cariImportJob(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	if job.Status == StatusImportCompleted {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Import job is already completed"})
+	}
+	if _, berjalan := importBerjalan.Load(job.ID); berjalan {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Import job is already running"})
+	}
+
+	_, err = ImportJobCollection.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{
+		"status":     StatusImportPending,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resume import job"})
+	}
+
+	go jalankanImport(job.ID)
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"message":   "Import job resumed",
+		"resume_at": job.Diproses + 2,
+	})
+}
+
+// Hapus password awal yang tidak diambil sampai masa simpannya habis
+func hapusKredensialKedaluwarsa(ctx context.Context) (int64, error) {
+	result, err := ImportJobCollection.UpdateMany(
+		ctx,
+		bson.M{"kedaluwarsa_pada": bson.M{"$lt": time.Now()}, "kredensial": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"kredensial": ""}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// MulaiHapusKredensialImport menghapus password awal yang kedaluwarsa setiap jam
+func MulaiHapusKredensialImport() {
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			jumlah, err := hapusKredensialKedaluwarsa(ctx)
+			cancel()
+			if err != nil {
+				fmt.Println("Error removing expired import credentials:", err)
+			} else if jumlah > 0 {
+				fmt.Println("Removed expired credentials from", jumlah, "import jobs")
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+// GetImportCredentials - Password awal yang dibuat sistem. Hanya bisa diambil sekali
+// setelah job selesai dan sebelum kedaluwarsa, sesudahnya password dihapus dari database.
+func GetImportCredentials(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, status, err := cariImportJob(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	if job.Status != StatusImportCompleted {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Credentials are available after the import job is completed"})
+	}
+
+	if job.KedaluwarsaPada != nil && job.KedaluwarsaPada.Before(time.Now()) && !job.KredensialDiambil {
+		return c.Status(http.StatusGone).JSON(fiber.Map{"error": "Credentials have expired"})
+	}
+
+	var sebelum model.ImportJob
+	err = ImportJobCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": job.ID, "kredensial_diambil": false, "$or": bson.A{
+			bson.M{"kedaluwarsa_pada": bson.M{"$exists": false}},
+			bson.M{"kedaluwarsa_pada": bson.M{"$gte": time.Now()}},
+		}},
+		bson.M{
+			"$set":   bson.M{"kredensial_diambil": true, "updated_at": time.Now()},
+			"$unset": bson.M{"kredensial": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&sebelum)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusGone).JSON(fiber.Map{"error": "Credentials have already been retrieved"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch credentials"})
+	}
+
+	kredensial := []model.ImportKredensial{}
+	for _, item := range sebelum.Kredensial {
+		password, err := dekripsiKredensial(item.Password)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decrypt credentials"})
+		}
+		item.Password = password
+		kredensial = append(kredensial, item)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"job_id":      job.ID,
+		"credentials": kredensial,
+		"total_count": len(kredensial),
+	})
+}
@@ -1,17 +1,377 @@
 package controllers
 
 import (
+	"context"
 	"demoapp/config"
 	"demoapp/model"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var UserModulCollection = config.GetCollection(config.DB, "usermodul")
 var UserCollection = config.GetCollection(config.DB, "users")
+var JenisUserCollection = config.GetCollection(config.DB, "jenis_user")
+
+// DefaultJenisUser adalah jenis_user untuk pengguna yang mendaftar sendiri
+const DefaultJenisUser = "pelanggan"
+
+// Nilai catatan pada dokumen usermodul
+const (
+	CatatanBundle     = "bundle"     // Bundle bawaan jenis_user, dikelola lewat katalog
+	CatatanUserKhusus = "userkhusus" // Modul tambahan untuk user tertentu
+)
+
+var errJenisUserTidakDikenal = errors.New("jenis_user is not registered")
+var errSiklusJenisUser = errors.New("jenis_user inheritance cycle")
+
+// Cari jenis_user di katalog berdasarkan kode
+func cariJenisUser(ctx context.Context, kode string) (model.JenisUser, error) {
+	var jenisUser model.JenisUser
+	err := JenisUserCollection.FindOne(ctx, bson.M{"kode": kode}).Decode(&jenisUser)
+	if err == mongo.ErrNoDocuments {
+		return jenisUser, fmt.Errorf("%w: %q", errJenisUserTidakDikenal, kode)
+	}
+	return jenisUser, err
+}
+
+// Cari jenis_user di katalog, dibuat jika belum terdaftar. Entri baru memakai modul
+// dari dokumen bundle yang sudah ada agar bundle lama tidak dikosongkan saat disinkronkan ulang.
+func pastikanJenisUser(ctx context.Context, kode string) (model.JenisUser, error) {
+	jenisUser, err := cariJenisUser(ctx, kode)
+	if !errors.Is(err, errJenisUserTidakDikenal) {
+		return jenisUser, err
+	}
+
+	modulIDs := []primitive.ObjectID{}
+	var bundle model.UserModul
+	err = UserModulCollection.FindOne(ctx, bson.M{"jenis_user": kode, "catatan": CatatanBundle}).Decode(&bundle)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return jenisUser, err
+	}
+	if bundle.ModulID != nil {
+		modulIDs = bundle.ModulID
+	}
+
+	_, err = JenisUserCollection.UpdateOne(
+		ctx,
+		bson.M{"kode": kode},
+		bson.M{"$setOnInsert": bson.M{
+			"nm_jenis_user": kode,
+			"modul_id":      modulIDs,
+			"created_at":    time.Now(),
+			"updated_at":    time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	// Proses lain bisa membuat entri yang sama bersamaan, upsert yang kalah tidak dianggap gagal
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return jenisUser, err
+	}
+	return cariJenisUser(ctx, kode)
+}
+
+// Daftarkan jenis_user default untuk pendaftaran mandiri dan semua kode jenis_user yang sudah
+// dipakai user lama ke katalog, agar validasi katalog tidak menolak data yang sudah ada.
+// Dijalankan saat server start dan aman diulang.
+func SeedJenisUser() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	rawKode, err := userCollection.Distinct(ctx, "jenis_user", bson.M{"jenis_user": bson.M{"$type": "string", "$ne": ""}})
+	if err != nil {
+		fmt.Println("Error reading jenis_user codes:", err)
+		return
+	}
+	kodes := []string{DefaultJenisUser}
+	for _, raw := range rawKode {
+		if kode, ok := raw.(string); ok && kode != DefaultJenisUser {
+			kodes = append(kodes, kode)
+		}
+	}
+	for _, kode := range kodes {
+		if _, err := pastikanJenisUser(ctx, kode); err != nil {
+			fmt.Println("Error seeding jenis_user", kode, ":", err)
+		}
+	}
+}
+
+// Status HTTP untuk error dari cariJenisUser dan validasi hierarki
+func statusJenisUserError(err error) int {
+	if errors.Is(err, errJenisUserTidakDikenal) || errors.Is(err, errSiklusJenisUser) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Pastikan semua modul_id benar-benar ada di koleksi modul
+func cekModulAda(ctx context.Context, modulIDs []primitive.ObjectID) error {
+	unik := map[primitive.ObjectID]bool{}
+	for _, id := range modulIDs {
+		unik[id] = true
+	}
+	count, err := ModulCollection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": modulIDs}})
+	if err != nil {
+		return err
+	}
+	if int(count) != len(unik) {
+		return errors.New("one or more modul_id do not exist")
+	}
+	return nil
+}
+
+// Modul dalam bundle hasil pewarisan beserta jenis_user yang mendefinisikannya
+type modulBundle struct {
+	ModulID primitive.ObjectID `json:"modul_id"`
+	Dari    string             `json:"dari"`
+}
+
+// Ambil seluruh katalog jenis_user, diindeks berdasarkan kode
+func loadKatalogJenisUser(ctx context.Context) (map[string]model.JenisUser, error) {
+	cursor, err := JenisUserCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var jenisUsers []model.JenisUser
+	if err := cursor.All(ctx, &jenisUsers); err != nil {
+		return nil, err
+	}
+
+	katalog := make(map[string]model.JenisUser, len(jenisUsers))
+	for _, jenisUser := range jenisUsers {
+		katalog[jenisUser.Kode] = jenisUser
+	}
+	return katalog, nil
+}
+
+// Daftar leluhur jenis_user (induk, induk dari induk, dst) dalam urutan penelusuran.
+// Mengembalikan errSiklusJenisUser jika hierarki membentuk siklus.
+func leluhurJenisUser(katalog map[string]model.JenisUser, kode string) ([]string, error) {
+	if _, ok := katalog[kode]; !ok {
+		return nil, fmt.Errorf("%w: %q", errJenisUserTidakDikenal, kode)
+	}
+
+	leluhur := []string{}
+	dikunjungi := map[string]bool{}
+	var jalur []string
+
+	var telusuri func(k string) error
+	telusuri = func(k string) error {
+		for i, j := range jalur {
+			if j == k {
+				siklus := append(append([]string{}, jalur[i:]...), k)
+				return fmt.Errorf("%w: %s", errSiklusJenisUser, strings.Join(siklus, " -> "))
+			}
+		}
+		if dikunjungi[k] {
+			return nil
+		}
+		dikunjungi[k] = true
+		if k != kode {
+			leluhur = append(leluhur, k)
+		}
+
+		jalur = append(jalur, k)
+		for _, parent := range katalog[k].Parent {
+			if _, ok := katalog[parent]; !ok {
+				return fmt.Errorf("%w: parent %q of %q", errJenisUserTidakDikenal, parent, k)
+			}
+			if err := telusuri(parent); err != nil {
+				return err
+			}
+		}
+		jalur = jalur[:len(jalur)-1]
+		return nil
+	}
+
+	if err := telusuri(kode); err != nil {
+		return nil, err
+	}
+	return leluhur, nil
+}
+
+// Bundle lengkap jenis_user: modul miliknya sendiri lalu modul warisan dari leluhurnya
+func flattenBundle(katalog map[string]model.JenisUser, kode string) ([]modulBundle, error) {
+	leluhur, err := leluhurJenisUser(katalog, kode)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := []modulBundle{}
+	ada := map[primitive.ObjectID]bool{}
+	for _, k := range append([]string{kode}, leluhur...) {
+		for _, modulID := range katalog[k].ModulID {
+			if !ada[modulID] {
+				ada[modulID] = true
+				bundle = append(bundle, modulBundle{ModulID: modulID, Dari: k})
+			}
+		}
+	}
+	return bundle, nil
+}
+
+// Bundle lengkap satu jenis_user langsung dari database
+func bundleJenisUser(ctx context.Context, kode string) ([]modulBundle, error) {
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return flattenBundle(katalog, kode)
+}
+
+// Ambil daftar modul_id dari bundle
+func modulIDBundle(bundle []modulBundle) []primitive.ObjectID {
+	modulIDs := make([]primitive.ObjectID, 0, len(bundle))
+	for _, modul := range bundle {
+		modulIDs = append(modulIDs, modul.ModulID)
+	}
+	return modulIDs
+}
+
+// Validasi daftar parent untuk jenis_user: harus terdaftar dan tidak membentuk siklus
+func cekParentJenisUser(ctx context.Context, jenisUser model.JenisUser) error {
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		return err
+	}
+	for _, parent := range jenisUser.Parent {
+		if parent == jenisUser.Kode {
+			return fmt.Errorf("%w: %q cannot inherit from itself", errSiklusJenisUser, parent)
+		}
+		if _, ok := katalog[parent]; !ok {
+			return fmt.Errorf("%w: parent %q", errJenisUserTidakDikenal, parent)
+		}
+	}
+
+	katalog[jenisUser.Kode] = jenisUser
+	_, err = leluhurJenisUser(katalog, jenisUser.Kode)
+	return err
+}
+
+// Samakan dokumen bundle di usermodul dengan katalog: modul dari bundle lengkap,
+// user dari semua pengguna aktif (belum dihapus) yang memiliki jenis_user tersebut
+func syncBundleJenisUser(ctx context.Context, katalog map[string]model.JenisUser, kode string, actor string) error {
+	bundle, err := flattenBundle(katalog, kode)
+	if err != nil {
+		return err
+	}
+
+	rawIDs, err := UserCollection.Distinct(ctx, "_id", bson.M{"jenis_user": kode, "deleted_at": nil})
+	if err != nil {
+		return err
+	}
+	userIDs := []primitive.ObjectID{}
+	for _, raw := range rawIDs {
+		if oid, ok := raw.(primitive.ObjectID); ok {
+			userIDs = append(userIDs, oid)
+		}
+	}
+
+	filter := bson.M{"jenis_user": kode, "catatan": CatatanBundle}
+	_, err = UserModulCollection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$set":         bson.M{"modul_id": modulIDBundle(bundle), "user_id": userIDs},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+			"$inc":         naikkanVersi,
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	catatHistori(ctx, UserModulCollection, AksiUpdate, actor, idDokumen(ctx, UserModulCollection, filter)...)
+	return nil
+}
+
+// Sinkronkan bundle jenis_user beserta semua turunannya yang ikut mewarisi
+func syncBundleTurunan(ctx context.Context, kode string, actor string) error {
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		return err
+	}
+	for k := range katalog {
+		leluhur, err := leluhurJenisUser(katalog, k)
+		if err != nil {
+			return err
+		}
+		if k != kode && !slices.Contains(leluhur, kode) {
+			continue
+		}
+		if err := syncBundleJenisUser(ctx, katalog, k, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tambahkan user ke dokumen bundle jenis_user, dibuat jika belum ada
+func masukkanKeBundle(ctx context.Context, userID primitive.ObjectID, jenisUser model.JenisUser, actor string) error {
+	bundle, err := bundleJenisUser(ctx, jenisUser.Kode)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"jenis_user": jenisUser.Kode, "catatan": CatatanBundle}
+	_, err = UserModulCollection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$addToSet":    bson.M{"user_id": userID},
+			"$setOnInsert": bson.M{"modul_id": modulIDBundle(bundle), "created_at": time.Now()},
+			"$inc":         naikkanVersi,
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	catatHistori(ctx, UserModulCollection, AksiUpdate, actor, idDokumen(ctx, UserModulCollection, filter)...)
+	return nil
+}
+
+// Pindahkan user ke jenis_user baru: keluarkan dari dokumen usermodul jenis lama,
+// masukkan ke bundle jenis baru, lalu perbarui field jenis_user di koleksi users
+func pindahkanJenisUser(ctx context.Context, userID primitive.ObjectID, jenisUser model.JenisUser, actor string) error {
+	// Hapus user_id dari jenis_user yang lama
+	filterLama := bson.M{"jenis_user": bson.M{"$ne": jenisUser.Kode}, "user_id": userID}
+	idLama := idDokumen(ctx, UserModulCollection, filterLama)
+	_, err := UserModulCollection.UpdateMany(
+		ctx,
+		filterLama,
+		bson.M{"$pull": bson.M{"user_id": userID}, "$inc": naikkanVersi},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove user from old modules: %w", err)
+	}
+	catatHistori(ctx, UserModulCollection, AksiUpdate, actor, idLama...)
+
+	// Tambahkan user_id ke bundle jenis_user yang baru
+	if err := masukkanKeBundle(ctx, userID, jenisUser, actor); err != nil {
+		return fmt.Errorf("failed to add user to the new type: %w", err)
+	}
+
+	// Perbarui `jenis_user` di koleksi `user`
+	_, err = UserCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"jenis_user": jenisUser.Kode}, "$inc": naikkanVersi},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user type in user collection: %w", err)
+	}
+	catatHistori(ctx, UserCollection, AksiUpdate, actor, userID)
+	return nil
+}
 
 // Fungsi untuk mengganti jenis_user dan memperbarui data user
 func ChangeUserType(c *fiber.Ctx) error {
@@ -32,42 +392,294 @@ func ChangeUserType(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
 	}
 
-	// Cari modul yang sudah ada dengan user_id yang sesuai
-	var userModul model.UserModul
-	err = UserModulCollection.FindOne(c.Context(), bson.M{"user_id": userID}).Decode(&userModul)
+	// Validasi jenis_user baru terhadap katalog
+	jenisUser, err := cariJenisUser(c.Context(), body.NewType)
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found in any module"})
+		return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Hapus user_id dari jenis_user yang lama
-	_, err = UserModulCollection.UpdateMany(
-		c.Context(),
-		bson.M{"jenis_user": bson.M{"$ne": body.NewType}, "user_id": userID}, // Cari berdasarkan user_id dan jenis_user yang bukan new_type
-		bson.M{"$pull": bson.M{"user_id": userID}}, // Hapus user_id dari modul yang tidak sesuai
-	)
+	// Pastikan user ada
+	count, err := UserCollection.CountDocuments(c.Context(), bson.M{"_id": userID, "deleted_at": nil})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to remove user from old modules"})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user"})
+	}
+	if count == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 	}
 
-	// Tambahkan user_id ke jenis_user yang baru
-	_, err = UserModulCollection.UpdateOne(
-		c.Context(),
-		bson.M{"jenis_user": body.NewType}, // Cari modul dengan jenis_user yang baru
-		bson.M{"$addToSet": bson.M{"user_id": userID}}, // Menambahkan user_id tanpa duplikasi
-	)
+	if err := pindahkanJenisUser(c.Context(), userID, jenisUser, aktorDari(c)); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User type updated successfully"})
+}
+
+// ------------------------------
+// Katalog jenis_user
+// ------------------------------
+
+// Create JenisUser
+func CreateJenisUser(c *fiber.Ctx) error {
+	jenisUser := new(model.JenisUser)
+
+	if err := c.BodyParser(jenisUser); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	// Validasi data
+	if err := validate.Struct(jenisUser); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Kode harus unik
+	count, err := JenisUserCollection.CountDocuments(c.Context(), bson.M{"kode": jenisUser.Kode})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add user to the new type"})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error checking kode uniqueness"})
+	}
+	if count > 0 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Kode is already registered"})
 	}
 
-	// Perbarui `jenis_user` di koleksi `user`
-	_, err = UserCollection.UpdateOne(
-		c.Context(),
-		bson.M{"_id": userID}, // Cari user berdasarkan _id
-		bson.M{"$set": bson.M{"jenis_user": body.NewType}}, // Perbarui jenis_user
-	)
+	if jenisUser.ModulID == nil {
+		jenisUser.ModulID = []primitive.ObjectID{}
+	}
+	if err := cekModulAda(c.Context(), jenisUser.ModulID); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Parent harus terdaftar dan tidak membentuk siklus
+	if err := cekParentJenisUser(c.Context(), *jenisUser); err != nil {
+		return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	jenisUser.ID = primitive.NewObjectID()
+	jenisUser.CreatedAt = time.Now()
+	jenisUser.UpdatedAt = time.Now()
+
+	if _, err := JenisUserCollection.InsertOne(c.Context(), jenisUser); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create jenis_user"})
+	}
+
+	// Buat dokumen bundle di usermodul
+	if err := syncBundleTurunan(c.Context(), jenisUser.Kode, aktorDari(c)); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to sync jenis_user bundle"})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"message": "JenisUser created successfully",
+		"id":      jenisUser.ID,
+	})
+}
+
+// Get All JenisUser
+func GetAllJenisUser(c *fiber.Ctx) error {
+	cursor, err := JenisUserCollection.Find(c.Context(), bson.M{})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update user type in user collection"})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch jenis_user"})
 	}
+	defer cursor.Close(c.Context())
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User type updated successfully"})
-}
\ No newline at end of file
+	jenisUsers := []model.JenisUser{}
+	if err := cursor.All(c.Context(), &jenisUsers); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse jenis_user"})
+	}
+
+	return c.JSON(jenisUsers)
+}
+
+// Get JenisUser by kode
+func GetJenisUser(c *fiber.Ctx) error {
+	jenisUser, err := cariJenisUser(c.Context(), c.Params("kode"))
+	if err != nil {
+		if errors.Is(err, errJenisUserTidakDikenal) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "JenisUser not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch jenis_user"})
+	}
+
+	return c.JSON(jenisUser)
+}
+
+// Update JenisUser, perubahan bundle langsung berlaku untuk semua user dengan jenis tersebut
+func UpdateJenisUser(c *fiber.Ctx) error {
+	jenisUser, err := cariJenisUser(c.Context(), c.Params("kode"))
+	if err != nil {
+		if errors.Is(err, errJenisUserTidakDikenal) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "JenisUser not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch jenis_user"})
+	}
+
+	// Kode tidak dapat diubah karena dipakai sebagai referensi di users dan usermodul
+	var body struct {
+		NmJenisUser *string   `json:"nm_jenis_user"`
+		ModulID     *[]string `json:"modul_id"`
+		Parent      *[]string `json:"parent"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if body.NmJenisUser != nil {
+		if *body.NmJenisUser == "" {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "nm_jenis_user cannot be empty"})
+		}
+		jenisUser.NmJenisUser = *body.NmJenisUser
+	}
+	if body.ModulID != nil {
+		for _, id := range *body.ModulID {
+			if _, err := primitive.ObjectIDFromHex(id); err != nil {
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID: " + id})
+			}
+		}
+		modulIDs := parseObjectIDs(*body.ModulID)
+		if modulIDs == nil {
+			modulIDs = []primitive.ObjectID{}
+		}
+		if err := cekModulAda(c.Context(), modulIDs); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		jenisUser.ModulID = modulIDs
+	}
+	if body.Parent != nil {
+		jenisUser.Parent = *body.Parent
+		if err := cekParentJenisUser(c.Context(), jenisUser); err != nil {
+			return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	jenisUser.UpdatedAt = time.Now()
+
+	_, err = JenisUserCollection.UpdateOne(c.Context(), bson.M{"_id": jenisUser.ID}, bson.M{"$set": bson.M{
+		"nm_jenis_user": jenisUser.NmJenisUser,
+		"modul_id":      jenisUser.ModulID,
+		"parent":        jenisUser.Parent,
+		"updated_at":    jenisUser.UpdatedAt,
+	}})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update jenis_user"})
+	}
+
+	// Propagasi bundle ke semua user dengan jenis_user ini dan turunannya
+	if err := syncBundleTurunan(c.Context(), jenisUser.Kode, aktorDari(c)); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to sync jenis_user bundle"})
+	}
+
+	return c.JSON(fiber.Map{"message": "JenisUser updated successfully", "jenis_user": jenisUser})
+}
+
+var errJenisUserDipakai = errors.New("JenisUser is still used")
+
+// Hapus jenis_user beserta dokumen bundle-nya, hanya jika tidak ada lagi user
+// dengan jenis tersebut dan tidak menjadi induk jenis_user lain
+func hapusJenisUser(ctx context.Context, kode string, actor string) error {
+	count, err := UserCollection.CountDocuments(ctx, bson.M{"jenis_user": kode})
+	if err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w by %d user(s)", errJenisUserDipakai, count)
+	}
+
+	count, err = JenisUserCollection.CountDocuments(ctx, bson.M{"parent": kode})
+	if err != nil {
+		return fmt.Errorf("failed to count child jenis_user: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w as a parent of %d jenis_user", errJenisUserDipakai, count)
+	}
+
+	result, err := JenisUserCollection.DeleteOne(ctx, bson.M{"kode": kode})
+	if err != nil {
+		return fmt.Errorf("failed to delete jenis_user: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", errJenisUserTidakDikenal, kode)
+	}
+
+	// Hapus dokumen bundle yang sudah tidak terpakai
+	filterBundle := bson.M{"jenis_user": kode, "catatan": CatatanBundle}
+	idBundle := idDokumen(ctx, UserModulCollection, filterBundle)
+	if _, err := UserModulCollection.DeleteMany(ctx, filterBundle); err != nil {
+		return fmt.Errorf("failed to delete jenis_user bundle: %w", err)
+	}
+	catatHistori(ctx, UserModulCollection, AksiDelete, actor, idBundle...)
+	return nil
+}
+
+// Delete JenisUser, hanya jika tidak ada lagi user dengan jenis tersebut
+func DeleteJenisUser(c *fiber.Ctx) error {
+	err := hapusJenisUser(c.Context(), c.Params("kode"), aktorDari(c))
+	switch {
+	case errors.Is(err, errJenisUserDipakai):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, errJenisUserTidakDikenal):
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "JenisUser not found"})
+	case err != nil:
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "JenisUser deleted successfully"})
+}
+
+// Ringkasan bundle lengkap satu jenis_user
+func ringkasanBundle(katalog map[string]model.JenisUser, kode string) (fiber.Map, error) {
+	leluhur, err := leluhurJenisUser(katalog, kode)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := flattenBundle(katalog, kode)
+	if err != nil {
+		return nil, err
+	}
+	return fiber.Map{
+		"kode":          kode,
+		"nm_jenis_user": katalog[kode].NmJenisUser,
+		"parent":        katalog[kode].Parent,
+		"leluhur":       leluhur,
+		"moduls":        bundle,
+		"total_count":   len(bundle),
+	}, nil
+}
+
+// Get bundle lengkap (termasuk warisan) untuk satu jenis_user
+func GetJenisUserBundle(c *fiber.Ctx) error {
+	katalog, err := loadKatalogJenisUser(c.Context())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch jenis_user"})
+	}
+
+	kode := c.Params("kode")
+	if _, ok := katalog[kode]; !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "JenisUser not found"})
+	}
+
+	ringkasan, err := ringkasanBundle(katalog, kode)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(ringkasan)
+}
+
+// Get bundle lengkap (termasuk warisan) untuk semua jenis_user
+func GetAllJenisUserBundles(c *fiber.Ctx) error {
+	katalog, err := loadKatalogJenisUser(c.Context())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch jenis_user"})
+	}
+
+	kodes := make([]string, 0, len(katalog))
+	for kode := range katalog {
+		kodes = append(kodes, kode)
+	}
+	slices.Sort(kodes)
+
+	bundles := make([]fiber.Map, 0, len(kodes))
+	for _, kode := range kodes {
+		ringkasan, err := ringkasanBundle(katalog, kode)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		bundles = append(bundles, ringkasan)
+	}
+	return c.JSON(bundles)
+}
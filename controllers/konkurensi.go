@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Update yang menaikkan versi dokumen, dipakai di setiap perubahan user, modul, usermodul dan usermodul_deny
+var naikkanVersi = bson.M{"version": 1}
+
+// ETag dari versi dokumen. Strong ETag agar bisa dipakai di If-Match; response yang juga
+// bergantung pada dokumen lain (misalnya ?include=moduls) tidak diberi ETag.
+func etagVersi(versi int64) string {
+	return fmt.Sprintf(`"%d"`, versi)
+}
+
+// Versi dari satu ETag, bentuk weak maupun strong diterima (perbandingan weak untuk If-None-Match)
+func versiDariEtag(etag string) (int64, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+	versi, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	return versi, err == nil
+}
+
+// Versi dari satu ETag untuk If-Match. RFC 9110 mewajibkan perbandingan strong,
+// sehingga weak ETag tidak pernah cocok.
+func versiDariEtagKuat(etag string) (int64, bool) {
+	if strings.HasPrefix(strings.TrimSpace(etag), "W/") {
+		return 0, false
+	}
+	return versiDariEtag(etag)
+}
+
+// Set header ETag lalu periksa If-None-Match. Bernilai true jika client sudah
+// memegang versi terbaru sehingga cukup dibalas 304.
+func belumBerubah(c *fiber.Ctx, versi int64) bool {
+	c.Set(fiber.HeaderETag, etagVersi(versi))
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+	for _, etag := range strings.Split(header, ",") {
+		if strings.TrimSpace(etag) == "*" {
+			return true
+		}
+		if v, ok := versiDariEtag(etag); ok && v == versi {
+			return true
+		}
+	}
+	return false
+}
+
+// Syarat field version untuk satu versi, dokumen lama tanpa field version dianggap versi 0
+func syaratVersi(versi int64) interface{} {
+	if versi == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return versi
+}
+
+// Periksa If-Match terhadap versi dokumen yang sudah dibaca. Tanpa header atau dengan "*" selalu cocok.
+func cocokIfMatch(c *fiber.Ctx, versi int64) bool {
+	return cocokEtag(c.Get(fiber.HeaderIfMatch), versi)
+}
+
+// Periksa nilai If-Match (header atau field version di operasi bulk SCIM) terhadap versi dokumen
+func cocokEtag(header string, versi int64) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+	for _, etag := range strings.Split(header, ",") {
+		if v, ok := versiDariEtagKuat(etag); ok && v == versi {
+			return true
+		}
+	}
+	return false
+}
+
+// Syarat field version dari header If-Match. Tanpa header atau dengan "*" update tidak bersyarat.
+// ETag yang tidak bisa dibaca atau weak tidak cocok dengan versi mana pun sehingga berujung 412.
+func syaratIfMatch(c *fiber.Ctx) (bson.M, bool) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return nil, false
+	}
+	versi := bson.A{}
+	for _, etag := range strings.Split(header, ",") {
+		if v, ok := versiDariEtagKuat(etag); ok {
+			versi = append(versi, v)
+			// Dokumen lama belum punya field version dan dianggap versi 0
+			if v == 0 {
+				versi = append(versi, nil)
+			}
+		}
+	}
+	return bson.M{"$in": versi}, true
+}
+
+// Dipanggil saat update bersyarat tidak menemukan dokumen. Jika dokumen masih ada berarti
+// versinya sudah berubah: ETag terbaru di-set dan hasilnya true agar caller membalas 412.
+func versiBerubah(ctx context.Context, c *fiber.Ctx, collection *mongo.Collection, filter bson.M) (bool, error) {
+	var dokumen struct {
+		Version int64 `bson:"version"`
+	}
+	err := collection.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"version": 1})).Decode(&dokumen)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	c.Set(fiber.HeaderETag, etagVersi(dokumen.Version))
+	return true, nil
+}
+
+// Response standar 412 untuk If-Match yang tidak lagi cocok
+func responsVersiBerubah(c *fiber.Ctx) error {
+	return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+		"error": "Resource has been modified by another request, fetch the latest version and retry",
+		"etag":  c.GetRespHeader(fiber.HeaderETag),
+	})
+}
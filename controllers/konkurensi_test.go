@@ -0,0 +1,75 @@
+package controllers
+
+import "testing"
+
+func TestVersiDariEtag(t *testing.T) {
+	tests := []struct {
+		etag      string
+		wantVersi int64
+		wantOK    bool
+		wantKuat  bool // Hasil versiDariEtagKuat
+	}{
+		{`"3"`, 3, true, true},
+		{`  "12" `, 12, true, true},
+		{`"0"`, 0, true, true},
+		{`W/"3"`, 3, true, false},
+		{` W/"7"`, 7, true, false},
+		{`3`, 0, false, false},
+		{`"abc"`, 0, false, false},
+		{`""`, 0, false, false},
+		{`"`, 0, false, false},
+		{`"3`, 0, false, false},
+		{``, 0, false, false},
+		{`*`, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.etag, func(t *testing.T) {
+			versi, ok := versiDariEtag(tt.etag)
+			if ok != tt.wantOK || (ok && versi != tt.wantVersi) {
+				t.Errorf("versiDariEtag(%q) = %d, %v, want %d, %v", tt.etag, versi, ok, tt.wantVersi, tt.wantOK)
+			}
+			versi, ok = versiDariEtagKuat(tt.etag)
+			if ok != tt.wantKuat || (ok && versi != tt.wantVersi) {
+				t.Errorf("versiDariEtagKuat(%q) = %d, %v, want %d, %v", tt.etag, versi, ok, tt.wantVersi, tt.wantKuat)
+			}
+		})
+	}
+}
+
+func TestEtagVersiBisaDibacaKembali(t *testing.T) {
+	for _, versi := range []int64{0, 1, 42, 1 << 40} {
+		etag := etagVersi(versi)
+		got, ok := versiDariEtagKuat(etag)
+		if !ok || got != versi {
+			t.Errorf("versiDariEtagKuat(etagVersi(%d)) = %d, %v", versi, got, ok)
+		}
+	}
+}
+
+func TestCocokEtag(t *testing.T) {
+	tests := []struct {
+		nama   string
+		header string
+		versi  int64
+		want   bool
+	}{
+		{"tanpa header", "", 5, true},
+		{"wildcard", "*", 5, true},
+		{"wildcard dengan spasi", " * ", 5, true},
+		{"versi sama", `"5"`, 5, true},
+		{"versi berbeda", `"4"`, 5, false},
+		{"salah satu dari daftar", `"3", "5"`, 5, true},
+		{"tidak ada di daftar", `"3","4"`, 5, false},
+		{"weak etag ditolak", `W/"5"`, 5, false},
+		{"weak dan strong dalam daftar", `W/"5", "5"`, 5, true},
+		{"etag rusak", `5`, 5, false},
+		{"dokumen lama versi 0", `"0"`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := cocokEtag(tt.header, tt.versi); got != tt.want {
+				t.Errorf("cocokEtag(%q, %d) = %v, want %v", tt.header, tt.versi, got, tt.want)
+			}
+		})
+	}
+}
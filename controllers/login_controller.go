@@ -28,7 +28,8 @@ func LoginHandler(c *fiber.Ctx) error {
 	// Mencari pengguna di database
 	var user model.User
 	err := userCollection.FindOne(context.TODO(), bson.M{
-		"username": loginReq.Username,
+		"username":   loginReq.Username,
+		"deleted_at": nil, // User yang sudah dihapus tidak bisa login
 	}).Decode(&user)
 
 	if err != nil {
@@ -41,15 +42,27 @@ func LoginHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid password"})
 	}
 
+	// Hanya akun active dan graduated yang boleh login
+	if !model.StatusBolehLogin(user.StatusAkun()) {
+		return middlewares.TolakStatusAkun(c, user.StatusAkun())
+	}
+
 	// Generate token
 	token, err := middlewares.GenerateJWT(user.Username, user.Role, user.JenisUser)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
 	}
 
+	// Preferensi ikut dikirim agar modul bisa langsung menyesuaikan bahasa dan tema
+	preferensi, err := preferensiUser(context.TODO(), user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch preferences"})
+	}
+
 	// Kirim token di response
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Login successful",
-		"token":   token,
+		"message":     "Login successful",
+		"token":       token,
+		"preferences": preferensi,
 	})
 }
\ No newline at end of file
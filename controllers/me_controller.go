@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"demoapp/responses"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Field profil yang boleh diubah oleh user sendiri
+var selfEditableFields = map[string]bool{
+	"nm_user":       true,
+	"email":         true,
+	"phone":         true,
+	"jenis_kelamin": true,
+}
+
+// Ambil user yang sedang login berdasarkan username di token JWT
+func userLogin(ctx context.Context, c *fiber.Ctx) (model.User, error) {
+	var user model.User
+	err := UserCollection.FindOne(ctx, bson.M{"username": aktorDari(c), "deleted_at": nil}).Decode(&user)
+	return user, err
+}
+
+// Response standar jika user dari token tidak bisa diambil
+func userLoginError(c *fiber.Ctx, err error) error {
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(responses.UserResponse{
+			Status:  http.StatusNotFound,
+			Message: "error",
+			Data:    &fiber.Map{"error": "User not found"},
+		})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+		Status:  http.StatusInternalServerError,
+		Message: "error",
+		Data:    &fiber.Map{"error": "Error fetching user: " + err.Error()},
+	})
+}
+
+// GetMe - Profil user yang sedang login
+func GetMe(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opsi, err := parseOpsiUser(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responses.UserResponse{
+			Status:  http.StatusBadRequest,
+			Message: "error",
+			Data:    &fiber.Map{"error": err.Error()},
+		})
+	}
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+	data, err := opsi.representasi(ctx, user)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	preferensi, err := preferensiUser(ctx, user.ID)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(responses.UserResponse{
+		Status:  http.StatusOK,
+		Message: "success",
+		Data:    &fiber.Map{"user": data, "preferences": preferensi},
+	})
+}
+
+// EditMe - Ubah profil sendiri, terbatas pada selfEditableFields
+func EditMe(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	return perbaruiUser(ctx, c, user.ID, selfEditableFields)
+}
+
+// PatchMe - Ubah profil sendiri dengan merge patch atau JSON patch, terbatas pada selfEditableFields
+func PatchMe(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	return patchUser(ctx, c, user.ID, selfEditableFields)
+}
+
+// EditMyPassword - Ganti password sendiri
+func EditMyPassword(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	return ubahPassword(c, user.ID)
+}
+
+// UploadMyPhoto - Upload foto profil sendiri
+func UploadMyPhoto(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	return simpanFotoUser(ctx, c, user.ID)
+}
+
+// GetMyModules - Modul yang dapat diakses user yang sedang login
+func GetMyModules(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fields, err := parseOpsiModul(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	moduls, err := modulEfektif(ctx, user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
+	}
+
+	// Modul favorit ditampilkan lebih dulu
+	pref, err := prefModulUser(ctx, user.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch module preferences"})
+	}
+	moduls = urutkanFavorit(moduls, pref.Favorit)
+
+	data, err := representasiModul(fields, moduls)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build module response"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"user_id":     user.ID,
+		"modules":     data,
+		"total_count": len(moduls),
+	})
+}
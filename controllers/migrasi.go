@@ -0,0 +1,476 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var MigrasiCollection = config.GetCollection(config.DB, "migrations")
+
+// Nama index unik di koleksi users, dipakai untuk mengenali field saat terjadi duplicate key
+const (
+	indexUsernameUnik = "username_unique"
+	indexEmailUnik    = "email_active_unique"
+)
+
+// Migrasi dijalankan berurutan sesuai versi dan masing-masing hanya sekali.
+// Setiap migrasi harus aman diulang karena migrasi yang gagal dicoba lagi dari awal.
+type migrasi struct {
+	Versi    int
+	Nama     string
+	Setelah  []int // Versi yang harus sudah diterapkan, migrasi lain tetap jalan walaupun ada yang gagal
+	Jalankan func(ctx context.Context) error
+}
+
+// Daftar migrasi. Migrasi yang sudah dirilis tidak boleh diubah, tambahkan versi baru di akhir.
+func daftarMigrasi() []migrasi {
+	return []migrasi{
+		{Versi: 1, Nama: "backfill_user_fields", Jalankan: migrasiBackfillUser},
+		{Versi: 2, Nama: "backfill_search_ngram", Jalankan: migrasiBackfillNgram},
+		{Versi: 3, Nama: "index_users", Jalankan: migrasiIndexUser},
+		{Versi: 4, Nama: "index_relations", Jalankan: migrasiIndexRelasi},
+		{Versi: 5, Nama: "index_users_email_unique", Setelah: []int{1}, Jalankan: migrasiIndexEmail},
+		{Versi: 6, Nama: "index_users_external_id", Jalankan: migrasiIndexExternalID},
+		{Versi: 7, Nama: "index_sync", Jalankan: migrasiIndexSync},
+		{Versi: 8, Nama: "index_transisi_jenis_user", Jalankan: migrasiIndexTransisi},
+		{Versi: 9, Nama: "baseline_histori", Setelah: []int{1, 4}, Jalankan: migrasiBaselineHistori},
+	}
+}
+
+// Field yang ditambahkan belakangan diisi untuk dokumen lama:
+// deleted_at null, status active dan version 0
+func migrasiBackfillUser(ctx context.Context) error {
+	if _, err := userCollection.UpdateMany(ctx, bson.M{"deleted_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"deleted_at": nil}}); err != nil {
+		return err
+	}
+	if _, err := userCollection.UpdateMany(ctx, bson.M{"status": bson.M{"$in": bson.A{"", nil}}}, bson.M{"$set": bson.M{"status": model.StatusActive}}); err != nil {
+		return err
+	}
+	for _, collection := range []*mongo.Collection{userCollection, modulCollection, UserModulCollection} {
+		if _, err := collection.UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": 0}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// User lama yang belum punya search_ngram
+func migrasiBackfillNgram(ctx context.Context) error {
+	_, err := reindexPencarianUser(ctx, bson.M{"search_ngram": bson.M{"$exists": false}})
+	return err
+}
+
+func migrasiIndexUser(ctx context.Context) error {
+	_, err := userCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// Username tetap dipakai oleh user yang sudah dihapus sampai di-purge
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetName(indexUsernameUnik).SetUnique(true)},
+		{Keys: bson.D{{Key: "search_ngram", Value: 1}}, Options: options.Index().SetName("search_ngram")},
+		{Keys: bson.D{{Key: "jenis_user", Value: 1}}, Options: options.Index().SetName("jenis_user")},
+		{Keys: bson.D{{Key: "status", Value: 1}}, Options: options.Index().SetName("status")},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("deleted_at_created_at")},
+	})
+	return errorIndexUnik(err)
+}
+
+// Index untuk koleksi yang mereferensikan user, modul dan katalog jenis_user.
+// Dokumen yang ditulis dengan upsert per pasangan kunci dijaga unik.
+func migrasiIndexRelasi(ctx context.Context) error {
+	// Upsert bersamaan sebelum index unik ada bisa membuat preferensi modul ganda per user
+	if err := gabungkanPrefModulGanda(ctx); err != nil {
+		return fmt.Errorf("%s: %w", UserModulPrefCollection.Name(), err)
+	}
+
+	daftar := []struct {
+		Collection *mongo.Collection
+		Index      []mongo.IndexModel
+	}{
+		{UserModulCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+			{Keys: bson.D{{Key: "jenis_user", Value: 1}, {Key: "catatan", Value: 1}}, Options: options.Index().SetName("jenis_user_catatan")},
+		}},
+		{JenisUserCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "kode", Value: 1}}, Options: options.Index().SetName("kode_unique").SetUnique(true)},
+		}},
+		{GrupCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+		}},
+		{UserModulDenyCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "modul_id", Value: 1}}, Options: options.Index().SetName("user_id_modul_id_unique").SetUnique(true)},
+		}},
+		{PengumumanStatusCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "pengumuman_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetName("pengumuman_id_user_id_unique").SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+		}},
+		{PreferensiCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id_unique").SetUnique(true)},
+		}},
+		{UserModulPrefCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id_unique").SetUnique(true)},
+		}},
+		{UserStatusLogCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("user_id_created_at")},
+		}},
+		{HistoriCollection, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "koleksi", Value: 1}, {Key: "dokumen_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("koleksi_dokumen_id_created_at")},
+		}},
+	}
+	for _, item := range daftar {
+		if _, err := item.Collection.Indexes().CreateMany(ctx, item.Index); err != nil {
+			return fmt.Errorf("%s: %w", item.Collection.Name(), errorIndexUnik(err))
+		}
+	}
+	return nil
+}
+
+// Gabungkan dokumen usermodul_pref ganda milik user yang sama ke dokumen tertua:
+// favorit digabung sesuai urutan, riwayat modul terakhir diambil yang terbaru per modul
+func gabungkanPrefModulGanda(ctx context.Context) error {
+	cursor, err := UserModulPrefCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "docs": bson.M{"$push": "$$ROOT"}, "jumlah": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"jumlah": bson.M{"$gt": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var ganda []struct {
+		Docs []model.UserModulPref `bson:"docs"`
+	}
+	if err := cursor.All(ctx, &ganda); err != nil {
+		return err
+	}
+
+	for _, item := range ganda {
+		gabungan := gabungkanPrefModul(item.Docs)
+		_, err := UserModulPrefCollection.UpdateOne(ctx, bson.M{"_id": gabungan.ID}, bson.M{"$set": bson.M{
+			"favorit":    gabungan.Favorit,
+			"terakhir":   gabungan.Terakhir,
+			"updated_at": time.Now(),
+		}})
+		if err != nil {
+			return err
+		}
+		lain := []primitive.ObjectID{}
+		for _, doc := range item.Docs[1:] {
+			lain = append(lain, doc.ID)
+		}
+		if _, err := UserModulPrefCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": lain}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gabungan beberapa preferensi modul milik satu user, ID dokumen pertama dipertahankan
+func gabungkanPrefModul(docs []model.UserModulPref) model.UserModulPref {
+	hasil := model.UserModulPref{ID: docs[0].ID, UserID: docs[0].UserID, Favorit: []primitive.ObjectID{}, Terakhir: []model.ModulTerakhir{}}
+	sudahFavorit := map[primitive.ObjectID]bool{}
+	terbaru := map[primitive.ObjectID]time.Time{}
+	for _, doc := range docs {
+		for _, modulID := range doc.Favorit {
+			if !sudahFavorit[modulID] {
+				sudahFavorit[modulID] = true
+				hasil.Favorit = append(hasil.Favorit, modulID)
+			}
+		}
+		for _, entri := range doc.Terakhir {
+			if waktu, ok := terbaru[entri.ModulID]; !ok || entri.DibukaPada.After(waktu) {
+				terbaru[entri.ModulID] = entri.DibukaPada
+			}
+		}
+	}
+	for modulID, waktu := range terbaru {
+		hasil.Terakhir = append(hasil.Terakhir, model.ModulTerakhir{ModulID: modulID, DibukaPada: waktu})
+	}
+	sort.Slice(hasil.Terakhir, func(i, j int) bool {
+		return hasil.Terakhir[i].DibukaPada.After(hasil.Terakhir[j].DibukaPada)
+	})
+	if len(hasil.Terakhir) > batasModulTerakhir {
+		hasil.Terakhir = hasil.Terakhir[:batasModulTerakhir]
+	}
+	return hasil
+}
+
+// Email unik hanya di antara user aktif: akun yang dihapus atau hasil merge boleh memakai email yang sama.
+// Dipisah dari index_users karena data lama bisa berisi akun ganda yang perlu di-merge lebih dulu.
+func migrasiIndexEmail(ctx context.Context) error {
+	_, err := userCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().
+			SetName(indexEmailUnik).
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"deleted_at": bson.M{"$type": "null"}}),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("active users share an email address, merge them via /admin/users/duplicates first: %w", err)
+	}
+	return err
+}
+
+// externalId dipakai klien SCIM untuk mencari user dari sistem sumber
+func migrasiIndexExternalID(ctx context.Context) error {
+	_, err := userCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "external_id", Value: 1}},
+		Options: options.Index().
+			SetName("external_id").
+			SetPartialFilterExpression(bson.M{"external_id": bson.M{"$type": "string"}}),
+	})
+	return err
+}
+
+// Index sumber dan laporan sync feed akademik/HR
+func migrasiIndexSync(ctx context.Context) error {
+	_, err := userCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "sumber_sync", Value: 1}},
+		Options: options.Index().
+			SetName("sumber_sync").
+			SetPartialFilterExpression(bson.M{"sumber_sync": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = SyncSumberCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "nama", Value: 1}},
+		Options: options.Index().SetName("nama_unik").SetUnique(true),
+	})
+	if err != nil {
+		return errorIndexUnik(err)
+	}
+	_, err = SyncRunCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "sumber_id", Value: 1}, {Key: "dimulai_pada", Value: -1}},
+	})
+	return err
+}
+
+// Index kohort user dan hasil per user transisi jenis_user. Pasangan transisi_id dan user_id
+// unik agar daftar kohort aman disimpan ulang saat transisi dilanjutkan.
+func migrasiIndexTransisi(ctx context.Context) error {
+	_, err := userCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "jenis_user", Value: 1}, {Key: "angkatan", Value: 1}},
+		Options: options.Index().SetName("jenis_user_angkatan"),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = TransisiCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "jadwal", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = HasilTransisiCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "transisi_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("transisi_user_unik").SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "transisi_id", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return errorIndexUnik(err)
+}
+
+// Dokumen yang dibuat sebelum histori dicatat diberi versi baseline agar perubahan pertamanya bisa di-revert
+func migrasiBaselineHistori(ctx context.Context) error {
+	for nama, koleksi := range daftarKoleksiHistori() {
+		jumlah, err := catatBaselineHistori(ctx, nama, koleksi.Collection)
+		if err != nil {
+			return fmt.Errorf("%s: %w", nama, err)
+		}
+		if jumlah > 0 {
+			fmt.Println("Recorded baseline history for", jumlah, nama, "documents")
+		}
+	}
+	return nil
+}
+
+// Index unik yang gagal karena data ganda diberi pesan yang lebih jelas
+func errorIndexUnik(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("existing documents violate a unique index, remove the duplicates and rerun migrations: %w", err)
+	}
+	return err
+}
+
+// Field users yang melanggar index unik, kosong jika err bukan duplicate key pada users
+func fieldDuplikatUser(err error) string {
+	if !mongo.IsDuplicateKeyError(err) {
+		return ""
+	}
+	switch {
+	case strings.Contains(err.Error(), indexUsernameUnik):
+		return "username"
+	case strings.Contains(err.Error(), indexEmailUnik):
+		return "email"
+	}
+	return ""
+}
+
+// Pesan 409 untuk field users yang melanggar index unik
+func pesanDuplikatUser(field string) string {
+	if field == "email" {
+		return "Email is already used by another account"
+	}
+	return "Username is already taken"
+}
+
+var errMigrasiBerjalan = errors.New("migration is already running in another process")
+
+// Migrasi berstatus running yang lebih lama dari ini dianggap ditinggalkan oleh proses yang mati
+const batasMigrasiBerjalan = 30 * time.Minute
+
+// Tandai migrasi sedang berjalan. Insert dengan _id versi menjadi kunci agar
+// dua proses yang start bersamaan tidak menjalankan migrasi yang sama.
+// Bernilai false jika migrasi sudah pernah diterapkan.
+func klaimMigrasi(ctx context.Context, m migrasi) (bool, error) {
+	_, err := MigrasiCollection.InsertOne(ctx, model.Migrasi{
+		Versi:       m.Versi,
+		Nama:        m.Nama,
+		Status:      model.MigrasiRunning,
+		DimulaiPada: time.Now(),
+	})
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	// Migrasi yang gagal sebelumnya, atau yang prosesnya mati di tengah jalan, diklaim ulang
+	result, err := MigrasiCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": m.Versi, "$or": bson.A{
+			bson.M{"status": model.MigrasiFailed},
+			bson.M{"status": model.MigrasiRunning, "dimulai_pada": bson.M{"$lt": time.Now().Add(-batasMigrasiBerjalan)}},
+		}},
+		bson.M{"$set": bson.M{"status": model.MigrasiRunning, "dimulai_pada": time.Now()}, "$unset": bson.M{"error": "", "selesai_pada": ""}},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+
+	var tercatat model.Migrasi
+	if err := MigrasiCollection.FindOne(ctx, bson.M{"_id": m.Versi}).Decode(&tercatat); err != nil {
+		return false, err
+	}
+	if tercatat.Status == model.MigrasiRunning {
+		return false, errMigrasiBerjalan
+	}
+	return false, nil
+}
+
+// JalankanMigrasi menerapkan semua migrasi yang belum pernah berhasil, berurutan.
+// Migrasi yang gagal tidak menghentikan migrasi lain, hanya migrasi yang bergantung padanya
+// (lihat Setelah) yang ditunda. Semua error digabung, nama migrasi yang diterapkan dikembalikan.
+func JalankanMigrasi(ctx context.Context) ([]string, error) {
+	diterapkan := []string{}
+	errs := []error{}
+	tertunda := map[int]bool{} // Versi yang gagal atau ditunda pada putaran ini
+	for _, m := range daftarMigrasi() {
+		if versi, ok := syaratMigrasiTertunda(m, tertunda); ok {
+			tertunda[m.Versi] = true
+			errs = append(errs, fmt.Errorf("migration %d %s: skipped until migration %d succeeds", m.Versi, m.Nama, versi))
+			continue
+		}
+
+		klaim, err := klaimMigrasi(ctx, m)
+		if err != nil {
+			tertunda[m.Versi] = true
+			errs = append(errs, fmt.Errorf("migration %d %s: %w", m.Versi, m.Nama, err))
+			continue
+		}
+		if !klaim {
+			continue
+		}
+
+		fmt.Println("Applying migration", m.Versi, m.Nama)
+		errMigrasi := m.Jalankan(ctx)
+		selesai := time.Now()
+		set := bson.M{"status": model.MigrasiApplied, "selesai_pada": selesai}
+		if errMigrasi != nil {
+			set = bson.M{"status": model.MigrasiFailed, "selesai_pada": selesai, "error": errMigrasi.Error()}
+		}
+		// Status dicatat dengan context baru agar tetap tersimpan walaupun ctx sudah habis
+		ctxCatat, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err = MigrasiCollection.UpdateOne(ctxCatat, bson.M{"_id": m.Versi}, bson.M{"$set": set})
+		cancel()
+		if errMigrasi != nil {
+			tertunda[m.Versi] = true
+			errs = append(errs, fmt.Errorf("migration %d %s: %w", m.Versi, m.Nama, errMigrasi))
+			continue
+		}
+		if err != nil {
+			tertunda[m.Versi] = true
+			errs = append(errs, fmt.Errorf("migration %d %s: failed to record status: %w", m.Versi, m.Nama, err))
+			continue
+		}
+		diterapkan = append(diterapkan, m.Nama)
+	}
+	return diterapkan, errors.Join(errs...)
+}
+
+// Versi syarat migrasi yang gagal atau ditunda pada putaran ini
+func syaratMigrasiTertunda(m migrasi, tertunda map[int]bool) (int, bool) {
+	for _, versi := range m.Setelah {
+		if tertunda[versi] {
+			return versi, true
+		}
+	}
+	return 0, false
+}
+
+// GetMigrations - Daftar migrasi beserta statusnya, migrasi yang belum pernah dijalankan berstatus pending
+func GetMigrations(c *fiber.Ctx) error {
+	cursor, err := MigrasiCollection.Find(c.Context(), bson.M{})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch migrations"})
+	}
+	var tercatat []model.Migrasi
+	if err := cursor.All(c.Context(), &tercatat); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode migrations"})
+	}
+	perVersi := map[int]model.Migrasi{}
+	for _, m := range tercatat {
+		perVersi[m.Versi] = m
+	}
+
+	hasil := []model.Migrasi{}
+	for _, m := range daftarMigrasi() {
+		item, ok := perVersi[m.Versi]
+		if !ok {
+			item = model.Migrasi{Versi: m.Versi, Nama: m.Nama, Status: "pending"}
+		}
+		hasil = append(hasil, item)
+	}
+	return c.JSON(fiber.Map{"migrations": hasil})
+}
+
+// RunMigrations - Jalankan migrasi yang tertunda tanpa restart, misalnya setelah akun ganda di-merge
+func RunMigrations(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	diterapkan, err := JalankanMigrasi(ctx)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errMigrasiBerjalan) {
+			status = http.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{"error": err.Error(), "applied": diterapkan})
+	}
+	return c.JSON(fiber.Map{"message": "Migrations are up to date", "applied": diterapkan})
+}
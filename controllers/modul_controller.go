@@ -12,11 +12,13 @@ import (
 	"time"
 
 	"demoapp/model"
+	"demoapp/responses"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Global MongoDB Collection untuk Modul
@@ -84,6 +86,7 @@ func CreateModul(c *fiber.Ctx) error {
 	// Set timestamps
 	modul.CreatedAt = time.Now()
 	modul.UpdatedAt = time.Now()
+	modul.Version = 1
 
 	// Validasi data
 	if err := validater.Struct(modul); err != nil {
@@ -91,11 +94,14 @@ func CreateModul(c *fiber.Ctx) error {
 	}
 
 	// Insert modul ke MongoDB
+	modul.ID = primitive.NewObjectID()
 	result, err := modulCollection.InsertOne(context.TODO(), modul)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create modul"})
 	}
+	catatHistori(context.TODO(), modulCollection, AksiCreate, aktorDari(c), modul.ID)
 
+	c.Set(fiber.HeaderETag, etagVersi(modul.Version))
 	return c.Status(http.StatusCreated).JSON(fiber.Map{
 		"message": "Modul created successfully",
 		"id":      result.InsertedID,
@@ -104,6 +110,11 @@ func CreateModul(c *fiber.Ctx) error {
 
 // Get All Moduls
 func GetAllModuls(c *fiber.Ctx) error {
+	fields, err := parseOpsiModul(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Mengambil data dari database
 	cursor, err := modulCollection.Find(context.TODO(), bson.M{})
 	if err != nil {
@@ -146,11 +157,22 @@ func GetAllModuls(c *fiber.Ctx) error {
 		})
 	}
 
+	data, err := representasiModul(fields, moduls)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"status":  http.StatusInternalServerError,
+			"message": "error",
+			"data": fiber.Map{
+				"error": "Failed to build modul response",
+			},
+		})
+	}
+
 	// Mengembalikan daftar modul yang berhasil ditemukan
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"status":  http.StatusOK,
 		"message": "success",
-		"data":    moduls,
+		"data":    data,
 	})
 }
 
@@ -163,13 +185,26 @@ func GetModulByID(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
 	}
 
+	fields, err := parseOpsiModul(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	var modul model.Modul
 	err = modulCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&modul)
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
 	}
 
-	return c.JSON(modul)
+	if belumBerubah(c, modul.Version) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	data, err := fields.Terapkan(responses.NewModulDTO(modul))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build modul response"})
+	}
+	return c.JSON(data)
 }
 
 // Update Modul
@@ -182,27 +217,19 @@ func UpdateModul(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
 	}
 
-	// Mencari modul berdasarkan ID
-	var existingModul model.Modul
-	err = modulCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&existingModul)
-	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
-	}
-
-	modul := &existingModul
-
-	// Parse form data hanya jika tidak kosong dan update hanya yang ada isinya
+	// Hanya field yang dikirim yang diubah
+	update := bson.M{}
 	if nmModul := c.FormValue("nm_modul"); nmModul != "" {
-		modul.NmModul = nmModul
+		update["nm_modul"] = nmModul
 	}
 	if ketModul := c.FormValue("ket_modul"); ketModul != "" {
-		modul.KetModul = ketModul
+		update["ket_modul"] = ketModul
 	}
 	if alamat := c.FormValue("alamat"); alamat != "" {
-		modul.Alamat = alamat
+		update["alamat"] = alamat
 	}
 	if isAktif := c.FormValue("is_aktif"); isAktif != "" {
-		modul.IsAktif = isAktif == "true"
+		update["is_aktif"] = isAktif == "true"
 	}
 
 	// Urutan hanya jika valid dan tidak kosong
@@ -211,30 +238,130 @@ func UpdateModul(c *fiber.Ctx) error {
 		if err != nil {
 			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'urutan' value"})
 		}
-		modul.Urutan = urutan
+		update["urutan"] = urutan
 	}
 
-	// Handle file upload jika ada file baru
+	// Handle file upload jika ada file baru. Jika update gagal, file yang sudah tersimpan
+	// dihapus lagi agar tidak tertinggal di folder uploads.
+	batalkanUpload := func() {}
 	if file, err := c.FormFile("gbr_icon"); err == nil {
 		filename, fileErr := saveFile(file)
 		if fileErr != nil {
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": fileErr.Error()})
 		}
-		modul.Gbr_Icon = filename
+		update["gbr_icon"] = filename
+		batalkanUpload = func() { os.Remove("./uploads/" + filename) }
 	}
 
 	// Set timestamp update
-	modul.UpdatedAt = time.Now()
+	update["updated_at"] = time.Now()
 
-	// Update hanya field yang diubah
-	update := bson.M{"$set": modul}
-	_, err = modulCollection.UpdateOne(context.TODO(), bson.M{"_id": objectID}, update)
+	// Dengan If-Match update hanya berlaku jika versi modul belum berubah
+	filter := bson.M{"_id": objectID}
+	syarat, bersyarat := syaratIfMatch(c)
+	if bersyarat {
+		filter["version"] = syarat
+	}
+
+	var modul model.Modul
+	err = modulCollection.FindOneAndUpdate(
+		context.TODO(),
+		filter,
+		bson.M{"$set": update, "$inc": naikkanVersi},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&modul)
+	if err != nil {
+		batalkanUpload()
+	}
+	if err == mongo.ErrNoDocuments {
+		if bersyarat {
+			berubah, err := versiBerubah(context.TODO(), c, modulCollection, bson.M{"_id": objectID})
+			if err != nil {
+				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update modul"})
+			}
+			if berubah {
+				return responsVersiBerubah(c)
+			}
+		}
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
+	}
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update modul"})
 	}
 
-	return c.JSON(fiber.Map{"message": "Modul updated successfully"})
+	catatHistori(context.TODO(), modulCollection, AksiUpdate, aktorDari(c), objectID)
+
+	c.Set(fiber.HeaderETag, etagVersi(modul.Version))
+	return c.JSON(fiber.Map{"message": "Modul updated successfully", "modul": responses.NewModulDTO(modul)})
+}
+
+// Field modul yang boleh diubah lewat PATCH
+var modulPatchFields = map[string]bool{
+	"nm_modul":  true,
+	"ket_modul": true,
+	"is_aktif":  true,
+	"alamat":    true,
+	"urutan":    true,
+	"gbr_icon":  true,
 }
+
+// PatchModul - Ubah modul dengan JSON Merge Patch (RFC 7396) atau JSON Patch (RFC 6902).
+// Berbeda dengan UpdateModul, field bisa dikosongkan dan tipe setiap field diperiksa.
+func PatchModul(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("modulId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var modul model.Modul
+	if err := modulCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&modul); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch modul"})
+	}
+	if !cocokIfMatch(c, modul.Version) {
+		c.Set(fiber.HeaderETag, etagVersi(modul.Version))
+		return responsVersiBerubah(c)
+	}
+
+	set, err := terapkanPatch(c, &modul, modulPatchFields, validater)
+	if err != nil {
+		return responsPatchError(c, err)
+	}
+	if len(set) == 0 {
+		c.Set(fiber.HeaderETag, etagVersi(modul.Version))
+		return c.JSON(fiber.Map{"message": "No changes", "modul": responses.NewModulDTO(modul)})
+	}
+	set["updated_at"] = time.Now()
+
+	// Update hanya berlaku jika modul belum diubah sejak dibaca
+	err = modulCollection.FindOneAndUpdate(
+		context.TODO(),
+		bson.M{"_id": objectID, "version": syaratVersi(modul.Version)},
+		bson.M{"$set": set, "$inc": naikkanVersi},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&modul)
+	if err == mongo.ErrNoDocuments {
+		berubah, err := versiBerubah(context.TODO(), c, modulCollection, bson.M{"_id": objectID})
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update modul"})
+		}
+		if !berubah {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
+		}
+		return responsVersiBerubah(c)
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update modul"})
+	}
+
+	catatHistori(context.TODO(), modulCollection, AksiUpdate, aktorDari(c), objectID)
+
+	c.Set(fiber.HeaderETag, etagVersi(modul.Version))
+	return c.JSON(fiber.Map{"message": "Modul updated successfully", "modul": responses.NewModulDTO(modul)})
+}
+
 // Delete Modul
 func DeleteModul(c *fiber.Ctx) error {
 	id := c.Params("modulId")
@@ -249,6 +376,7 @@ func DeleteModul(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
 	}
+	catatHistori(context.TODO(), modulCollection, AksiDelete, aktorDari(c), objectID)
 
 	if modul.Gbr_Icon != "" {
 		filePath := "./uploads/" + modul.Gbr_Icon
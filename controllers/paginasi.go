@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/responses"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	limitHalamanDefault = 20
+	limitHalamanMaks    = 100
+)
+
+var errCursorTidakValid = errors.New("invalid cursor")
+
+// Urutan untuk satu field, Naik false berarti descending
+type urutanField struct {
+	Field string
+	Naik  bool
+}
+
+// Parameter halaman yang dibaca dari query string
+type halamanQuery struct {
+	Limit  int
+	Page   int // Lebih dari 0 berarti mode offset, selain itu mode cursor
+	Cursor string
+	Urutan []urutanField // Selalu diakhiri _id agar urutan deterministik
+}
+
+// Isi cursor keyset sebelum di-encode
+type isiCursor struct {
+	Arah  string `bson:"a"` // "n" halaman berikutnya, "p" halaman sebelumnya
+	Sort  string `bson:"s"` // Sort saat cursor dibuat, cursor tidak berlaku untuk sort lain
+	Nilai bson.A `bson:"v"` // Nilai field sort dari dokumen batas
+}
+
+// Hasil satu halaman beserta meta dan link
+type hasilHalaman struct {
+	Dokumen []bson.Raw
+	Meta    responses.PageMeta
+	Links   responses.PageLinks
+}
+
+// Baca limit, page, cursor dan sort dari query string.
+// Format sort: sort=-created_at,nm_user (tanda - untuk descending).
+func parseHalaman(c *fiber.Ctx, sortDiizinkan map[string]bool, sortDefault string) (halamanQuery, error) {
+	q := halamanQuery{Limit: limitHalamanDefault, Cursor: c.Query("cursor")}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("limit must be a positive number")
+		}
+		if n > limitHalamanMaks {
+			n = limitHalamanMaks
+		}
+		q.Limit = n
+	}
+
+	if page := c.Query("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("page must be a positive number")
+		}
+		if q.Cursor != "" {
+			return q, fmt.Errorf("page and cursor cannot be used together")
+		}
+		q.Page = n
+	}
+
+	sort := c.Query("sort", sortDefault)
+	sudah := map[string]bool{}
+	for _, bagian := range strings.Split(sort, ",") {
+		bagian = strings.TrimSpace(bagian)
+		if bagian == "" {
+			continue
+		}
+		urutan := urutanField{Field: bagian, Naik: true}
+		if strings.HasPrefix(bagian, "-") {
+			urutan = urutanField{Field: bagian[1:], Naik: false}
+		} else if strings.HasPrefix(bagian, "+") {
+			urutan.Field = bagian[1:]
+		}
+		if !sortDiizinkan[urutan.Field] && urutan.Field != "_id" {
+			return q, fmt.Errorf("cannot sort by field: %s", urutan.Field)
+		}
+		if sudah[urutan.Field] {
+			return q, fmt.Errorf("duplicate sort field: %s", urutan.Field)
+		}
+		sudah[urutan.Field] = true
+		q.Urutan = append(q.Urutan, urutan)
+	}
+	if !sudah["_id"] {
+		q.Urutan = append(q.Urutan, urutanField{Field: "_id", Naik: true})
+	}
+
+	return q, nil
+}
+
+// Representasi teks urutan, misalnya ["-created_at", "_id"]
+func teksUrutan(urutan []urutanField) []string {
+	hasil := make([]string, 0, len(urutan))
+	for _, u := range urutan {
+		if u.Naik {
+			hasil = append(hasil, u.Field)
+		} else {
+			hasil = append(hasil, "-"+u.Field)
+		}
+	}
+	return hasil
+}
+
+// Dokumen sort untuk MongoDB, dibalik saat mengambil halaman sebelumnya
+func bsonUrutan(urutan []urutanField, balik bool) bson.D {
+	sort := bson.D{}
+	for _, u := range urutan {
+		arah := 1
+		if u.Naik == balik {
+			arah = -1
+		}
+		sort = append(sort, bson.E{Key: u.Field, Value: arah})
+	}
+	return sort
+}
+
+// Kondisi field setelah nilai batas. Null dan field kosong dianggap paling kecil,
+// sama seperti urutan sort MongoDB. Nil berarti tidak ada dokumen yang memenuhi.
+func kondisiSetelah(field string, nilai interface{}, lebihBesar bool) bson.M {
+	if nilai == nil {
+		if lebihBesar {
+			return bson.M{field: bson.M{"$ne": nil}}
+		}
+		return nil
+	}
+	if lebihBesar {
+		return bson.M{field: bson.M{"$gt": nilai}}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{"$lt": nilai}},
+		bson.M{field: nil},
+	}}
+}
+
+// Filter keyset: dokumen yang letaknya setelah (maju) atau sebelum nilai batas
+func kondisiCursor(urutan []urutanField, nilai bson.A, maju bool) bson.M {
+	cabang := bson.A{}
+	for i, u := range urutan {
+		lebihBesar := u.Naik == maju
+		batas := kondisiSetelah(u.Field, nilai[i], lebihBesar)
+		if batas == nil {
+			continue
+		}
+		kondisi := bson.A{}
+		for j := 0; j < i; j++ {
+			kondisi = append(kondisi, bson.M{urutan[j].Field: nilai[j]})
+		}
+		kondisi = append(kondisi, batas)
+		cabang = append(cabang, bson.M{"$and": kondisi})
+	}
+	return bson.M{"$or": cabang}
+}
+
+// Ambil nilai field sort dari dokumen sebagai kunci cursor
+func nilaiKunci(dokumen bson.Raw, urutan []urutanField) bson.A {
+	nilai := bson.A{}
+	for _, u := range urutan {
+		rv, err := dokumen.LookupErr(u.Field)
+		if err != nil || rv.Type == bsontype.Null {
+			nilai = append(nilai, nil)
+			continue
+		}
+		nilai = append(nilai, rv)
+	}
+	return nilai
+}
+
+func encodeCursor(arah string, urutan []urutanField, dokumen bson.Raw) (string, error) {
+	data, err := bson.MarshalExtJSON(isiCursor{
+		Arah:  arah,
+		Sort:  strings.Join(teksUrutan(urutan), ","),
+		Nilai: nilaiKunci(dokumen, urutan),
+	}, true, false)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string, urutan []urutanField) (isiCursor, error) {
+	var isi isiCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return isi, errCursorTidakValid
+	}
+	if err := bson.UnmarshalExtJSON(data, true, &isi); err != nil {
+		return isi, errCursorTidakValid
+	}
+	if isi.Sort != strings.Join(teksUrutan(urutan), ",") || len(isi.Nilai) != len(urutan) {
+		return isi, errCursorTidakValid
+	}
+	if isi.Arah != "n" && isi.Arah != "p" {
+		return isi, errCursorTidakValid
+	}
+	return isi, nil
+}
+
+// URL halaman saat ini dengan sebagian query diganti atau dihapus
+func urlHalaman(c *fiber.Ctx, ganti map[string]string, hapus ...string) string {
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	for kunci, nilai := range ganti {
+		query.Set(kunci, nilai)
+	}
+	for _, kunci := range hapus {
+		query.Del(kunci)
+	}
+	if len(query) == 0 {
+		return c.BaseURL() + c.Path()
+	}
+	return c.BaseURL() + c.Path() + "?" + query.Encode()
+}
+
+// Ambil satu halaman dokumen dari collection sesuai filter dan parameter halaman
+func cariHalaman(ctx context.Context, c *fiber.Ctx, collection *mongo.Collection, filter bson.M, q halamanQuery) (hasilHalaman, error) {
+	hasil := hasilHalaman{
+		Meta:  responses.PageMeta{Limit: q.Limit, Sort: teksUrutan(q.Urutan)},
+		Links: responses.PageLinks{Self: urlHalaman(c, nil)},
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return hasil, err
+	}
+	hasil.Meta.Total = total
+
+	if q.Page > 0 {
+		opts := options.Find().
+			SetSort(bsonUrutan(q.Urutan, false)).
+			SetSkip(int64((q.Page - 1) * q.Limit)).
+			SetLimit(int64(q.Limit))
+		cursor, err := collection.Find(ctx, filter, opts)
+		if err != nil {
+			return hasil, err
+		}
+		if err := cursor.All(ctx, &hasil.Dokumen); err != nil {
+			return hasil, err
+		}
+
+		totalPages := int((total + int64(q.Limit) - 1) / int64(q.Limit))
+		hasil.Meta.Page = q.Page
+		hasil.Meta.TotalPages = totalPages
+		if q.Page < totalPages {
+			hasil.Links.Next = urlHalaman(c, map[string]string{"page": strconv.Itoa(q.Page + 1)})
+		}
+		if q.Page > 1 && totalPages > 0 {
+			prev := q.Page - 1
+			if prev > totalPages {
+				prev = totalPages
+			}
+			hasil.Links.Prev = urlHalaman(c, map[string]string{"page": strconv.Itoa(prev)})
+		}
+		hasil.Meta.Count = len(hasil.Dokumen)
+		return hasil, nil
+	}
+
+	// Mode cursor (keyset): ambil satu dokumen lebih untuk tahu masih ada halaman lain
+	maju := true
+	filterHalaman := filter
+	if q.Cursor != "" {
+		isi, err := decodeCursor(q.Cursor, q.Urutan)
+		if err != nil {
+			return hasil, err
+		}
+		maju = isi.Arah == "n"
+		filterHalaman = bson.M{"$and": bson.A{filter, kondisiCursor(q.Urutan, isi.Nilai, maju)}}
+	}
+
+	opts := options.Find().
+		SetSort(bsonUrutan(q.Urutan, !maju)).
+		SetLimit(int64(q.Limit + 1))
+	cursor, err := collection.Find(ctx, filterHalaman, opts)
+	if err != nil {
+		return hasil, err
+	}
+	var dokumen []bson.Raw
+	if err := cursor.All(ctx, &dokumen); err != nil {
+		return hasil, err
+	}
+
+	adaLagi := len(dokumen) > q.Limit
+	if adaLagi {
+		dokumen = dokumen[:q.Limit]
+	}
+	if !maju {
+		for i, j := 0, len(dokumen)-1; i < j; i, j = i+1, j-1 {
+			dokumen[i], dokumen[j] = dokumen[j], dokumen[i]
+		}
+	}
+	hasil.Dokumen = dokumen
+	hasil.Meta.Count = len(dokumen)
+
+	if len(dokumen) > 0 {
+		// Maju: halaman berikutnya ada jika masih ada sisa, halaman sebelumnya ada jika datang dari cursor.
+		// Mundur: kebalikannya.
+		adaNext, adaPrev := adaLagi, q.Cursor != ""
+		if !maju {
+			adaNext, adaPrev = true, adaLagi
+		}
+		if adaNext {
+			next, err := encodeCursor("n", q.Urutan, dokumen[len(dokumen)-1])
+			if err != nil {
+				return hasil, err
+			}
+			hasil.Meta.NextCursor = next
+			hasil.Links.Next = urlHalaman(c, map[string]string{"cursor": next}, "page")
+		}
+		if adaPrev {
+			prev, err := encodeCursor("p", q.Urutan, dokumen[0])
+			if err != nil {
+				return hasil, err
+			}
+			hasil.Meta.PrevCursor = prev
+			hasil.Links.Prev = urlHalaman(c, map[string]string{"cursor": prev}, "page")
+		}
+	}
+
+	return hasil, nil
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Media type patch yang didukung
+const (
+	mediaMergePatch = "application/merge-patch+json" // RFC 7396
+	mediaJSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+// Kesalahan saat menerapkan patch beserta status HTTP-nya
+type errorPatch struct {
+	Status int
+	Pesan  string
+	Fields map[string]string // Kesalahan validasi per field JSON
+}
+
+func (e *errorPatch) Error() string {
+	return e.Pesan
+}
+
+// Response standar untuk errorPatch, error lain dianggap 500
+func responsPatchError(c *fiber.Ctx, err error) error {
+	var errPatch *errorPatch
+	if !errors.As(err, &errPatch) {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	body := fiber.Map{"error": errPatch.Pesan}
+	if len(errPatch.Fields) > 0 {
+		body["fields"] = errPatch.Fields
+	}
+	if errPatch.Status == http.StatusUnsupportedMediaType {
+		c.Set("Accept-Patch", mediaMergePatch+", "+mediaJSONPatch)
+	}
+	return c.Status(errPatch.Status).JSON(body)
+}
+
+// Field struct yang boleh di-patch, berdasarkan nama field JSON-nya
+type fieldPatch struct {
+	Index int
+	JSON  string
+	BSON  string
+	Go    string
+}
+
+func daftarFieldPatch(t reflect.Type, diizinkan map[string]bool) []fieldPatch {
+	fields := []fieldPatch{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		nama := strings.Split(f.Tag.Get("json"), ",")[0]
+		if !diizinkan[nama] {
+			continue
+		}
+		fields = append(fields, fieldPatch{
+			Index: i,
+			JSON:  nama,
+			BSON:  strings.Split(f.Tag.Get("bson"), ",")[0],
+			Go:    f.Name,
+		})
+	}
+	return fields
+}
+
+// Terapkan body request sebagai merge patch atau JSON patch ke dokumen (pointer ke struct model).
+// Hanya field di allow-list yang bisa disentuh, hasilnya dicek tipenya saat decode lalu divalidasi
+// dengan tag validator model. Mengembalikan $set berisi field yang benar-benar berubah (nama bson).
+func terapkanPatch(c *fiber.Ctx, dokumen interface{}, diizinkan map[string]bool, v *validator.Validate) (bson.M, error) {
+	mediaType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || (mediaType != mediaMergePatch && mediaType != mediaJSONPatch) {
+		return nil, &errorPatch{
+			Status: http.StatusUnsupportedMediaType,
+			Pesan:  fmt.Sprintf("Content-Type must be %s or %s", mediaMergePatch, mediaJSONPatch),
+		}
+	}
+
+	// Dokumen yang dipatch hanya berisi field yang diizinkan
+	asalJSON, err := json.Marshal(asalPatch(dokumen, diizinkan))
+	if err != nil {
+		return nil, err
+	}
+
+	var hasilJSON []byte
+	if mediaType == mediaMergePatch {
+		if !json.Valid(c.Body()) {
+			return nil, &errorPatch{Status: http.StatusBadRequest, Pesan: "Malformed merge patch document"}
+		}
+		hasilJSON, err = jsonpatch.MergePatch(asalJSON, c.Body())
+		if err != nil {
+			return nil, &errorPatch{Status: http.StatusBadRequest, Pesan: "Malformed merge patch document: " + err.Error()}
+		}
+	} else {
+		patch, err := jsonpatch.DecodePatch(c.Body())
+		if err != nil {
+			return nil, &errorPatch{Status: http.StatusBadRequest, Pesan: "Malformed JSON patch document: " + err.Error()}
+		}
+		hasilJSON, err = patch.Apply(asalJSON)
+		if err != nil {
+			// Operasi test yang gagal atau path yang tidak ada
+			return nil, &errorPatch{Status: http.StatusUnprocessableEntity, Pesan: "JSON patch cannot be applied: " + err.Error()}
+		}
+	}
+	return terapkanHasilPatch(hasilJSON, dokumen, diizinkan, v)
+}
+
+// Terapkan body PUT ke dokumen. Field di luar allow-list diabaikan dan field yang tidak dikirim
+// tidak berubah; pemeriksaan tipe dan validasinya sama dengan terapkanPatch.
+func terapkanUpdate(body []byte, dokumen interface{}, diizinkan map[string]bool, v *validator.Validate) (bson.M, error) {
+	var masuk map[string]json.RawMessage
+	if err := json.Unmarshal(body, &masuk); err != nil {
+		return nil, &errorPatch{Status: http.StatusBadRequest, Pesan: "Invalid request body format. " + err.Error()}
+	}
+	hasil := asalPatch(dokumen, diizinkan)
+	ada := false
+	for nama, isi := range masuk {
+		if diizinkan[nama] {
+			hasil[nama] = isi
+			ada = true
+		}
+	}
+	if !ada {
+		return nil, &errorPatch{Status: http.StatusBadRequest, Pesan: "No valid fields to update"}
+	}
+	hasilJSON, err := json.Marshal(hasil)
+	if err != nil {
+		return nil, err
+	}
+	return terapkanHasilPatch(hasilJSON, dokumen, diizinkan, v)
+}
+
+// Isi field yang diizinkan dari dokumen, menjadi titik awal patch
+func asalPatch(dokumen interface{}, diizinkan map[string]bool) map[string]interface{} {
+	nilai := reflect.ValueOf(dokumen).Elem()
+	asal := map[string]interface{}{}
+	for _, f := range daftarFieldPatch(nilai.Type(), diizinkan) {
+		asal[f.JSON] = nilai.Field(f.Index).Interface()
+	}
+	return asal
+}
+
+// Decode dokumen hasil patch ke dokumen asal lalu validasi, mengembalikan $set field yang berubah
+func terapkanHasilPatch(hasilJSON []byte, dokumen interface{}, diizinkan map[string]bool, v *validator.Validate) (bson.M, error) {
+	nilai := reflect.ValueOf(dokumen).Elem()
+	fields := daftarFieldPatch(nilai.Type(), diizinkan)
+	lama := reflect.New(nilai.Type()).Elem()
+	lama.Set(nilai)
+
+	// Field di luar allow-list ditolak, bukan diabaikan
+	var hasil map[string]json.RawMessage
+	if err := json.Unmarshal(hasilJSON, &hasil); err != nil {
+		return nil, &errorPatch{Status: http.StatusUnprocessableEntity, Pesan: "Patched document must be a JSON object"}
+	}
+	ditolak := []string{}
+	for nama := range hasil {
+		if !diizinkan[nama] {
+			ditolak = append(ditolak, nama)
+		}
+	}
+	if len(ditolak) > 0 {
+		sort.Strings(ditolak)
+		return nil, &errorPatch{Status: http.StatusUnprocessableEntity, Pesan: "Fields cannot be patched: " + strings.Join(ditolak, ", ")}
+	}
+
+	// Field yang dihapus oleh patch menjadi nilai kosong, decode sekaligus memeriksa tipe
+	for _, f := range fields {
+		nilai.Field(f.Index).Set(reflect.Zero(nilai.Field(f.Index).Type()))
+	}
+	if err := json.Unmarshal(hasilJSON, dokumen); err != nil {
+		var errTipe *json.UnmarshalTypeError
+		if errors.As(err, &errTipe) {
+			return nil, &errorPatch{
+				Status: http.StatusUnprocessableEntity,
+				Pesan:  "Invalid field type",
+				Fields: map[string]string{errTipe.Field: "must be of type " + errTipe.Type.String()},
+			}
+		}
+		return nil, &errorPatch{Status: http.StatusUnprocessableEntity, Pesan: err.Error()}
+	}
+
+	// Validasi hanya field yang bisa di-patch, field lain tidak ikut diperiksa
+	namaGo := make([]string, 0, len(fields))
+	for _, f := range fields {
+		namaGo = append(namaGo, f.Go)
+	}
+	if err := v.StructPartial(dokumen, namaGo...); err != nil {
+		errPatch := &errorPatch{Status: http.StatusUnprocessableEntity, Pesan: "Validation failed", Fields: map[string]string{}}
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return nil, err
+		}
+		for _, fe := range validationErrors {
+			for _, f := range fields {
+				if f.Go == fe.StructField() {
+					errPatch.Fields[f.JSON] = fmt.Sprintf("failed on the '%s' validation", fe.Tag())
+				}
+			}
+		}
+		return nil, errPatch
+	}
+
+	set := bson.M{}
+	for _, f := range fields {
+		baru := nilai.Field(f.Index).Interface()
+		if !reflect.DeepEqual(lama.Field(f.Index).Interface(), baru) {
+			set[f.BSON] = baru
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"demoapp/model"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Jalankan terapkanPatch terhadap salinan modul lewat request Fiber sungguhan
+func jalankanPatch(t *testing.T, contentType string, body string, modul model.Modul) (model.Modul, bson.M, error) {
+	t.Helper()
+	var set bson.M
+	var errPatch error
+	app := fiber.New()
+	app.Patch("/", func(c *fiber.Ctx) error {
+		set, errPatch = terapkanPatch(c, &modul, modulPatchFields, validater)
+		return c.SendStatus(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	return modul, set, errPatch
+}
+
+func TestTerapkanPatch(t *testing.T) {
+	asal := model.Modul{NmModul: "Sistem Akademik", KetModul: "SIAKAD", IsAktif: true, Alamat: "https://siakad.example", Urutan: 1}
+
+	tests := []struct {
+		nama        string
+		contentType string
+		body        string
+		wantSet     bson.M
+		wantStatus  int // 0 jika patch berhasil
+		wantField   string
+	}{
+		{
+			nama:        "merge patch mengubah field",
+			contentType: mediaMergePatch,
+			body:        `{"nm_modul":"SIAKAD Baru","urutan":3}`,
+			wantSet:     bson.M{"nm_modul": "SIAKAD Baru", "urutan": 3},
+		},
+		{
+			nama:        "merge patch dengan charset",
+			contentType: mediaMergePatch + "; charset=utf-8",
+			body:        `{"is_aktif":false}`,
+			wantSet:     bson.M{"is_aktif": false},
+		},
+		{
+			nama:        "merge patch null mengosongkan field",
+			contentType: mediaMergePatch,
+			body:        `{"ket_modul":null}`,
+			wantSet:     bson.M{"ket_modul": ""},
+		},
+		{
+			nama:        "merge patch tanpa perubahan",
+			contentType: mediaMergePatch,
+			body:        `{"nm_modul":"Sistem Akademik"}`,
+			wantSet:     bson.M{},
+		},
+		{
+			nama:        "json patch replace",
+			contentType: mediaJSONPatch,
+			body:        `[{"op":"replace","path":"/alamat","value":"https://baru.example"}]`,
+			wantSet:     bson.M{"alamat": "https://baru.example"},
+		},
+		{
+			nama:        "json patch test lalu replace",
+			contentType: mediaJSONPatch,
+			body:        `[{"op":"test","path":"/urutan","value":1},{"op":"replace","path":"/urutan","value":2}]`,
+			wantSet:     bson.M{"urutan": 2},
+		},
+		{
+			nama:        "json patch test gagal",
+			contentType: mediaJSONPatch,
+			body:        `[{"op":"test","path":"/urutan","value":5}]`,
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			nama:        "json patch rusak",
+			contentType: mediaJSONPatch,
+			body:        `{"op":"replace"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			nama:        "merge patch rusak",
+			contentType: mediaMergePatch,
+			body:        `{"nm_modul":`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			nama:        "content type tidak didukung",
+			contentType: fiber.MIMEApplicationJSON,
+			body:        `{"nm_modul":"SIAKAD"}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			nama:       "tanpa content type",
+			body:       `{"nm_modul":"SIAKAD"}`,
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			nama:        "merge patch field di luar allow-list",
+			contentType: mediaMergePatch,
+			body:        `{"version":10}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			nama:        "json patch menambah field di luar allow-list",
+			contentType: mediaJSONPatch,
+			body:        `[{"op":"add","path":"/created_at","value":"2020-01-01T00:00:00Z"}]`,
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			nama:        "tipe field salah",
+			contentType: mediaMergePatch,
+			body:        `{"urutan":"satu"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantField:   "urutan",
+		},
+		{
+			nama:        "boolean berupa string",
+			contentType: mediaJSONPatch,
+			body:        `[{"op":"replace","path":"/is_aktif","value":"true"}]`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantField:   "is_aktif",
+		},
+		{
+			nama:        "validasi required gagal",
+			contentType: mediaJSONPatch,
+			body:        `[{"op":"remove","path":"/nm_modul"}]`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantField:   "nm_modul",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			hasil, set, err := jalankanPatch(t, tt.contentType, tt.body, asal)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(set, tt.wantSet) {
+					t.Errorf("set = %v, want %v", set, tt.wantSet)
+				}
+				return
+			}
+
+			var errPatch *errorPatch
+			if !errors.As(err, &errPatch) {
+				t.Fatalf("error = %v, want *errorPatch", err)
+			}
+			if errPatch.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", errPatch.Status, tt.wantStatus, errPatch.Pesan)
+			}
+			if tt.wantField != "" {
+				if _, ok := errPatch.Fields[tt.wantField]; !ok {
+					t.Errorf("fields = %v, want entry for %q", errPatch.Fields, tt.wantField)
+				}
+			}
+			if hasil.Version != asal.Version || !hasil.CreatedAt.Equal(asal.CreatedAt) {
+				t.Errorf("fields outside the allow-list changed: %+v", hasil)
+			}
+		})
+	}
+}
+
+func TestTerapkanUpdate(t *testing.T) {
+	asal := model.Modul{NmModul: "Sistem Akademik", IsAktif: true, Urutan: 1, Version: 4}
+
+	tests := []struct {
+		nama       string
+		body       string
+		wantSet    bson.M
+		wantStatus int
+	}{
+		{"field yang dikirim saja yang berubah", `{"urutan":2}`, bson.M{"urutan": 2}, 0},
+		{"field di luar allow-list diabaikan", `{"urutan":2,"version":99}`, bson.M{"urutan": 2}, 0},
+		{"hanya field di luar allow-list", `{"version":99}`, nil, http.StatusBadRequest},
+		{"body bukan objek", `[1,2]`, nil, http.StatusBadRequest},
+		{"tipe field salah", `{"is_aktif":"ya"}`, nil, http.StatusUnprocessableEntity},
+		{"validasi gagal", `{"nm_modul":""}`, nil, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			modul := asal
+			set, err := terapkanUpdate([]byte(tt.body), &modul, modulPatchFields, validater)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(set, tt.wantSet) {
+					t.Errorf("set = %v, want %v", set, tt.wantSet)
+				}
+				if modul.Version != asal.Version {
+					t.Errorf("version = %d, want %d", modul.Version, asal.Version)
+				}
+				return
+			}
+			var errPatch *errorPatch
+			if !errors.As(err, &errPatch) || errPatch.Status != tt.wantStatus {
+				t.Errorf("error = %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"demoapp/responses"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Skor minimum (proporsi trigram query yang cocok) agar user masuk hasil pencarian
+	skorMinimumPencarian = 0.4
+	// Jumlah dokumen per batch saat reindex
+	batchReindex = 500
+)
+
+// Ejaan lama dan variasi penulisan nama Indonesia yang disamakan sebelum dibuat trigram.
+// Urutan penting: dj dan tj diproses sebelum j diubah menjadi y.
+var ejaanNama = strings.NewReplacer(
+	"dj", "j",
+	"tj", "c",
+	"oe", "u",
+	"ch", "kh",
+	"ph", "f",
+	"th", "t",
+	"dh", "d",
+)
+
+// Huruf kecil, karakter selain huruf dan angka menjadi spasi
+func normalisasiTeks(teks string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(teks), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Bentuk fonetik nama: ejaan lama disamakan, j dianggap y, huruf ganda dipadatkan.
+// Sukarno/Soekarno, Cahyo/Tjahjo, Muhamad/Muhammad menghasilkan bentuk yang sama.
+func fonetikNama(teks string) string {
+	teks = ejaanNama.Replace(normalisasiTeks(teks))
+	teks = strings.ReplaceAll(teks, "j", "y")
+
+	var hasil strings.Builder
+	var sebelumnya rune
+	for _, r := range teks {
+		if r != sebelumnya || r == ' ' {
+			hasil.WriteRune(r)
+		}
+		sebelumnya = r
+	}
+	return hasil.String()
+}
+
+// Nomor telepon hanya angka, awalan 62 diganti 0
+func normalisasiTelepon(teks string) string {
+	angka := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, teks)
+	if strings.HasPrefix(angka, "62") {
+		angka = "0" + angka[2:]
+	}
+	return angka
+}
+
+// Trigram per kata, diberi spasi di awal dan akhir agar kata pendek dan awalan kata tetap punya trigram
+func trigram(teks string) []string {
+	sudah := map[string]bool{}
+	hasil := []string{}
+	for _, kata := range strings.Fields(teks) {
+		runes := []rune(" " + kata + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			gram := string(runes[i : i+3])
+			if !sudah[gram] {
+				sudah[gram] = true
+				hasil = append(hasil, gram)
+			}
+		}
+	}
+	return hasil
+}
+
+// Gabungan beberapa daftar trigram tanpa duplikat
+func gabungTrigram(daftar ...[]string) []string {
+	sudah := map[string]bool{}
+	hasil := []string{}
+	for _, grams := range daftar {
+		for _, gram := range grams {
+			if !sudah[gram] {
+				sudah[gram] = true
+				hasil = append(hasil, gram)
+			}
+		}
+	}
+	return hasil
+}
+
+// Trigram yang disimpan di field search_ngram milik user
+func ngramUser(user model.User) []string {
+	return gabungTrigram(
+		trigram(normalisasiTeks(user.Username)),
+		trigram(fonetikNama(user.NmUser)),
+		trigram(normalisasiTeks(user.Email)),
+		trigram(normalisasiTelepon(user.Phone)),
+	)
+}
+
+// Simpan ulang search_ngram setelah user berubah
+func perbaruiNgramUser(ctx context.Context, user model.User) error {
+	_, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"search_ngram": ngramUser(user)}})
+	return err
+}
+
+// Varian query yang dicocokkan: teks biasa, bentuk fonetik, dan nomor telepon.
+// Skor user adalah skor terbaik dari semua varian.
+func varianQuery(q string) [][]string {
+	varian := [][]string{trigram(normalisasiTeks(q))}
+	if fonetik := trigram(fonetikNama(q)); len(fonetik) > 0 {
+		varian = append(varian, fonetik)
+	}
+	if telepon := normalisasiTelepon(q); len(telepon) >= 3 {
+		varian = append(varian, trigram(telepon))
+	}
+	return varian
+}
+
+// Tandai kata nama yang bentuk fonetiknya cocok dengan salah satu kata query, hasil sudah di-escape HTML
+func sorotNama(teks string, kataQuery []string) string {
+	cocok := false
+	kata := strings.Fields(teks)
+	for i, k := range kata {
+		bentuk := fonetikNama(k)
+		kata[i] = html.EscapeString(k)
+		for _, q := range kataQuery {
+			if strings.Contains(bentuk, q) || miripTrigram(bentuk, q) {
+				kata[i] = "<em>" + kata[i] + "</em>"
+				cocok = true
+				break
+			}
+		}
+	}
+	if !cocok {
+		return ""
+	}
+	return strings.Join(kata, " ")
+}
+
+// Tandai bagian teks yang memuat kata query apa adanya (tanpa membedakan huruf besar/kecil)
+func sorotSubstring(teks string, kataQuery []string) string {
+	kecil := strings.ToLower(teks)
+	if len(kecil) != len(teks) {
+		return ""
+	}
+	tanda := make([]bool, len(teks))
+	cocok := false
+	for _, q := range kataQuery {
+		for mulai := 0; ; {
+			i := strings.Index(kecil[mulai:], q)
+			if i < 0 {
+				break
+			}
+			for j := mulai + i; j < mulai+i+len(q); j++ {
+				tanda[j] = true
+			}
+			cocok = true
+			mulai += i + len(q)
+		}
+	}
+	if !cocok {
+		return ""
+	}
+
+	var hasil strings.Builder
+	for i := 0; i < len(teks); {
+		j := i
+		for j < len(teks) && tanda[j] == tanda[i] {
+			j++
+		}
+		if tanda[i] {
+			hasil.WriteString("<em>" + html.EscapeString(teks[i:j]) + "</em>")
+		} else {
+			hasil.WriteString(html.EscapeString(teks[i:j]))
+		}
+		i = j
+	}
+	return hasil.String()
+}
+
+// Dua kata dianggap mirip jika proporsi trigram kata query yang ada di kata lain memenuhi skor minimum
+func miripTrigram(kata string, q string) bool {
+	gramQuery := trigram(q)
+	if len(gramQuery) == 0 {
+		return false
+	}
+	gramKata := map[string]bool{}
+	for _, gram := range trigram(kata) {
+		gramKata[gram] = true
+	}
+	sama := 0
+	for _, gram := range gramQuery {
+		if gramKata[gram] {
+			sama++
+		}
+	}
+	return float64(sama)/float64(len(gramQuery)) >= skorMinimumPencarian
+}
+
+// Sorotan per field untuk satu user
+func sorotanUser(user model.User, q string) fiber.Map {
+	sorotan := fiber.Map{}
+	biasa := strings.Fields(normalisasiTeks(q))
+	fonetik := strings.Fields(fonetikNama(q))
+
+	if s := sorotNama(user.NmUser, fonetik); s != "" {
+		sorotan["nm_user"] = s
+	}
+	if s := sorotSubstring(user.Username, biasa); s != "" {
+		sorotan["username"] = s
+	}
+	if s := sorotSubstring(user.Email, biasa); s != "" {
+		sorotan["email"] = s
+	}
+	if telepon := normalisasiTelepon(q); len(telepon) >= 3 && strings.Contains(normalisasiTelepon(user.Phone), telepon) {
+		sorotan["phone"] = "<em>" + html.EscapeString(user.Phone) + "</em>"
+	}
+	return sorotan
+}
+
+// SearchUsers - Cari user berdasarkan nama, NIM/username, email atau telepon.
+// Hasil diurutkan berdasarkan skor kemiripan trigram dan toleran terhadap salah ketik serta ejaan lama.
+func SearchUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	q := strings.TrimSpace(c.Query("q"))
+	if len([]rune(normalisasiTeks(q))) < 2 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Query q must be at least 2 characters"})
+	}
+
+	limit := limitHalamanDefault
+	if nilai := c.Query("limit"); nilai != "" {
+		n, err := strconv.Atoi(nilai)
+		if err != nil || n < 1 {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "limit must be a positive number"})
+		}
+		if n > limitHalamanMaks {
+			n = limitHalamanMaks
+		}
+		limit = n
+	}
+
+	// Filter yang sama dengan daftar user tetap berlaku saat mencari
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	varian := varianQuery(q)
+	skor := bson.A{}
+	for _, grams := range varian {
+		skor = append(skor, bson.M{"$divide": bson.A{
+			bson.M{"$size": bson.M{"$setIntersection": bson.A{bson.M{"$ifNull": bson.A{"$search_ngram", bson.A{}}}, grams}}},
+			len(grams),
+		}})
+	}
+	filter["search_ngram"] = bson.M{"$in": gabungTrigram(varian...)}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{"_skor": bson.M{"$max": skor}}}},
+		{{Key: "$match", Value: bson.M{"_skor": bson.M{"$gte": skorMinimumPencarian}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_skor", Value: -1}, {Key: "nm_user", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := userCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		fmt.Println("Error searching users:", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to search users"})
+	}
+	var hasilCari []struct {
+		model.User `bson:",inline"`
+		Skor       float64 `bson:"_skor"`
+	}
+	if err := cursor.All(ctx, &hasilCari); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode search results"})
+	}
+
+	results := make([]fiber.Map, 0, len(hasilCari))
+	for _, hasil := range hasilCari {
+		user := hasil.User
+		results = append(results, fiber.Map{
+			"user":      responses.NewUserDTO(user),
+			"score":     hasil.Skor,
+			"highlight": sorotanUser(user, q),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"query":       q,
+		"results":     results,
+		"total_count": len(results),
+	})
+}
+
+// Hitung ulang search_ngram untuk user yang cocok dengan filter, ditulis per batch
+func reindexPencarianUser(ctx context.Context, filter bson.M) (int, error) {
+	cursor, err := userCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{
+		"username": 1, "nm_user": 1, "email": 1, "phone": 1,
+	}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	total := 0
+	batch := []mongo.WriteModel{}
+	simpan := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := userCollection.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+		batch = batch[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var user model.User
+		if err := cursor.Decode(&user); err != nil {
+			return total, err
+		}
+		batch = append(batch, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": user.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"search_ngram": ngramUser(user)}}))
+		total++
+		if len(batch) >= batchReindex {
+			if err := simpan(); err != nil {
+				return total, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return total, err
+	}
+	return total, simpan()
+}
+
+// ReindexUserSearch - Bangun ulang search_ngram semua user, dipakai untuk data lama atau setelah aturan normalisasi berubah
+func ReindexUserSearch(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	total, err := reindexPencarianUser(ctx, bson.M{})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reindex users: " + err.Error()})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User search index rebuilt", "total_count": total})
+}
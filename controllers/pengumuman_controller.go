@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var PengumumanCollection = config.GetCollection(config.DB, "pengumuman")
+var PengumumanStatusCollection = config.GetCollection(config.DB, "pengumuman_status")
+
+// Urutan tampil berdasarkan severity, yang paling penting lebih dulu
+var bobotSeverity = map[string]int{
+	"critical": 0,
+	"warning":  1,
+	"info":     2,
+}
+
+// Validasi isi pengumuman dan normalisasi target kosong menjadi array kosong
+func validasiPengumuman(ctx context.Context, pengumuman *model.Pengumuman) error {
+	if err := validate.Struct(pengumuman); err != nil {
+		return err
+	}
+	if pengumuman.AkhirTayang != nil && !pengumuman.AkhirTayang.After(pengumuman.MulaiTayang) {
+		return errors.New("akhir_tayang must be after mulai_tayang")
+	}
+
+	if pengumuman.TargetJenisUser == nil {
+		pengumuman.TargetJenisUser = []string{}
+	}
+	if pengumuman.TargetGrup == nil {
+		pengumuman.TargetGrup = []primitive.ObjectID{}
+	}
+	if pengumuman.TargetModul == nil {
+		pengumuman.TargetModul = []primitive.ObjectID{}
+	}
+
+	for _, kode := range pengumuman.TargetJenisUser {
+		if _, err := cariJenisUser(ctx, kode); err != nil {
+			return err
+		}
+	}
+	if len(pengumuman.TargetGrup) > 0 {
+		count, err := GrupCollection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": pengumuman.TargetGrup}})
+		if err != nil {
+			return err
+		}
+		if int(count) != len(pengumuman.TargetGrup) {
+			return errors.New("one or more target_grup do not exist")
+		}
+	}
+	return cekModulAda(ctx, pengumuman.TargetModul)
+}
+
+// Filter pengumuman yang sedang tayang dan ditujukan ke user
+func filterPengumumanUser(ctx context.Context, user model.User) (bson.M, error) {
+	now := time.Now()
+
+	// Pengumuman untuk jenis_user induk ikut tampil di jenis_user turunannya
+	jenisUsers := []string{user.JenisUser}
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if leluhur, err := leluhurJenisUser(katalog, user.JenisUser); err == nil {
+		jenisUsers = append(jenisUsers, leluhur...)
+	}
+
+	grups, err := grupUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	grupIDs := []primitive.ObjectID{}
+	for _, grup := range grups {
+		grupIDs = append(grupIDs, grup.ID)
+	}
+
+	// Modul yang sedang tidak aktif tetap dicocokkan agar pengumumannya sampai ke pemegang akses
+	moduls, err := modulDiberikan(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	modulIDs := []primitive.ObjectID{}
+	for _, modul := range moduls {
+		modulIDs = append(modulIDs, modul.ID)
+	}
+
+	return bson.M{
+		"mulai_tayang": bson.M{"$lte": now},
+		"$and": []bson.M{
+			{"$or": []bson.M{
+				{"akhir_tayang": nil},
+				{"akhir_tayang": bson.M{"$gt": now}},
+			}},
+			{"$or": []bson.M{
+				{"target_jenis_user": bson.M{"$size": 0}, "target_grup": bson.M{"$size": 0}, "target_modul": bson.M{"$size": 0}},
+				{"target_jenis_user": bson.M{"$in": jenisUsers}},
+				{"target_grup": bson.M{"$in": grupIDs}},
+				{"target_modul": bson.M{"$in": modulIDs}},
+			}},
+		},
+	}, nil
+}
+
+// ------------------------------
+// Admin
+// ------------------------------
+
+// Create Pengumuman
+func CreatePengumuman(c *fiber.Ctx) error {
+	pengumuman := new(model.Pengumuman)
+	if err := c.BodyParser(pengumuman); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validasiPengumuman(c.Context(), pengumuman); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	pengumuman.ID = primitive.NewObjectID()
+	pengumuman.CreatedBy = aktorDari(c)
+	pengumuman.CreatedAt = time.Now()
+	pengumuman.UpdatedAt = time.Now()
+
+	result, err := PengumumanCollection.InsertOne(c.Context(), pengumuman)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create pengumuman"})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"message": "Pengumuman created successfully",
+		"id":      result.InsertedID,
+	})
+}
+
+// Get All Pengumuman, terbaru lebih dulu
+func GetAllPengumuman(c *fiber.Ctx) error {
+	cursor, err := PengumumanCollection.Find(c.Context(), bson.M{}, options.Find().SetSort(bson.M{"mulai_tayang": -1}))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch pengumuman"})
+	}
+
+	pengumumans := []model.Pengumuman{}
+	if err := cursor.All(c.Context(), &pengumumans); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse pengumuman"})
+	}
+
+	return c.JSON(pengumumans)
+}
+
+// Get Pengumuman by ID beserta jumlah user yang sudah membaca dan menutupnya
+func GetPengumumanByID(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("pengumumanId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var pengumuman model.Pengumuman
+	err = PengumumanCollection.FindOne(c.Context(), bson.M{"_id": objectID}).Decode(&pengumuman)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Pengumuman not found"})
+	}
+
+	dibaca, err := PengumumanStatusCollection.CountDocuments(c.Context(), bson.M{"pengumuman_id": objectID, "dibaca_pada": bson.M{"$ne": nil}})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count pengumuman status"})
+	}
+	ditutup, err := PengumumanStatusCollection.CountDocuments(c.Context(), bson.M{"pengumuman_id": objectID, "ditutup_pada": bson.M{"$ne": nil}})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count pengumuman status"})
+	}
+
+	return c.JSON(fiber.Map{
+		"pengumuman":    pengumuman,
+		"read_count":    dibaca,
+		"dismiss_count": ditutup,
+	})
+}
+
+// Update Pengumuman
+func UpdatePengumuman(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("pengumumanId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	pengumuman := new(model.Pengumuman)
+	if err := c.BodyParser(pengumuman); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validasiPengumuman(c.Context(), pengumuman); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"judul":             pengumuman.Judul,
+			"isi":               pengumuman.Isi,
+			"severity":          pengumuman.Severity,
+			"mulai_tayang":      pengumuman.MulaiTayang,
+			"akhir_tayang":      pengumuman.AkhirTayang,
+			"target_jenis_user": pengumuman.TargetJenisUser,
+			"target_grup":       pengumuman.TargetGrup,
+			"target_modul":      pengumuman.TargetModul,
+			"updated_at":        time.Now(),
+		},
+	}
+
+	result, err := PengumumanCollection.UpdateOne(c.Context(), bson.M{"_id": objectID}, update)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update pengumuman"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Pengumuman not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Pengumuman updated successfully"})
+}
+
+// Delete Pengumuman beserta status baca milik user
+func DeletePengumuman(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("pengumumanId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	result, err := PengumumanCollection.DeleteOne(c.Context(), bson.M{"_id": objectID})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete pengumuman"})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Pengumuman not found"})
+	}
+
+	if _, err := PengumumanStatusCollection.DeleteMany(c.Context(), bson.M{"pengumuman_id": objectID}); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete pengumuman status"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Pengumuman deleted successfully"})
+}
+
+// ------------------------------
+// User (/me)
+// ------------------------------
+
+// GetMyPengumuman - Pengumuman yang sedang tayang untuk user.
+// Pengumuman yang sudah ditutup disembunyikan kecuali dengan ?include_dismissed=true
+func GetMyPengumuman(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	filter, err := filterPengumumanUser(ctx, user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resolve pengumuman target"})
+	}
+
+	cursor, err := PengumumanCollection.Find(ctx, filter)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch pengumuman"})
+	}
+	var pengumumans []model.Pengumuman
+	if err := cursor.All(ctx, &pengumumans); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse pengumuman"})
+	}
+
+	// Status baca dan tutup milik user
+	statusCursor, err := PengumumanStatusCollection.Find(ctx, bson.M{"user_id": user.ID})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch pengumuman status"})
+	}
+	var statuses []model.PengumumanStatus
+	if err := statusCursor.All(ctx, &statuses); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse pengumuman status"})
+	}
+	statusPerID := map[primitive.ObjectID]model.PengumumanStatus{}
+	for _, status := range statuses {
+		statusPerID[status.PengumumanID] = status
+	}
+
+	sort.SliceStable(pengumumans, func(i, j int) bool {
+		if bobotSeverity[pengumumans[i].Severity] != bobotSeverity[pengumumans[j].Severity] {
+			return bobotSeverity[pengumumans[i].Severity] < bobotSeverity[pengumumans[j].Severity]
+		}
+		return pengumumans[i].MulaiTayang.After(pengumumans[j].MulaiTayang)
+	})
+
+	includeDismissed := c.QueryBool("include_dismissed")
+	hasil := []fiber.Map{}
+	belumDibaca := 0
+	for _, pengumuman := range pengumumans {
+		status := statusPerID[pengumuman.ID]
+		if status.DitutupPada != nil && !includeDismissed {
+			continue
+		}
+		if status.DibacaPada == nil {
+			belumDibaca++
+		}
+		hasil = append(hasil, fiber.Map{
+			"pengumuman":   pengumuman,
+			"dibaca_pada":  status.DibacaPada,
+			"ditutup_pada": status.DitutupPada,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"pengumuman":   hasil,
+		"unread_count": belumDibaca,
+		"total_count":  len(hasil),
+	})
+}
+
+// Tandai status pengumuman milik user, field berisi "dibaca_pada" atau "ditutup_pada"
+func tandaiPengumuman(c *fiber.Ctx, field string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pengumumanID, err := primitive.ObjectIDFromHex(c.Params("pengumumanId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	// Hanya pengumuman yang memang tampil untuk user
+	filter, err := filterPengumumanUser(ctx, user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resolve pengumuman target"})
+	}
+	filter["_id"] = pengumumanID
+	err = PengumumanCollection.FindOne(ctx, filter).Err()
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Pengumuman not found"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch pengumuman"})
+	}
+
+	// Menutup pengumuman sekaligus menandainya sudah dibaca
+	now := time.Now()
+	set := bson.M{field: now}
+	setOnInsert := bson.M{}
+	if field == "ditutup_pada" {
+		setOnInsert["dibaca_pada"] = now
+	}
+	update := bson.M{"$set": set}
+	if len(setOnInsert) > 0 {
+		update["$setOnInsert"] = setOnInsert
+	}
+
+	_, err = PengumumanStatusCollection.UpdateOne(
+		ctx,
+		bson.M{"pengumuman_id": pengumumanID, "user_id": user.ID},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update pengumuman status"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Pengumuman status updated successfully"})
+}
+
+// ReadMyPengumuman - Tandai pengumuman sudah dibaca
+func ReadMyPengumuman(c *fiber.Ctx) error {
+	return tandaiPengumuman(c, "dibaca_pada")
+}
+
+// DismissMyPengumuman - Tutup pengumuman agar tidak tampil lagi
+func DismissMyPengumuman(c *fiber.Ctx) error {
+	return tandaiPengumuman(c, "ditutup_pada")
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var PreferensiCollection = config.GetCollection(config.DB, "preferensi")
+
+// Nilai bawaan untuk user yang belum pernah menyimpan preferensi
+func preferensiDefault(userID primitive.ObjectID) model.Preferensi {
+	return model.Preferensi{
+		UserID:    userID,
+		Bahasa:    "id",
+		Tema:      "system",
+		ZonaWaktu: "Asia/Jakarta",
+		Notifikasi: model.PreferensiNotifikasi{
+			Email: true,
+			Push:  true,
+		},
+	}
+}
+
+// Ambil preferensi user, nilai bawaan jika belum pernah disimpan
+func preferensiUser(ctx context.Context, userID primitive.ObjectID) (model.Preferensi, error) {
+	preferensi := preferensiDefault(userID)
+	err := PreferensiCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&preferensi)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return preferensi, err
+	}
+	return preferensi, nil
+}
+
+// GetMyPreferences - Preferensi user yang sedang login
+func GetMyPreferences(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	preferensi, err := preferensiUser(ctx, user.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch preferences"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"preferences": preferensi})
+}
+
+// UpdateMyPreferences - Ubah sebagian atau seluruh preferensi.
+// Field yang tidak dikirim tetap memakai nilai sebelumnya, field yang tidak dikenal ditolak.
+func UpdateMyPreferences(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	preferensi, err := preferensiUser(ctx, user.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch preferences"})
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(c.Body()))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&preferensi); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body: " + err.Error()})
+	}
+	preferensi.UserID = user.ID
+	preferensi.UpdatedAt = time.Now()
+
+	if err := validate.Struct(&preferensi); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	_, err = PreferensiCollection.ReplaceOne(
+		ctx,
+		bson.M{"user_id": user.ID},
+		preferensi,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save preferences"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Preferences updated successfully", "preferences": preferensi})
+}
+
+// ResetMyPreferences - Kembalikan preferensi ke nilai bawaan
+func ResetMyPreferences(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := userLogin(ctx, c)
+	if err != nil {
+		return userLoginError(c, err)
+	}
+
+	if _, err := PreferensiCollection.DeleteOne(ctx, bson.M{"user_id": user.ID}); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reset preferences"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Preferences reset successfully", "preferences": preferensiDefault(user.ID)})
+}
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var PurgeLogCollection = config.GetCollection(config.DB, "purge_log")
+
+const (
+	// Masa retensi bawaan user yang sudah dihapus, dalam hari
+	retensiUserDefault = 30
+	// Jarak bawaan antar purge terjadwal
+	intervalPurgeDefault = 24 * time.Hour
+	// Folder foto user, foto di luar folder ini tidak ikut dihapus
+	direktoriFotoUser = "./storage/images"
+)
+
+// Mencegah purge terjadwal dan purge manual berjalan bersamaan
+var purgeBerjalan sync.Mutex
+
+// Masa retensi dari env USER_RETENTION_DAYS
+func retensiUser() time.Duration {
+	hari := retensiUserDefault
+	if nilai := os.Getenv("USER_RETENTION_DAYS"); nilai != "" {
+		if n, err := strconv.Atoi(nilai); err == nil && n >= 0 {
+			hari = n
+		} else {
+			fmt.Println("Invalid USER_RETENTION_DAYS, using default:", retensiUserDefault)
+		}
+	}
+	return time.Duration(hari) * 24 * time.Hour
+}
+
+// Interval purge dari env USER_PURGE_INTERVAL, misalnya "6h"
+func intervalPurge() time.Duration {
+	if nilai := os.Getenv("USER_PURGE_INTERVAL"); nilai != "" {
+		if d, err := time.ParseDuration(nilai); err == nil && d > 0 {
+			return d
+		}
+		fmt.Println("Invalid USER_PURGE_INTERVAL, using default:", intervalPurgeDefault)
+	}
+	return intervalPurgeDefault
+}
+
+// Hapus foto user dari storage, hanya jika berada di folder foto user
+func hapusFotoUser(photo string) (bool, error) {
+	if photo == "" {
+		return false, nil
+	}
+	folder, err := filepath.Abs(direktoriFotoUser)
+	if err != nil {
+		return false, err
+	}
+	path, err := filepath.Abs(photo)
+	if err != nil {
+		return false, err
+	}
+	if rel, err := filepath.Rel(folder, path); err != nil || strings.HasPrefix(rel, "..") {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// Hapus permanen satu user lalu bersihkan semua data yang merujuk ke user tersebut.
+// Dokumen user dihapus lebih dulu dengan syarat masih berstatus terhapus, sehingga user
+// yang baru saja di-restore tidak ikut terhapus.
+func purgeSatuUser(ctx context.Context, user model.User) (model.PurgeLog, bool) {
+	log := model.PurgeLog{
+		UserID:    user.ID,
+		Username:  user.Username,
+		DeletedAt: user.DeletedAt.Time(),
+		DeletedBy: user.DeletedBy,
+		PurgedAt:  time.Now(),
+	}
+	catat := func(bagian string, err error) {
+		log.Errors = append(log.Errors, bagian+": "+err.Error())
+	}
+
+	result, err := userCollection.DeleteOne(ctx, bson.M{"_id": user.ID, "deleted_at": bson.M{"$ne": nil}})
+	if err != nil {
+		fmt.Println("Error purging user", user.ID.Hex(), ":", err)
+		return log, false
+	}
+	if result.DeletedCount == 0 {
+		return log, false
+	}
+
+	// Histori user ikut dihapus agar data pribadi tidak tersisa, hanya penanda purge yang disimpan
+	if _, err := HistoriCollection.DeleteMany(ctx, bson.M{"koleksi": userCollection.Name(), "dokumen_id": user.ID}); err != nil {
+		catat("histori", err)
+	}
+	if _, err := HistoriCollection.InsertOne(ctx, model.Histori{
+		Koleksi:   userCollection.Name(),
+		DokumenID: user.ID,
+		Version:   user.Version,
+		Aksi:      AksiPurge,
+		Diff:      []model.HistoriDiff{},
+		Dihapus:   true,
+		Actor:     aktorSistem,
+		CreatedAt: log.PurgedAt,
+	}); err != nil {
+		catat("histori", err)
+	}
+
+	// Keluarkan user dari semua dokumen usermodul, dokumen khusus yang menjadi kosong ikut dihapus
+	usermodulIDs, err := UserModulCollection.Distinct(ctx, "_id", bson.M{"user_id": user.ID})
+	if err != nil {
+		catat("usermodul", err)
+	} else if len(usermodulIDs) > 0 {
+		if res, err := UserModulCollection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": usermodulIDs}}, bson.M{"$pull": bson.M{"user_id": user.ID}, "$inc": naikkanVersi}); err != nil {
+			catat("usermodul", err)
+		} else {
+			log.UserModulDiubah = res.ModifiedCount
+		}
+		if res, err := UserModulCollection.DeleteMany(ctx, bson.M{
+			"_id":     bson.M{"$in": usermodulIDs},
+			"user_id": bson.M{"$size": 0},
+			"catatan": bson.M{"$ne": CatatanBundle},
+		}); err != nil {
+			catat("usermodul", err)
+		} else {
+			log.UserModulDihapus = res.DeletedCount
+		}
+		ids := make([]primitive.ObjectID, 0, len(usermodulIDs))
+		for _, raw := range usermodulIDs {
+			if id, ok := raw.(primitive.ObjectID); ok {
+				ids = append(ids, id)
+			}
+		}
+		catatHistori(ctx, UserModulCollection, AksiPurge, aktorSistem, ids...)
+	}
+
+	if res, err := GrupCollection.UpdateMany(ctx, bson.M{"user_id": user.ID}, bson.M{"$pull": bson.M{"user_id": user.ID}}); err != nil {
+		catat("grup", err)
+	} else {
+		log.GrupDiubah = res.ModifiedCount
+	}
+	if res, err := UserModulDenyCollection.DeleteMany(ctx, bson.M{"user_id": user.ID}); err != nil {
+		catat("usermodul_deny", err)
+	} else {
+		log.DenyDihapus = res.DeletedCount
+	}
+	if res, err := PengumumanStatusCollection.DeleteMany(ctx, bson.M{"user_id": user.ID}); err != nil {
+		catat("pengumuman_status", err)
+	} else {
+		log.PengumumanDihapus = res.DeletedCount
+	}
+	if res, err := UserStatusLogCollection.DeleteMany(ctx, bson.M{"user_id": user.ID}); err != nil {
+		catat("user_status_log", err)
+	} else {
+		log.StatusLogDihapus = res.DeletedCount
+	}
+	if _, err := UserModulPrefCollection.DeleteOne(ctx, bson.M{"user_id": user.ID}); err != nil {
+		catat("usermodul_pref", err)
+	}
+	if _, err := PreferensiCollection.DeleteOne(ctx, bson.M{"user_id": user.ID}); err != nil {
+		catat("preferensi", err)
+	}
+
+	if dihapus, err := hapusFotoUser(user.Photo); err != nil {
+		catat("photo", err)
+	} else if dihapus {
+		log.Foto = user.Photo
+	}
+
+	if _, err := PurgeLogCollection.InsertOne(ctx, log); err != nil {
+		fmt.Println("Error writing purge log for user", user.ID.Hex(), ":", err)
+	}
+	return log, true
+}
+
+// Hapus permanen semua user yang masa retensinya sudah habis
+func purgeUser(ctx context.Context) ([]model.PurgeLog, error) {
+	if !purgeBerjalan.TryLock() {
+		return nil, fmt.Errorf("purge is already running")
+	}
+	defer purgeBerjalan.Unlock()
+
+	// Akun hasil merge tetap diarsipkan agar jejak merged_into tidak hilang
+	batas := primitive.NewDateTimeFromTime(time.Now().Add(-retensiUser()))
+	cursor, err := userCollection.Find(ctx, bson.M{
+		"deleted_at":  bson.M{"$ne": nil, "$lte": batas},
+		"merged_into": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	logs := []model.PurgeLog{}
+	for cursor.Next(ctx) {
+		var user model.User
+		if err := cursor.Decode(&user); err != nil {
+			return logs, err
+		}
+		if log, ok := purgeSatuUser(ctx, user); ok {
+			logs = append(logs, log)
+		}
+	}
+	return logs, cursor.Err()
+}
+
+// MulaiPurgeUser menjalankan purge terjadwal di background. Dipanggil sekali saat aplikasi mulai.
+func MulaiPurgeUser() {
+	go func() {
+		interval := intervalPurge()
+		fmt.Println("User purge scheduled every", interval, "with retention", retensiUser())
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+			logs, err := purgeUser(ctx)
+			cancel()
+			if err != nil {
+				fmt.Println("Error purging deleted users:", err)
+			} else if len(logs) > 0 {
+				fmt.Println("Purged", len(logs), "deleted users")
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// PurgeUsers - Jalankan purge sekarang tanpa menunggu jadwal
+func PurgeUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	logs, err := purgeUser(ctx)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to purge users: " + err.Error(), "purged": logs})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":     "Purge completed",
+		"retention":   retensiUser().String(),
+		"purged":      logs,
+		"total_count": len(logs),
+	})
+}
+
+// GetPurgeLog - Riwayat purge user, terbaru lebih dulu
+func GetPurgeLog(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if nilai := c.Query("user_id"); nilai != "" {
+		userID, err := primitive.ObjectIDFromHex(nilai)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+		}
+		filter["user_id"] = userID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "purged_at", Value: -1}}).SetLimit(100)
+	cursor, err := PurgeLogCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch purge log"})
+	}
+	logs := []model.PurgeLog{}
+	if err := cursor.All(ctx, &logs); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode purge log"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"purge_log": logs, "total_count": len(logs)})
+}
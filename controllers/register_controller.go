@@ -41,6 +41,12 @@ func RegisterHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Email already exists"})
 	}
 
+	// Jenis user default didaftarkan ke katalog jika admin belum membuatnya
+	jenisUser, err := pastikanJenisUser(context.TODO(), DefaultJenisUser)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Default jenis_user is not available: " + err.Error()})
+	}
+
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -58,13 +64,25 @@ func RegisterHandler(c *fiber.Ctx) error {
 		CreatedAt:    primitive.NewDateTimeFromTime(time.Now()),
 		JenisKelamin: req.JenisKelamin,
 		Phone:        req.Phone,
-		JenisUser:    "pelanggan",
+		JenisUser:    jenisUser.Kode,
+		Status:       model.StatusActive,
 	}
+	newUser.SearchNgram = ngramUser(newUser)
+	newUser.Version = 1
 
 	_, err = userCollection.InsertOne(context.TODO(), newUser)
+	if field := fieldDuplikatUser(err); field != "" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": pesanDuplikatUser(field)})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to register user"})
 	}
+	catatHistori(context.TODO(), userCollection, AksiCreate, newUser.Username, newUser.ID)
+
+	// Masukkan user ke bundle modul jenis_user default
+	if err := masukkanKeBundle(context.TODO(), newUser.ID, jenisUser, newUser.Username); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add user to jenis_user bundle"})
+	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "User registered successfully"})
 }
\ No newline at end of file
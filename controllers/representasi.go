@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"demoapp/responses"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Relasi user yang bisa disertakan lewat ?include=
+const includeModuls = "moduls"
+
+// Opsi representasi user dari query ?fields= dan ?include=
+type opsiUser struct {
+	Fields responses.Fieldset
+	Moduls bool // Sertakan modul efektif user
+}
+
+// Baca ?fields= dan ?include= untuk response user
+func parseOpsiUser(c *fiber.Ctx) (opsiUser, error) {
+	var opsi opsiUser
+	fields, err := responses.ParseFieldset(c.Query("fields"), responses.UserDTO{})
+	if err != nil {
+		return opsi, err
+	}
+	opsi.Fields = fields
+
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		switch include = strings.TrimSpace(include); include {
+		case "":
+		case includeModuls:
+			opsi.Moduls = true
+		default:
+			return opsi, fmt.Errorf("unknown include: %s", include)
+		}
+	}
+
+	// Relasi yang diminta tetap dikirim walaupun tidak disebut di ?fields=
+	if opsi.Moduls {
+		opsi.Fields = opsi.Fields.Tambah(includeModuls)
+	}
+	return opsi, nil
+}
+
+// Representasi publik satu user sesuai opsi
+func (opsi opsiUser) representasi(ctx context.Context, user model.User) (interface{}, error) {
+	dto := responses.NewUserDTO(user)
+	if opsi.Moduls {
+		moduls, err := modulEfektif(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		dtoModuls := responses.NewModulDTOs(moduls)
+		dto.Moduls = &dtoModuls
+	}
+	return opsi.Fields.Terapkan(dto)
+}
+
+// Baca ?fields= untuk response modul
+func parseOpsiModul(c *fiber.Ctx) (responses.Fieldset, error) {
+	return responses.ParseFieldset(c.Query("fields"), responses.ModulDTO{})
+}
+
+// Representasi publik daftar modul sesuai fieldset
+func representasiModul(fields responses.Fieldset, moduls []model.Modul) ([]interface{}, error) {
+	hasil := make([]interface{}, 0, len(moduls))
+	for _, modul := range moduls {
+		data, err := fields.Terapkan(responses.NewModulDTO(modul))
+		if err != nil {
+			return nil, err
+		}
+		hasil = append(hasil, data)
+	}
+	return hasil, nil
+}
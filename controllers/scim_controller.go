@@ -0,0 +1,723 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/responses"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Media type response SCIM
+const mediaScim = "application/scim+json"
+
+// Batas query dan bulk yang diumumkan di ServiceProviderConfig
+const (
+	countScimDefault   = 100
+	countScimMaks      = 200
+	bulkScimMaksOp     = 1000
+	bulkScimMaksUkuran = 1 << 20
+)
+
+// Kesalahan SCIM beserta status HTTP dan scimType (RFC 7644 bagian 3.12)
+type errorScim struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *errorScim) Error() string {
+	return e.Detail
+}
+
+func errorNotFoundScim(resource string, id string) *errorScim {
+	return &errorScim{Status: http.StatusNotFound, Detail: fmt.Sprintf("%s %s not found", resource, id)}
+}
+
+func errorVersiScim() *errorScim {
+	return &errorScim{Status: http.StatusPreconditionFailed, Detail: "Resource has been modified, fetch the latest version and retry"}
+}
+
+// Ubah error dari fungsi lain menjadi errorScim. Error yang tidak dikenal menjadi 500.
+func keErrorScim(err error) *errorScim {
+	var errScim *errorScim
+	switch {
+	case errors.As(err, &errScim):
+		return errScim
+	case errors.Is(err, errJenisUserTidakDikenal), errors.Is(err, errSiklusJenisUser),
+		errors.Is(err, errTransisiStatus), errors.Is(err, errStatusTidakDikenal):
+		return &errorScim{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: err.Error()}
+	case errors.Is(err, errJenisUserDipakai):
+		return &errorScim{Status: http.StatusConflict, Detail: err.Error()}
+	case errors.Is(err, errStatusBerubah):
+		return errorVersiScim()
+	}
+	return &errorScim{Status: http.StatusInternalServerError, Detail: err.Error()}
+}
+
+func bodyErrorScim(errScim *errorScim) responses.ScimError {
+	return responses.ScimError{
+		Schemas:  []string{responses.ScimSchemaError},
+		Status:   strconv.Itoa(errScim.Status),
+		ScimType: errScim.ScimType,
+		Detail:   errScim.Detail,
+	}
+}
+
+func kirimScim(c *fiber.Ctx, status int, body interface{}) error {
+	return c.Status(status).JSON(body, mediaScim)
+}
+
+func kirimErrorScim(c *fiber.Ctx, err error) error {
+	errScim := keErrorScim(err)
+	return kirimScim(c, errScim.Status, bodyErrorScim(errScim))
+}
+
+// Body request SCIM dibaca sebagai JSON apa pun Content-Type-nya (application/scim+json atau application/json)
+func bacaBodyScim(body []byte, tujuan interface{}) error {
+	if err := json.Unmarshal(body, tujuan); err != nil {
+		return &errorScim{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "Request body is not valid JSON: " + err.Error()}
+	}
+	return nil
+}
+
+// Resource struct diubah menjadi map untuk PATCH dan proyeksi atribut
+func mapScim(resource interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	hasil := map[string]interface{}{}
+	err = json.Unmarshal(data, &hasil)
+	return hasil, err
+}
+
+// Kumpulan konteks request yang dibutuhkan operasi SCIM, juga dipakai oleh operasi bulk
+type konteksScim struct {
+	BaseURL string // Awalan URL /scim/v2 untuk meta.location
+	Actor   string
+}
+
+func konteksDari(c *fiber.Ctx) konteksScim {
+	return konteksScim{BaseURL: c.BaseURL() + "/scim/v2", Actor: aktorDari(c)}
+}
+
+func waktuScim(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Waktu perubahan terakhir per dokumen dari histori, satu query untuk satu halaman resource
+func waktuUbahScim(ctx context.Context, koleksi string, ids []primitive.ObjectID) (map[primitive.ObjectID]time.Time, error) {
+	hasil := map[primitive.ObjectID]time.Time{}
+	if len(ids) == 0 {
+		return hasil, nil
+	}
+	cursor, err := HistoriCollection.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"koleksi": koleksi, "dokumen_id": bson.M{"$in": ids}}},
+		bson.M{"$group": bson.M{"_id": "$dokumen_id", "terakhir": bson.M{"$max": "$created_at"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var baris []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Terakhir time.Time          `bson:"terakhir"`
+	}
+	if err := cursor.All(ctx, &baris); err != nil {
+		return nil, err
+	}
+	for _, b := range baris {
+		hasil[b.ID] = b.Terakhir
+	}
+	return hasil, nil
+}
+
+// Parameter query resource, dari query string GET atau body POST .search
+type queryScim struct {
+	Filter     *filterScim
+	StartIndex int64 // Dimulai dari 1
+	Count      int
+	SortBy     string
+	Descending bool
+	Attributes []string
+	Excluded   []string
+}
+
+// Atribut dipisah koma seperti di query string
+func daftarAttrScim(teks string) []string {
+	hasil := []string{}
+	for _, attr := range strings.Split(teks, ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			hasil = append(hasil, attr)
+		}
+	}
+	return hasil
+}
+
+func parseQueryScim(c *fiber.Ctx) (queryScim, error) {
+	var body struct {
+		Schemas            []string `json:"schemas"`
+		Attributes         []string `json:"attributes"`
+		ExcludedAttributes []string `json:"excludedAttributes"`
+		Filter             string   `json:"filter"`
+		SortBy             string   `json:"sortBy"`
+		SortOrder          string   `json:"sortOrder"`
+		StartIndex         *int64   `json:"startIndex"`
+		Count              *int     `json:"count"`
+	}
+	if c.Method() == fiber.MethodPost {
+		if err := bacaBodyScim(c.Body(), &body); err != nil {
+			return queryScim{}, err
+		}
+	} else {
+		body.Attributes = daftarAttrScim(c.Query("attributes"))
+		body.ExcludedAttributes = daftarAttrScim(c.Query("excludedAttributes"))
+		body.Filter = c.Query("filter")
+		body.SortBy = c.Query("sortBy")
+		body.SortOrder = c.Query("sortOrder")
+		for nama, tujuan := range map[string]interface{}{"startIndex": &body.StartIndex, "count": &body.Count} {
+			nilai := c.Query(nama)
+			if nilai == "" {
+				continue
+			}
+			angka, err := strconv.Atoi(nilai)
+			if err != nil {
+				return queryScim{}, &errorScim{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: nama + " must be an integer"}
+			}
+			switch t := tujuan.(type) {
+			case **int64:
+				v := int64(angka)
+				*t = &v
+			case **int:
+				*t = &angka
+			}
+		}
+	}
+
+	q := queryScim{StartIndex: 1, Count: countScimDefault, SortBy: body.SortBy, Attributes: body.Attributes, Excluded: body.ExcludedAttributes}
+	// Nilai startIndex di bawah 1 dan count negatif diperlakukan sesuai RFC 7644 bagian 3.4.2.4
+	if body.StartIndex != nil && *body.StartIndex > 1 {
+		q.StartIndex = *body.StartIndex
+	}
+	if body.Count != nil {
+		q.Count = max(*body.Count, 0)
+	}
+	q.Count = min(q.Count, countScimMaks)
+
+	switch strings.ToLower(body.SortOrder) {
+	case "", "ascending":
+	case "descending":
+		q.Descending = true
+	default:
+		return q, &errorScim{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: "sortOrder must be ascending or descending"}
+	}
+
+	if body.Filter != "" {
+		filter, err := parseFilterScim(body.Filter)
+		if err != nil {
+			return q, err
+		}
+		q.Filter = filter
+	}
+	return q, nil
+}
+
+// Apakah atribut ikut dikirim menurut attributes/excludedAttributes, untuk melewati query yang mahal
+func (q queryScim) dikirim(attr string) bool {
+	for _, nama := range q.Excluded {
+		if strings.EqualFold(nama, attr) {
+			return false
+		}
+	}
+	if len(q.Attributes) == 0 {
+		return true
+	}
+	for _, nama := range q.Attributes {
+		if p, err := parsePathScim(nama); err == nil && p.Ext == "" && strings.EqualFold(p.Attr, attr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Terapkan attributes dan excludedAttributes. schemas, id dan meta selalu dikirim.
+func proyeksiScim(resource map[string]interface{}, attributes, excluded []string) map[string]interface{} {
+	if len(attributes) > 0 {
+		hasil := map[string]interface{}{}
+		for _, kunci := range []string{"schemas", "id", "meta"} {
+			if nilai, ok := resource[kunci]; ok {
+				hasil[kunci] = nilai
+			}
+		}
+		for _, nama := range attributes {
+			path, err := parsePathScim(nama)
+			if err != nil {
+				continue
+			}
+			salinAttrScim(resource, hasil, path)
+		}
+		resource = hasil
+	}
+	for _, nama := range excluded {
+		path, err := parsePathScim(nama)
+		if err != nil || path.Attr == "id" || path.Attr == "schemas" {
+			continue
+		}
+		wadah := resource
+		if path.Ext != "" {
+			_, ext, _ := ambilAttrScim(resource, path.Ext)
+			objek, ok := ext.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			wadah = objek
+		}
+		if path.Sub == "" {
+			hapusAttrScim(wadah, path.Attr)
+		} else if _, nilai, ok := ambilAttrScim(wadah, path.Attr); ok {
+			if objek, ok := nilai.(map[string]interface{}); ok {
+				hapusAttrScim(objek, path.Sub)
+			}
+		}
+	}
+	return resource
+}
+
+func salinAttrScim(asal, tujuan map[string]interface{}, path pathScim) {
+	if path.Ext != "" {
+		kunciExt, ext, ok := ambilAttrScim(asal, path.Ext)
+		objek, kompleks := ext.(map[string]interface{})
+		if !ok || !kompleks {
+			return
+		}
+		_, tujuanExt, _ := ambilAttrScim(tujuan, path.Ext)
+		objekTujuan, ok := tujuanExt.(map[string]interface{})
+		if !ok {
+			objekTujuan = map[string]interface{}{}
+			tujuan[kunciExt] = objekTujuan
+		}
+		salinAttrScim(objek, objekTujuan, pathScim{Attr: path.Attr, Sub: path.Sub})
+		return
+	}
+
+	kunci, nilai, ok := ambilAttrScim(asal, path.Attr)
+	if !ok {
+		return
+	}
+	objek, kompleks := nilai.(map[string]interface{})
+	if path.Sub == "" || !kompleks {
+		tujuan[kunci] = nilai
+		return
+	}
+	_, lama, _ := ambilAttrScim(tujuan, path.Attr)
+	objekTujuan, ok := lama.(map[string]interface{})
+	if !ok {
+		objekTujuan = map[string]interface{}{}
+		tujuan[kunci] = objekTujuan
+	}
+	if kunciSub, sub, ok := ambilAttrScim(objek, path.Sub); ok {
+		objekTujuan[kunciSub] = sub
+	}
+}
+
+// Daftar resource dalam ListResponse, setiap resource diproyeksikan sesuai query
+func listResponseScim(q queryScim, total int64, resources []interface{}) (responses.ScimListResponse, error) {
+	hasil := responses.ScimListResponse{
+		Schemas:      []string{responses.ScimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   q.StartIndex,
+		ItemsPerPage: len(resources),
+		Resources:    []interface{}{},
+	}
+	for _, resource := range resources {
+		peta, err := mapScim(resource)
+		if err != nil {
+			return hasil, err
+		}
+		hasil.Resources = append(hasil.Resources, proyeksiScim(peta, q.Attributes, q.Excluded))
+	}
+	return hasil, nil
+}
+
+// Kirim satu resource sesuai ?attributes= dan ?excludedAttributes=
+func kirimResourceScim(c *fiber.Ctx, status int, resource interface{}) error {
+	peta, err := mapScim(resource)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	peta = proyeksiScim(peta, daftarAttrScim(c.Query("attributes")), daftarAttrScim(c.Query("excludedAttributes")))
+	if meta, ok := peta["meta"].(map[string]interface{}); ok {
+		if lokasi, ok := meta["location"].(string); ok && status == http.StatusCreated {
+			c.Set(fiber.HeaderLocation, lokasi)
+		}
+		if versi, ok := meta["version"].(string); ok && versi != "" {
+			c.Set(fiber.HeaderETag, versi)
+		}
+	}
+	return kirimScim(c, status, peta)
+}
+
+// ------------------------------
+// Discovery
+// ------------------------------
+
+// GetScimServiceProviderConfig - Kemampuan server SCIM ini
+func GetScimServiceProviderConfig(c *fiber.Ctx) error {
+	base := konteksDari(c).BaseURL
+	return kirimScim(c, http.StatusOK, fiber.Map{
+		"schemas":          []string{responses.ScimSchemaServiceProvider},
+		"documentationUri": "https://datatracker.ietf.org/doc/html/rfc7644",
+		"patch":            fiber.Map{"supported": true},
+		"bulk":             fiber.Map{"supported": true, "maxOperations": bulkScimMaksOp, "maxPayloadSize": bulkScimMaksUkuran},
+		"filter":           fiber.Map{"supported": true, "maxResults": countScimMaks},
+		"changePassword":   fiber.Map{"supported": true},
+		"sort":             fiber.Map{"supported": true},
+		"etag":             fiber.Map{"supported": true},
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Service credential issued per provisioning client",
+			"primary":     true,
+		}},
+		"meta": responses.ScimMeta{ResourceType: "ServiceProviderConfig", Location: base + "/ServiceProviderConfig"},
+	})
+}
+
+func resourceTypesScim(base string) []fiber.Map {
+	return []fiber.Map{
+		{
+			"schemas":          []string{responses.ScimSchemaResourceType},
+			"id":               "User",
+			"name":             "User",
+			"endpoint":         "/Users",
+			"description":      "User account",
+			"schema":           responses.ScimSchemaUser,
+			"schemaExtensions": []fiber.Map{{"schema": responses.ScimSchemaUserExt, "required": false}},
+			"meta":             responses.ScimMeta{ResourceType: "ResourceType", Location: base + "/ResourceTypes/User"},
+		},
+		{
+			"schemas":          []string{responses.ScimSchemaResourceType},
+			"id":               "Group",
+			"name":             "Group",
+			"endpoint":         "/Groups",
+			"description":      "Jenis user with its default module bundle",
+			"schema":           responses.ScimSchemaGroup,
+			"schemaExtensions": []fiber.Map{{"schema": responses.ScimSchemaGroupExt, "required": false}},
+			"meta":             responses.ScimMeta{ResourceType: "ResourceType", Location: base + "/ResourceTypes/Group"},
+		},
+	}
+}
+
+// GetScimResourceTypes - Daftar resource type, atau satu resource type dengan :name
+func GetScimResourceTypes(c *fiber.Ctx) error {
+	daftar := resourceTypesScim(konteksDari(c).BaseURL)
+	if nama := c.Params("name"); nama != "" {
+		for _, item := range daftar {
+			if item["id"] == nama {
+				return kirimScim(c, http.StatusOK, item)
+			}
+		}
+		return kirimErrorScim(c, errorNotFoundScim("ResourceType", nama))
+	}
+	resources := make([]interface{}, 0, len(daftar))
+	for _, item := range daftar {
+		resources = append(resources, item)
+	}
+	return kirimScim(c, http.StatusOK, responses.ScimListResponse{
+		Schemas: []string{responses.ScimSchemaListResponse}, TotalResults: int64(len(resources)),
+		StartIndex: 1, ItemsPerPage: len(resources), Resources: resources,
+	})
+}
+
+// Definisi satu atribut schema SCIM
+func attrSchema(nama, tipe string, multi, wajib bool, mutability string, sub ...fiber.Map) fiber.Map {
+	attr := fiber.Map{
+		"name":        nama,
+		"type":        tipe,
+		"multiValued": multi,
+		"required":    wajib,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    "default",
+		"uniqueness":  "none",
+	}
+	if mutability == "writeOnly" {
+		attr["returned"] = "never"
+	}
+	if len(sub) > 0 {
+		attr["subAttributes"] = sub
+	}
+	return attr
+}
+
+// Sub atribut nilai multi-valued: value, display, type, primary
+func subMultiValue(tipeValue string) []fiber.Map {
+	return []fiber.Map{
+		attrSchema("value", tipeValue, false, false, "readWrite"),
+		attrSchema("display", "string", false, false, "readOnly"),
+		attrSchema("type", "string", false, false, "readWrite"),
+		attrSchema("primary", "boolean", false, false, "readWrite"),
+	}
+}
+
+func schemasScim(base string) []fiber.Map {
+	userName := attrSchema("userName", "string", false, true, "readWrite")
+	userName["uniqueness"] = "server"
+	externalID := attrSchema("externalId", "string", false, false, "readWrite")
+	externalID["caseExact"] = true
+	members := attrSchema("members", "complex", true, false, "readWrite",
+		attrSchema("value", "string", false, false, "immutable"),
+		attrSchema("display", "string", false, false, "readOnly"),
+		attrSchema("type", "string", false, false, "immutable"),
+	)
+	displayName := attrSchema("displayName", "string", false, true, "immutable")
+	displayName["uniqueness"] = "server"
+
+	daftar := []fiber.Map{
+		{
+			"id":          responses.ScimSchemaUser,
+			"name":        "User",
+			"description": "User account",
+			"attributes": []fiber.Map{
+				userName,
+				externalID,
+				attrSchema("name", "complex", false, false, "readWrite",
+					attrSchema("formatted", "string", false, false, "readWrite"),
+					attrSchema("givenName", "string", false, false, "writeOnly"),
+					attrSchema("familyName", "string", false, false, "writeOnly"),
+				),
+				attrSchema("displayName", "string", false, false, "readWrite"),
+				attrSchema("userType", "string", false, false, "readWrite"),
+				attrSchema("active", "boolean", false, false, "readWrite"),
+				attrSchema("password", "string", false, false, "writeOnly"),
+				attrSchema("emails", "complex", true, true, "readWrite", subMultiValue("string")...),
+				attrSchema("phoneNumbers", "complex", true, false, "readWrite", subMultiValue("string")...),
+				attrSchema("photos", "complex", true, false, "readWrite", subMultiValue("reference")...),
+				attrSchema("roles", "complex", true, false, "readWrite", subMultiValue("string")...),
+				attrSchema("groups", "complex", true, false, "readOnly", subMultiValue("string")...),
+			},
+		},
+		{
+			"id":          responses.ScimSchemaUserExt,
+			"name":        "UserExtension",
+			"description": "Account attributes outside the core schema",
+			"attributes": []fiber.Map{
+				attrSchema("jenisKelamin", "integer", false, false, "readWrite"),
+				attrSchema("status", "string", false, false, "readOnly"),
+			},
+		},
+		{
+			"id":          responses.ScimSchemaGroup,
+			"name":        "Group",
+			"description": "Jenis user, every user belongs to exactly one group",
+			"attributes":  []fiber.Map{displayName, members},
+		},
+		{
+			"id":          responses.ScimSchemaGroupExt,
+			"name":        "GroupExtension",
+			"description": "Default module bundle granted to every member",
+			"attributes": []fiber.Map{
+				attrSchema("modules", "complex", true, false, "readWrite", subMultiValue("string")...),
+				attrSchema("parent", "string", true, false, "readWrite"),
+			},
+		},
+	}
+	for _, schema := range daftar {
+		schema["schemas"] = []string{responses.ScimSchemaSchema}
+		schema["meta"] = responses.ScimMeta{ResourceType: "Schema", Location: base + "/Schemas/" + schema["id"].(string)}
+	}
+	return daftar
+}
+
+// GetScimSchemas - Daftar schema, atau satu schema dengan :id
+func GetScimSchemas(c *fiber.Ctx) error {
+	daftar := schemasScim(konteksDari(c).BaseURL)
+	if id := c.Params("id"); id != "" {
+		for _, item := range daftar {
+			if item["id"] == id {
+				return kirimScim(c, http.StatusOK, item)
+			}
+		}
+		return kirimErrorScim(c, errorNotFoundScim("Schema", id))
+	}
+	resources := make([]interface{}, 0, len(daftar))
+	for _, item := range daftar {
+		resources = append(resources, item)
+	}
+	return kirimScim(c, http.StatusOK, responses.ScimListResponse{
+		Schemas: []string{responses.ScimSchemaListResponse}, TotalResults: int64(len(resources)),
+		StartIndex: 1, ItemsPerPage: len(resources), Resources: resources,
+	})
+}
+
+// ------------------------------
+// Bulk
+// ------------------------------
+
+type operasiBulkScim struct {
+	Method  string          `json:"method"`
+	BulkID  string          `json:"bulkId,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Path    string          `json:"path"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type hasilBulkScim struct {
+	Method   string               `json:"method"`
+	BulkID   string               `json:"bulkId,omitempty"`
+	Version  string               `json:"version,omitempty"`
+	Location string               `json:"location,omitempty"`
+	Status   string               `json:"status"`
+	Response *responses.ScimError `json:"response,omitempty"`
+}
+
+// Ganti referensi "bulkId:xxx" dengan ID resource yang sudah dibuat di operasi sebelumnya
+func gantiBulkIDScim(teks string, dibuat map[string]string) (string, error) {
+	for bulkID, id := range dibuat {
+		teks = strings.ReplaceAll(teks, "bulkId:"+bulkID, id)
+	}
+	if i := strings.Index(teks, "bulkId:"); i >= 0 {
+		return teks, &errorScim{Status: http.StatusConflict, ScimType: "invalidValue", Detail: "Unresolved bulkId reference in " + teks[i:min(len(teks), i+40)]}
+	}
+	return teks, nil
+}
+
+// Jalankan satu operasi bulk. Mengembalikan resource hasil (nil untuk DELETE) beserta status HTTP.
+func jalankanOperasiBulkScim(ctx context.Context, k konteksScim, op operasiBulkScim, dibuat map[string]string) (interface{}, int, error) {
+	path, err := gantiBulkIDScim(op.Path, dibuat)
+	if err != nil {
+		return nil, 0, err
+	}
+	var data map[string]interface{}
+	if len(op.Data) > 0 {
+		teks, err := gantiBulkIDScim(string(op.Data), dibuat)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := bacaBodyScim([]byte(teks), &data); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	bagian := strings.Split(strings.Trim(path, "/"), "/")
+	resource, id := bagian[0], ""
+	if len(bagian) == 2 {
+		id = bagian[1]
+	}
+	if len(bagian) > 2 || (resource != "Users" && resource != "Groups") {
+		return nil, 0, &errorScim{Status: http.StatusBadRequest, ScimType: "invalidPath", Detail: "Unsupported bulk path " + path}
+	}
+	method := strings.ToUpper(op.Method)
+	if (method == fiber.MethodPost) != (id == "") {
+		return nil, 0, &errorScim{Status: http.StatusBadRequest, ScimType: "invalidPath", Detail: method + " is not allowed on " + path}
+	}
+
+	switch {
+	case method == fiber.MethodPost && resource == "Users":
+		hasil, err := scimBuatUser(ctx, k, data)
+		return hasil, http.StatusCreated, err
+	case method == fiber.MethodPost:
+		hasil, err := scimBuatGroup(ctx, k, data)
+		return hasil, http.StatusCreated, err
+	case method == fiber.MethodPut && resource == "Users":
+		hasil, err := scimGantiUser(ctx, k, id, data, op.Version)
+		return hasil, http.StatusOK, err
+	case method == fiber.MethodPut:
+		hasil, err := scimGantiGroup(ctx, k, id, data)
+		return hasil, http.StatusOK, err
+	case method == fiber.MethodPatch:
+		var patch requestPatchScim
+		if err := bacaBodyScim(op.Data, &patch); err != nil {
+			return nil, 0, err
+		}
+		if resource == "Users" {
+			hasil, err := scimPatchUser(ctx, k, id, patch, op.Version)
+			return hasil, http.StatusOK, err
+		}
+		hasil, err := scimPatchGroup(ctx, k, id, patch)
+		return hasil, http.StatusOK, err
+	case method == fiber.MethodDelete && resource == "Users":
+		return nil, http.StatusNoContent, scimHapusUser(ctx, k, id, op.Version)
+	case method == fiber.MethodDelete:
+		return nil, http.StatusNoContent, scimHapusGroup(ctx, k, id)
+	}
+	return nil, 0, &errorScim{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "Unsupported bulk method " + op.Method}
+}
+
+// ScimBulk - Jalankan beberapa operasi Users/Groups berurutan (RFC 7644 bagian 3.7).
+// Operasi berikutnya bisa mereferensikan resource baru lewat "bulkId:<id>".
+func ScimBulk(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if len(c.Body()) > bulkScimMaksUkuran {
+		return kirimErrorScim(c, &errorScim{Status: http.StatusRequestEntityTooLarge, Detail: fmt.Sprintf("Bulk payload exceeds %d bytes", bulkScimMaksUkuran)})
+	}
+	var body struct {
+		Schemas      []string          `json:"schemas"`
+		FailOnErrors int               `json:"failOnErrors"`
+		Operations   []operasiBulkScim `json:"Operations"`
+	}
+	if err := bacaBodyScim(c.Body(), &body); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	if len(body.Operations) > bulkScimMaksOp {
+		return kirimErrorScim(c, &errorScim{Status: http.StatusRequestEntityTooLarge, Detail: fmt.Sprintf("Bulk request exceeds %d operations", bulkScimMaksOp)})
+	}
+
+	k := konteksDari(c)
+	dibuat := map[string]string{}
+	hasil := []hasilBulkScim{}
+	gagal := 0
+	for _, op := range body.Operations {
+		item := hasilBulkScim{Method: op.Method, BulkID: op.BulkID}
+		if strings.EqualFold(op.Method, fiber.MethodPost) && op.BulkID == "" {
+			errScim := &errorScim{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: "bulkId is required for POST operations"}
+			body := bodyErrorScim(errScim)
+			item.Status, item.Response = body.Status, &body
+			hasil = append(hasil, item)
+			gagal++
+		} else {
+			resource, status, err := jalankanOperasiBulkScim(ctx, k, op, dibuat)
+			if err != nil {
+				body := bodyErrorScim(keErrorScim(err))
+				item.Status, item.Response = body.Status, &body
+				gagal++
+			} else {
+				item.Status = strconv.Itoa(status)
+				switch r := resource.(type) {
+				case *responses.ScimUser:
+					item.Location, item.Version = r.Meta.Location, r.Meta.Version
+					if op.BulkID != "" {
+						dibuat[op.BulkID] = r.ID
+					}
+				case *responses.ScimGroup:
+					item.Location = r.Meta.Location
+					if op.BulkID != "" {
+						dibuat[op.BulkID] = r.ID
+					}
+				}
+			}
+			hasil = append(hasil, item)
+		}
+		if body.FailOnErrors > 0 && gagal >= body.FailOnErrors {
+			break
+		}
+	}
+
+	return kirimScim(c, http.StatusOK, fiber.Map{
+		"schemas":    []string{responses.ScimSchemaBulkResponse},
+		"Operations": hasil,
+	})
+}
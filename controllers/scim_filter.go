@@ -0,0 +1,586 @@
+package controllers
+
+import (
+	"demoapp/responses"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ekspresi filter SCIM (RFC 7644 bagian 3.4.2.2) yang sudah di-parse
+type filterScim struct {
+	Op    string      // and, or, not, valuepath, atau operator pembanding: eq ne co sw ew gt ge lt le pr
+	Attr  string      // Path atribut huruf kecil tanpa URN schema inti, misalnya "emails.value"
+	Nilai interface{} // string, float64, bool atau nil
+	Kiri  *filterScim // Operand and/or, isi not, atau filter di dalam [] untuk valuepath
+	Kanan *filterScim
+}
+
+var operatorScim = map[string]bool{"eq": true, "ne": true, "co": true, "sw": true, "ew": true, "gt": true, "ge": true, "lt": true, "le": true, "pr": true}
+
+func errorFilterScim(format string, args ...interface{}) *errorScim {
+	return &errorScim{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: fmt.Sprintf(format, args...)}
+}
+
+// Token filter: kurung, kata (nama atribut, operator, true/false/null, angka) dan string JSON
+type tokenScim struct {
+	Teks   string
+	String bool
+}
+
+func tokenFilterScim(teks string) ([]tokenScim, error) {
+	tokens := []tokenScim{}
+	for i := 0; i < len(teks); {
+		switch ch := teks[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			i++
+		case ch == '(' || ch == ')' || ch == '[' || ch == ']':
+			tokens = append(tokens, tokenScim{Teks: string(ch)})
+			i++
+		case ch == '"':
+			// String mengikuti aturan escape JSON
+			j := i + 1
+			for ; j < len(teks) && teks[j] != '"'; j++ {
+				if teks[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(teks) {
+				return nil, errorFilterScim("unterminated string in filter")
+			}
+			var nilai string
+			if err := json.Unmarshal([]byte(teks[i:j+1]), &nilai); err != nil {
+				return nil, errorFilterScim("invalid string in filter: %s", teks[i:j+1])
+			}
+			tokens = append(tokens, tokenScim{Teks: nilai, String: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(teks) && !strings.ContainsRune(" \t\n()[]\"", rune(teks[j])) {
+				j++
+			}
+			tokens = append(tokens, tokenScim{Teks: teks[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type parserFilterScim struct {
+	tokens []tokenScim
+	posisi int
+}
+
+func (p *parserFilterScim) lihat() (tokenScim, bool) {
+	if p.posisi >= len(p.tokens) {
+		return tokenScim{}, false
+	}
+	return p.tokens[p.posisi], true
+}
+
+func (p *parserFilterScim) kata(kata string) bool {
+	token, ok := p.lihat()
+	if ok && !token.String && strings.EqualFold(token.Teks, kata) {
+		p.posisi++
+		return true
+	}
+	return false
+}
+
+func (p *parserFilterScim) harus(kata string) error {
+	if !p.kata(kata) {
+		if token, ok := p.lihat(); ok {
+			return errorFilterScim("expected %q but found %q", kata, token.Teks)
+		}
+		return errorFilterScim("expected %q at end of filter", kata)
+	}
+	return nil
+}
+
+// parseFilterScim mengubah teks filter menjadi pohon ekspresi
+func parseFilterScim(teks string) (*filterScim, error) {
+	tokens, err := tokenFilterScim(teks)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errorFilterScim("filter is empty")
+	}
+	p := &parserFilterScim{tokens: tokens}
+	filter, err := p.atau()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := p.lihat(); ok {
+		return nil, errorFilterScim("unexpected %q in filter", token.Teks)
+	}
+	return filter, nil
+}
+
+func (p *parserFilterScim) atau() (*filterScim, error) {
+	kiri, err := p.dan()
+	if err != nil {
+		return nil, err
+	}
+	for p.kata("or") {
+		kanan, err := p.dan()
+		if err != nil {
+			return nil, err
+		}
+		kiri = &filterScim{Op: "or", Kiri: kiri, Kanan: kanan}
+	}
+	return kiri, nil
+}
+
+func (p *parserFilterScim) dan() (*filterScim, error) {
+	kiri, err := p.faktor()
+	if err != nil {
+		return nil, err
+	}
+	for p.kata("and") {
+		kanan, err := p.faktor()
+		if err != nil {
+			return nil, err
+		}
+		kiri = &filterScim{Op: "and", Kiri: kiri, Kanan: kanan}
+	}
+	return kiri, nil
+}
+
+func (p *parserFilterScim) faktor() (*filterScim, error) {
+	if p.kata("not") {
+		if err := p.harus("("); err != nil {
+			return nil, err
+		}
+		isi, err := p.atau()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.harus(")"); err != nil {
+			return nil, err
+		}
+		return &filterScim{Op: "not", Kiri: isi}, nil
+	}
+	if p.kata("(") {
+		isi, err := p.atau()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.harus(")"); err != nil {
+			return nil, err
+		}
+		return isi, nil
+	}
+
+	token, ok := p.lihat()
+	if !ok || token.String {
+		return nil, errorFilterScim("expected attribute name in filter")
+	}
+	p.posisi++
+	attr := normalisasiAttrScim(token.Teks)
+
+	// Value path, misalnya emails[type eq "work" and value co "@unair.ac.id"]
+	if p.kata("[") {
+		isi, err := p.atau()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.harus("]"); err != nil {
+			return nil, err
+		}
+		return &filterScim{Op: "valuepath", Attr: attr, Kiri: isi}, nil
+	}
+
+	opToken, ok := p.lihat()
+	if !ok || opToken.String || !operatorScim[strings.ToLower(opToken.Teks)] {
+		return nil, errorFilterScim("expected operator after %q", token.Teks)
+	}
+	p.posisi++
+	op := strings.ToLower(opToken.Teks)
+	if op == "pr" {
+		return &filterScim{Op: op, Attr: attr}, nil
+	}
+
+	nilaiToken, ok := p.lihat()
+	if !ok {
+		return nil, errorFilterScim("expected value after %q", opToken.Teks)
+	}
+	p.posisi++
+	nilai, err := nilaiFilterScim(nilaiToken)
+	if err != nil {
+		return nil, err
+	}
+	return &filterScim{Op: op, Attr: attr, Nilai: nilai}, nil
+}
+
+func nilaiFilterScim(token tokenScim) (interface{}, error) {
+	if token.String {
+		return token.Teks, nil
+	}
+	switch strings.ToLower(token.Teks) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	var angka float64
+	if err := json.Unmarshal([]byte(token.Teks), &angka); err != nil {
+		return nil, errorFilterScim("invalid value %q in filter", token.Teks)
+	}
+	return angka, nil
+}
+
+// Nama atribut dibandingkan tanpa membedakan huruf besar kecil, URN schema inti dibuang.
+// Atribut extension tetap diawali URN-nya, misalnya "urn:...:user:jeniskelamin".
+func normalisasiAttrScim(attr string) string {
+	kecil := strings.ToLower(attr)
+	for _, schema := range []string{responses.ScimSchemaUser, responses.ScimSchemaGroup} {
+		if prefix := strings.ToLower(schema) + ":"; strings.HasPrefix(kecil, prefix) {
+			return kecil[len(prefix):]
+		}
+	}
+	return kecil
+}
+
+// Path operasi PATCH: attr[filter].sub, bagian filter dan sub opsional
+type pathScim struct {
+	Ext    string // URN extension huruf kecil jika atribut berada di objek extension
+	Attr   string // Nama atribut huruf kecil, atau URN extension untuk seluruh objek extension
+	Filter *filterScim
+	Sub    string
+}
+
+func parsePathScim(teks string) (pathScim, error) {
+	teks = strings.TrimSpace(teks)
+	if teks == "" {
+		return pathScim{}, nil
+	}
+	errPath := &errorScim{Status: http.StatusBadRequest, ScimType: "invalidPath", Detail: "Invalid path: " + teks}
+
+	// Atribut extension: URN diikuti ":" lalu nama atribut
+	for _, schema := range []string{responses.ScimSchemaUserExt, responses.ScimSchemaGroupExt} {
+		if strings.EqualFold(teks, schema) {
+			return pathScim{Attr: strings.ToLower(schema)}, nil
+		}
+		if prefix := schema + ":"; len(teks) > len(prefix) && strings.EqualFold(teks[:len(prefix)], prefix) {
+			path, err := parsePathScim(teks[len(prefix):])
+			path.Ext = strings.ToLower(schema)
+			return path, err
+		}
+	}
+	teks = normalisasiAttrScim(teks)
+
+	path := pathScim{}
+	if buka := strings.Index(teks, "["); buka >= 0 {
+		tutup := strings.LastIndex(teks, "]")
+		if tutup < buka {
+			return path, errPath
+		}
+		filter, err := parseFilterScim(teks[buka+1 : tutup])
+		if err != nil {
+			return path, err
+		}
+		path.Attr = teks[:buka]
+		path.Filter = filter
+		sisa := teks[tutup+1:]
+		if sisa != "" {
+			if !strings.HasPrefix(sisa, ".") || len(sisa) < 2 {
+				return path, errPath
+			}
+			path.Sub = sisa[1:]
+		}
+		return path, nil
+	}
+	path.Attr, path.Sub, _ = strings.Cut(teks, ".")
+	if path.Attr == "" || strings.ContainsAny(path.Attr, " ()") {
+		return path, errPath
+	}
+	return path, nil
+}
+
+// Nilai atribut di map resource, nama atribut tidak membedakan huruf besar kecil
+func ambilAttrScim(objek map[string]interface{}, nama string) (string, interface{}, bool) {
+	for kunci, nilai := range objek {
+		if strings.EqualFold(kunci, nama) {
+			return kunci, nilai, true
+		}
+	}
+	return nama, nil, false
+}
+
+// Nilai-nilai untuk path bertitik pada satu objek. Atribut multi-valued menghasilkan beberapa nilai.
+func nilaiPathScim(objek map[string]interface{}, path string) []interface{} {
+	kepala, sisa, bertitik := strings.Cut(path, ".")
+	_, nilai, ok := ambilAttrScim(objek, kepala)
+	if !ok || nilai == nil {
+		return nil
+	}
+	daftar, multi := nilai.([]interface{})
+	if !multi {
+		daftar = []interface{}{nilai}
+	}
+	hasil := []interface{}{}
+	for _, item := range daftar {
+		if !bertitik {
+			// Atribut multi-valued kompleks tanpa sub atribut dibandingkan lewat value
+			if anak, ok := item.(map[string]interface{}); ok {
+				hasil = append(hasil, nilaiPathScim(anak, "value")...)
+				continue
+			}
+			hasil = append(hasil, item)
+			continue
+		}
+		if anak, ok := item.(map[string]interface{}); ok {
+			hasil = append(hasil, nilaiPathScim(anak, sisa)...)
+		}
+	}
+	return hasil
+}
+
+// cocokFilterScim mengevaluasi filter terhadap satu objek di memori, dipakai untuk value path PATCH
+func cocokFilterScim(f *filterScim, objek map[string]interface{}) bool {
+	switch f.Op {
+	case "and":
+		return cocokFilterScim(f.Kiri, objek) && cocokFilterScim(f.Kanan, objek)
+	case "or":
+		return cocokFilterScim(f.Kiri, objek) || cocokFilterScim(f.Kanan, objek)
+	case "not":
+		return !cocokFilterScim(f.Kiri, objek)
+	case "valuepath":
+		_, nilai, _ := ambilAttrScim(objek, f.Attr)
+		daftar, _ := nilai.([]interface{})
+		for _, item := range daftar {
+			if anak, ok := item.(map[string]interface{}); ok && cocokFilterScim(f.Kiri, anak) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, nilai := range nilaiPathScim(objek, f.Attr) {
+		if bandingkanNilaiScim(f.Op, nilai, f.Nilai) {
+			return true
+		}
+	}
+	// ne dan pr pada atribut yang tidak ada
+	return f.Op == "ne" && len(nilaiPathScim(objek, f.Attr)) == 0 && f.Nilai != nil
+}
+
+func bandingkanNilaiScim(op string, nilai, target interface{}) bool {
+	if op == "pr" {
+		return nilai != nil && nilai != ""
+	}
+	switch n := nilai.(type) {
+	case string:
+		t, ok := target.(string)
+		if !ok {
+			return op == "ne"
+		}
+		a, b := strings.ToLower(n), strings.ToLower(t)
+		switch op {
+		case "eq":
+			return a == b
+		case "ne":
+			return a != b
+		case "co":
+			return strings.Contains(a, b)
+		case "sw":
+			return strings.HasPrefix(a, b)
+		case "ew":
+			return strings.HasSuffix(a, b)
+		case "gt":
+			return a > b
+		case "ge":
+			return a >= b
+		case "lt":
+			return a < b
+		case "le":
+			return a <= b
+		}
+	case bool:
+		t, ok := target.(bool)
+		switch op {
+		case "eq":
+			return ok && n == t
+		case "ne":
+			return !ok || n != t
+		}
+	case float64:
+		t, ok := target.(float64)
+		if !ok {
+			return op == "ne"
+		}
+		switch op {
+		case "eq":
+			return n == t
+		case "ne":
+			return n != t
+		case "gt":
+			return n > t
+		case "ge":
+			return n >= t
+		case "lt":
+			return n < t
+		case "le":
+			return n <= t
+		}
+	}
+	return false
+}
+
+// Jenis atribut SCIM yang bisa dipakai di filter query
+const (
+	tipeScimString      = "string"      // Dibandingkan tanpa membedakan huruf besar kecil
+	tipeScimStringExact = "stringExact" // Dibandingkan persis
+	tipeScimID          = "id"          // ObjectID, hanya eq dan ne
+	tipeScimWaktu       = "dateTime"    // RFC 3339
+	tipeScimAngka       = "integer"
+	tipeScimKonstanta   = "konstanta" // Nilai tetap yang sama untuk semua resource, misalnya emails.type
+)
+
+// Pemetaan atribut SCIM ke field MongoDB
+type atributScim struct {
+	Field     string
+	Tipe      string
+	Konstanta interface{}
+	// Khusus menerjemahkan atribut yang tidak disimpan apa adanya, misalnya active
+	Khusus func(op string, nilai interface{}) (bson.M, error)
+}
+
+// Filter yang tidak akan cocok dengan dokumen mana pun
+var filterKosongScim = bson.M{"_id": bson.M{"$exists": false}}
+
+// bsonFilterScim menerjemahkan filter ke query MongoDB berdasarkan pemetaan atribut
+func bsonFilterScim(f *filterScim, atribut map[string]atributScim) (bson.M, error) {
+	switch f.Op {
+	case "and", "or":
+		kiri, err := bsonFilterScim(f.Kiri, atribut)
+		if err != nil {
+			return nil, err
+		}
+		kanan, err := bsonFilterScim(f.Kanan, atribut)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$" + f.Op: bson.A{kiri, kanan}}, nil
+	case "not":
+		isi, err := bsonFilterScim(f.Kiri, atribut)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{isi}}, nil
+	case "valuepath":
+		// Atribut di dalam [] memakai awalan atribut induknya
+		return bsonFilterScim(awaliFilterScim(f.Kiri, f.Attr), atribut)
+	}
+
+	attr, ok := atribut[f.Attr]
+	if !ok {
+		return nil, errorFilterScim("filtering on attribute %q is not supported", f.Attr)
+	}
+	if attr.Khusus != nil {
+		return attr.Khusus(f.Op, f.Nilai)
+	}
+	if f.Op == "pr" {
+		if attr.Tipe == tipeScimKonstanta || attr.Tipe == tipeScimID {
+			return bson.M{}, nil
+		}
+		return bson.M{attr.Field: bson.M{"$exists": true, "$nin": bson.A{nil, ""}}}, nil
+	}
+
+	switch attr.Tipe {
+	case tipeScimKonstanta:
+		if bandingkanNilaiScim(f.Op, attr.Konstanta, f.Nilai) {
+			return bson.M{}, nil
+		}
+		return filterKosongScim, nil
+	case tipeScimID:
+		teks, _ := f.Nilai.(string)
+		id, err := primitive.ObjectIDFromHex(teks)
+		switch {
+		case f.Op == "eq" && err != nil:
+			return filterKosongScim, nil
+		case f.Op == "eq":
+			return bson.M{attr.Field: id}, nil
+		case f.Op == "ne" && err != nil:
+			return bson.M{}, nil
+		case f.Op == "ne":
+			return bson.M{attr.Field: bson.M{"$ne": id}}, nil
+		}
+		return nil, errorFilterScim("operator %q is not supported for %q", f.Op, f.Attr)
+	case tipeScimWaktu:
+		teks, _ := f.Nilai.(string)
+		waktu, err := time.Parse(time.RFC3339, teks)
+		if err != nil {
+			return nil, errorFilterScim("%q must be compared with an RFC 3339 date", f.Attr)
+		}
+		return bandingkanBsonScim(attr.Field, f.Op, primitive.NewDateTimeFromTime(waktu), f.Attr)
+	case tipeScimAngka:
+		angka, ok := f.Nilai.(float64)
+		if !ok {
+			return nil, errorFilterScim("%q must be compared with a number", f.Attr)
+		}
+		return bandingkanBsonScim(attr.Field, f.Op, int(angka), f.Attr)
+	}
+
+	teks, ok := f.Nilai.(string)
+	if !ok {
+		return nil, errorFilterScim("%q must be compared with a string", f.Attr)
+	}
+	if attr.Tipe == tipeScimStringExact {
+		switch f.Op {
+		case "co":
+			return bson.M{attr.Field: bson.M{"$regex": regexp.QuoteMeta(teks)}}, nil
+		case "sw":
+			return bson.M{attr.Field: bson.M{"$regex": "^" + regexp.QuoteMeta(teks)}}, nil
+		case "ew":
+			return bson.M{attr.Field: bson.M{"$regex": regexp.QuoteMeta(teks) + "$"}}, nil
+		}
+		return bandingkanBsonScim(attr.Field, f.Op, teks, f.Attr)
+	}
+
+	pola := map[string]string{
+		"eq": "^" + regexp.QuoteMeta(teks) + "$",
+		"ne": "^" + regexp.QuoteMeta(teks) + "$",
+		"co": regexp.QuoteMeta(teks),
+		"sw": "^" + regexp.QuoteMeta(teks),
+		"ew": regexp.QuoteMeta(teks) + "$",
+	}[f.Op]
+	switch f.Op {
+	case "eq", "co", "sw", "ew":
+		return bson.M{attr.Field: primitive.Regex{Pattern: pola, Options: "i"}}, nil
+	case "ne":
+		return bson.M{attr.Field: bson.M{"$not": primitive.Regex{Pattern: pola, Options: "i"}}}, nil
+	}
+	return bandingkanBsonScim(attr.Field, f.Op, teks, f.Attr)
+}
+
+func bandingkanBsonScim(field, op string, nilai interface{}, attr string) (bson.M, error) {
+	operator := map[string]string{"eq": "$eq", "ne": "$ne", "gt": "$gt", "ge": "$gte", "lt": "$lt", "le": "$lte"}[op]
+	if operator == "" {
+		return nil, errorFilterScim("operator %q is not supported for %q", op, attr)
+	}
+	return bson.M{field: bson.M{operator: nilai}}, nil
+}
+
+// Salin filter dengan setiap atribut diberi awalan induk, untuk value path
+func awaliFilterScim(f *filterScim, induk string) *filterScim {
+	if f == nil {
+		return nil
+	}
+	salinan := *f
+	if salinan.Attr != "" {
+		salinan.Attr = induk + "." + salinan.Attr
+	}
+	salinan.Kiri = awaliFilterScim(f.Kiri, induk)
+	salinan.Kanan = awaliFilterScim(f.Kanan, induk)
+	return &salinan
+}
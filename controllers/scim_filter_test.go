@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// Tulis pohon filter dalam bentuk prefix agar mudah dibandingkan
+func tulisFilterScim(f *filterScim) string {
+	switch f.Op {
+	case "and", "or":
+		return fmt.Sprintf("(%s %s %s)", f.Op, tulisFilterScim(f.Kiri), tulisFilterScim(f.Kanan))
+	case "not":
+		return fmt.Sprintf("(not %s)", tulisFilterScim(f.Kiri))
+	case "valuepath":
+		return fmt.Sprintf("%s[%s]", f.Attr, tulisFilterScim(f.Kiri))
+	case "pr":
+		return fmt.Sprintf("(pr %s)", f.Attr)
+	}
+	return fmt.Sprintf("(%s %s %#v)", f.Op, f.Attr, f.Nilai)
+}
+
+func TestParseFilterScim(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{`userName eq "bjensen"`, `(eq username "bjensen")`},
+		{`USERNAME EQ "BJensen"`, `(eq username "BJensen")`},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "b"`, `(sw username "b")`},
+		{`name.familyName co "O'Malley"`, `(co name.familyname "O'Malley")`},
+		{`title pr`, `(pr title)`},
+		{`active eq true`, `(eq active true)`},
+		{`active ne false`, `(ne active false)`},
+		{`title eq null`, `(eq title <nil>)`},
+		{`meta.version gt 2`, `(gt meta.version 2)`},
+		{`displayName eq "a \"kutip\" b"`, `(eq displayname "a \"kutip\" b")`},
+		{`a eq "1" and b eq "2" or c eq "3"`, `(or (and (eq a "1") (eq b "2")) (eq c "3"))`},
+		{`a eq "1" or b eq "2" and c eq "3"`, `(or (eq a "1") (and (eq b "2") (eq c "3")))`},
+		{`a eq "1" and (b eq "2" or c eq "3")`, `(and (eq a "1") (or (eq b "2") (eq c "3")))`},
+		{`not (active eq true)`, `(not (eq active true))`},
+		{`emails[type eq "work" and value co "@unair.ac.id"]`, `emails[(and (eq type "work") (co value "@unair.ac.id"))]`},
+		{`emails[value pr] and active eq true`, `(and emails[(pr value)] (eq active true))`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := parseFilterScim(tt.filter)
+			if err != nil {
+				t.Fatalf("parseFilterScim(%q) error: %v", tt.filter, err)
+			}
+			if got := tulisFilterScim(f); got != tt.want {
+				t.Errorf("parseFilterScim(%q) = %s, want %s", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterScimTidakValid(t *testing.T) {
+	tests := []string{
+		``,
+		`   `,
+		`userName`,
+		`userName eq`,
+		`userName like "b"`,
+		`userName eq "bjensen`,
+		`userName eq bjensen`,
+		`"userName" eq "b"`,
+		`(userName eq "b"`,
+		`userName eq "b")`,
+		`not userName eq "b"`,
+		`emails[type eq "work"`,
+		`a eq "1" and`,
+		`a eq "1" b eq "2"`,
+	}
+	for _, filter := range tests {
+		t.Run(filter, func(t *testing.T) {
+			_, err := parseFilterScim(filter)
+			var errScim *errorScim
+			if !errors.As(err, &errScim) {
+				t.Fatalf("parseFilterScim(%q) error = %v, want *errorScim", filter, err)
+			}
+			if errScim.Status != http.StatusBadRequest || errScim.ScimType != "invalidFilter" {
+				t.Errorf("parseFilterScim(%q) = %d %s, want 400 invalidFilter", filter, errScim.Status, errScim.ScimType)
+			}
+		})
+	}
+}
@@ -0,0 +1,586 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"demoapp/responses"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Atribut multi-valued pada SCIM Group, termasuk atribut extension modules dan parent
+var multiScimGroup = map[string]bool{"members": true, "modules": true, "parent": true}
+
+// Pemetaan atribut SCIM Group ke field koleksi jenis_user untuk filter dan sortBy
+func atributScimGroup(ctx context.Context) map[string]atributScim {
+	return map[string]atributScim{
+		"id":                {Field: "_id", Tipe: tipeScimID},
+		"displayname":       {Field: "kode", Tipe: tipeScimString},
+		"meta.created":      {Field: "created_at", Tipe: tipeScimWaktu},
+		"meta.lastmodified": {Field: "updated_at", Tipe: tipeScimWaktu},
+		"members":           {Khusus: filterMemberScim(ctx)},
+		"members.value":     {Khusus: filterMemberScim(ctx)},
+	}
+}
+
+// members.value eq "<user id>" dicocokkan dengan jenis_user milik user tersebut
+func filterMemberScim(ctx context.Context) func(op string, nilai interface{}) (bson.M, error) {
+	return func(op string, nilai interface{}) (bson.M, error) {
+		if op != "eq" {
+			return nil, errorFilterScim("members can only be filtered with eq")
+		}
+		teks, _ := nilai.(string)
+		userID, err := primitive.ObjectIDFromHex(teks)
+		if err != nil {
+			return filterKosongScim, nil
+		}
+		user, err := cariUserByID(ctx, userID)
+		if err == mongo.ErrNoDocuments {
+			return filterKosongScim, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"kode": user.JenisUser}, nil
+	}
+}
+
+// Semua user yang belum dihapus untuk setiap kode jenis_user, dipakai sebagai anggota group
+func anggotaJenisUser(ctx context.Context, kode []string) (map[string][]model.User, error) {
+	hasil := map[string][]model.User{}
+	if len(kode) == 0 {
+		return hasil, nil
+	}
+	cursor, err := userCollection.Find(
+		ctx,
+		bson.M{"jenis_user": bson.M{"$in": kode}, "deleted_at": nil},
+		options.Find().SetProjection(bson.M{"username": 1, "jenis_user": 1}).SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var users []model.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		hasil[user.JenisUser] = append(hasil[user.JenisUser], user)
+	}
+	return hasil, nil
+}
+
+// Nama modul untuk display pada atribut modules
+func namaModulScim(ctx context.Context, daftar []model.JenisUser) (map[primitive.ObjectID]string, error) {
+	ids := []primitive.ObjectID{}
+	for _, jenisUser := range daftar {
+		ids = append(ids, jenisUser.ModulID...)
+	}
+	hasil := map[primitive.ObjectID]string{}
+	if len(ids) == 0 {
+		return hasil, nil
+	}
+	cursor, err := modulCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"nm_modul": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var moduls []model.Modul
+	if err := cursor.All(ctx, &moduls); err != nil {
+		return nil, err
+	}
+	for _, modul := range moduls {
+		hasil[modul.ID] = modul.NmModul
+	}
+	return hasil, nil
+}
+
+// Resource SCIM Group dari model.JenisUser
+func resourceScimGroup(jenisUser model.JenisUser, anggota []model.User, namaModul map[primitive.ObjectID]string, base string) *responses.ScimGroup {
+	lastModified := jenisUser.UpdatedAt
+	if lastModified.IsZero() {
+		lastModified = jenisUser.CreatedAt
+	}
+	resource := &responses.ScimGroup{
+		Schemas:     []string{responses.ScimSchemaGroup, responses.ScimSchemaGroupExt},
+		ID:          jenisUser.ID.Hex(),
+		DisplayName: jenisUser.Kode,
+		Members:     []responses.ScimMultiValue{},
+		Ext:         responses.ScimGroupExt{Modules: []responses.ScimMultiValue{}, Parent: jenisUser.Parent},
+		Meta: responses.ScimMeta{
+			ResourceType: "Group",
+			Created:      waktuScim(jenisUser.CreatedAt),
+			LastModified: waktuScim(lastModified),
+			Location:     base + "/Groups/" + jenisUser.ID.Hex(),
+		},
+	}
+	for _, user := range anggota {
+		resource.Members = append(resource.Members, responses.ScimMultiValue{
+			Value:   user.ID.Hex(),
+			Display: user.Username,
+			Type:    "User",
+			Ref:     base + "/Users/" + user.ID.Hex(),
+		})
+	}
+	for _, modulID := range jenisUser.ModulID {
+		resource.Ext.Modules = append(resource.Ext.Modules, responses.ScimMultiValue{Value: modulID.Hex(), Display: namaModul[modulID]})
+	}
+	return resource
+}
+
+// Atribut group dari body SCIM. Members, modules dan parent yang tidak dikirim dibiarkan
+// seperti semula kecuali lengkap bernilai true (hasil PATCH), di mana atribut yang
+// tidak ada berarti sudah dikosongkan.
+type masukanScimGroup struct {
+	Kode       string
+	Members    []primitive.ObjectID
+	AdaMembers bool
+	ModulID    []primitive.ObjectID
+	AdaModul   bool
+	Parent     []string
+	AdaParent  bool
+}
+
+// ID dari value setiap nilai multi-valued
+func idMultiScim(nilai interface{}, nama string) ([]primitive.ObjectID, error) {
+	daftar, ok := nilai.([]interface{})
+	if !ok {
+		return nil, errorNilaiScim("%s must be an array", nama)
+	}
+	hasil := []primitive.ObjectID{}
+	for _, item := range daftar {
+		objek, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errorNilaiScim("each value of %s must be an object", nama)
+		}
+		teks, err := stringScim(objek, "value")
+		if err != nil {
+			return nil, err
+		}
+		id, err := primitive.ObjectIDFromHex(teks)
+		if err != nil {
+			return nil, errorNilaiScim("%q in %s is not a valid ID", teks, nama)
+		}
+		hasil = append(hasil, id)
+	}
+	return hasil, nil
+}
+
+func bacaScimGroup(data map[string]interface{}, lengkap bool) (masukanScimGroup, error) {
+	masuk := masukanScimGroup{AdaMembers: lengkap, AdaModul: lengkap, AdaParent: lengkap, Members: []primitive.ObjectID{}, ModulID: []primitive.ObjectID{}}
+	var err error
+
+	if masuk.Kode, err = stringScim(data, "displayName"); err != nil {
+		return masuk, err
+	}
+	if masuk.Kode == "" {
+		return masuk, errorNilaiScim("displayName is required")
+	}
+	if _, nilai, ada := ambilAttrScim(data, "members"); ada && nilai != nil {
+		if masuk.Members, err = idMultiScim(nilai, "members"); err != nil {
+			return masuk, err
+		}
+		masuk.AdaMembers = true
+	}
+
+	_, nilai, ada := ambilAttrScim(data, responses.ScimSchemaGroupExt)
+	if !ada || nilai == nil {
+		return masuk, nil
+	}
+	ext, ok := nilai.(map[string]interface{})
+	if !ok {
+		return masuk, errorNilaiScim("%s must be an object", responses.ScimSchemaGroupExt)
+	}
+	if _, nilai, ada := ambilAttrScim(ext, "modules"); ada && nilai != nil {
+		if masuk.ModulID, err = idMultiScim(nilai, "modules"); err != nil {
+			return masuk, err
+		}
+		masuk.AdaModul = true
+	}
+	if _, nilai, ada := ambilAttrScim(ext, "parent"); ada && nilai != nil {
+		daftar, ok := nilai.([]interface{})
+		if !ok {
+			return masuk, errorNilaiScim("parent must be an array of jenis_user codes")
+		}
+		masuk.Parent = []string{}
+		for _, item := range daftar {
+			kode, ok := item.(string)
+			if !ok || strings.TrimSpace(kode) == "" {
+				return masuk, errorNilaiScim("parent must be an array of jenis_user codes")
+			}
+			masuk.Parent = append(masuk.Parent, strings.TrimSpace(kode))
+		}
+		masuk.AdaParent = true
+	}
+	return masuk, nil
+}
+
+// Pastikan semua anggota adalah user yang belum dihapus
+func cekAnggotaScim(ctx context.Context, ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	ada := idDokumen(ctx, userCollection, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": nil})
+	if len(ada) != len(ids) {
+		return errorNilaiScim("one or more members do not refer to an existing user")
+	}
+	return nil
+}
+
+// Periksa modul dan parent sebelum jenis_user disimpan
+func cekBundleScim(ctx context.Context, jenisUser model.JenisUser) error {
+	if err := cekModulAda(ctx, jenisUser.ModulID); err != nil {
+		return errorNilaiScim("%s", err.Error())
+	}
+	return cekParentJenisUser(ctx, jenisUser)
+}
+
+// Baca satu jenis_user beserta representasi SCIM-nya. ID yang tidak valid dianggap tidak ditemukan.
+func scimAmbilGroup(ctx context.Context, k konteksScim, id string) (*responses.ScimGroup, model.JenisUser, error) {
+	var jenisUser model.JenisUser
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, jenisUser, errorNotFoundScim("Group", id)
+	}
+	err = JenisUserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&jenisUser)
+	if err == mongo.ErrNoDocuments {
+		return nil, jenisUser, errorNotFoundScim("Group", id)
+	}
+	if err != nil {
+		return nil, jenisUser, err
+	}
+	anggota, err := anggotaJenisUser(ctx, []string{jenisUser.Kode})
+	if err != nil {
+		return nil, jenisUser, err
+	}
+	namaModul, err := namaModulScim(ctx, []model.JenisUser{jenisUser})
+	if err != nil {
+		return nil, jenisUser, err
+	}
+	return resourceScimGroup(jenisUser, anggota[jenisUser.Kode], namaModul, k.BaseURL), jenisUser, nil
+}
+
+// Buat jenis_user dari resource SCIM Group lalu pindahkan anggotanya ke jenis_user tersebut
+func scimBuatGroup(ctx context.Context, k konteksScim, data map[string]interface{}) (*responses.ScimGroup, error) {
+	masuk, err := bacaScimGroup(data, false)
+	if err != nil {
+		return nil, err
+	}
+	count, err := JenisUserCollection.CountDocuments(ctx, bson.M{"kode": masuk.Kode})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, &errorScim{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "displayName is already registered"}
+	}
+
+	jenisUser := model.JenisUser{
+		ID:          primitive.NewObjectID(),
+		Kode:        masuk.Kode,
+		NmJenisUser: masuk.Kode,
+		ModulID:     masuk.ModulID,
+		Parent:      masuk.Parent,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := cekBundleScim(ctx, jenisUser); err != nil {
+		return nil, err
+	}
+	if err := cekAnggotaScim(ctx, masuk.Members); err != nil {
+		return nil, err
+	}
+
+	if _, err := JenisUserCollection.InsertOne(ctx, jenisUser); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, &errorScim{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "displayName is already registered"}
+		}
+		return nil, err
+	}
+	if err := syncBundleTurunan(ctx, jenisUser.Kode, k.Actor); err != nil {
+		return nil, fmt.Errorf("failed to sync jenis_user bundle: %w", err)
+	}
+	for _, userID := range masuk.Members {
+		if err := pindahkanJenisUser(ctx, userID, jenisUser, k.Actor); err != nil {
+			return nil, err
+		}
+	}
+	resource, _, err := scimAmbilGroup(ctx, k, jenisUser.ID.Hex())
+	return resource, err
+}
+
+// Simpan perubahan group. displayName adalah kode jenis_user dan tidak bisa diganti.
+// Anggota yang dikeluarkan dipindahkan ke jenis_user bawaan karena setiap user harus
+// memiliki tepat satu jenis_user.
+func scimSimpanGroup(ctx context.Context, k konteksScim, lama model.JenisUser, masuk masukanScimGroup) (*responses.ScimGroup, error) {
+	if masuk.Kode != lama.Kode {
+		return nil, &errorScim{Status: http.StatusBadRequest, ScimType: "mutability", Detail: "displayName is the jenis_user code and cannot be changed"}
+	}
+
+	baru := lama
+	if masuk.AdaModul {
+		baru.ModulID = masuk.ModulID
+	}
+	if masuk.AdaParent {
+		baru.Parent = masuk.Parent
+	}
+	bundleBerubah := masuk.AdaModul || masuk.AdaParent
+	if bundleBerubah {
+		if err := cekBundleScim(ctx, baru); err != nil {
+			return nil, err
+		}
+	}
+
+	// Hitung dan periksa perubahan anggota sebelum ada yang ditulis
+	masukIDs, keluarIDs := []primitive.ObjectID{}, []primitive.ObjectID{}
+	if masuk.AdaMembers {
+		anggota, err := anggotaJenisUser(ctx, []string{lama.Kode})
+		if err != nil {
+			return nil, err
+		}
+		sekarang := map[primitive.ObjectID]bool{}
+		for _, user := range anggota[lama.Kode] {
+			sekarang[user.ID] = true
+		}
+		diminta := map[primitive.ObjectID]bool{}
+		for _, userID := range masuk.Members {
+			if !diminta[userID] && !sekarang[userID] {
+				masukIDs = append(masukIDs, userID)
+			}
+			diminta[userID] = true
+		}
+		for _, user := range anggota[lama.Kode] {
+			if !diminta[user.ID] {
+				keluarIDs = append(keluarIDs, user.ID)
+			}
+		}
+		if err := cekAnggotaScim(ctx, masukIDs); err != nil {
+			return nil, err
+		}
+		if len(keluarIDs) > 0 && lama.Kode == DefaultJenisUser {
+			return nil, &errorScim{Status: http.StatusBadRequest, ScimType: "mutability", Detail: "members cannot be removed from the default group, add them to another group instead"}
+		}
+	}
+
+	if bundleBerubah || len(masukIDs) > 0 || len(keluarIDs) > 0 {
+		baru.UpdatedAt = time.Now()
+		_, err := JenisUserCollection.UpdateOne(ctx, bson.M{"_id": lama.ID}, bson.M{"$set": bson.M{
+			"modul_id":   baru.ModulID,
+			"parent":     baru.Parent,
+			"updated_at": baru.UpdatedAt,
+		}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if bundleBerubah {
+		if err := syncBundleTurunan(ctx, lama.Kode, k.Actor); err != nil {
+			return nil, fmt.Errorf("failed to sync jenis_user bundle: %w", err)
+		}
+	}
+
+	for _, userID := range masukIDs {
+		if err := pindahkanJenisUser(ctx, userID, baru, k.Actor); err != nil {
+			return nil, err
+		}
+	}
+	if len(keluarIDs) > 0 {
+		bawaan, err := cariJenisUser(ctx, DefaultJenisUser)
+		if err != nil {
+			return nil, err
+		}
+		for _, userID := range keluarIDs {
+			if err := pindahkanJenisUser(ctx, userID, bawaan, k.Actor); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	resource, _, err := scimAmbilGroup(ctx, k, lama.ID.Hex())
+	return resource, err
+}
+
+// PUT: anggota diganti dengan isi members, modules dan parent hanya diganti jika dikirim
+func scimGantiGroup(ctx context.Context, k konteksScim, id string, data map[string]interface{}) (*responses.ScimGroup, error) {
+	_, lama, err := scimAmbilGroup(ctx, k, id)
+	if err != nil {
+		return nil, err
+	}
+	masuk, err := bacaScimGroup(data, false)
+	if err != nil {
+		return nil, err
+	}
+	if !masuk.AdaMembers {
+		masuk.AdaMembers, masuk.Members = true, []primitive.ObjectID{}
+	}
+	return scimSimpanGroup(ctx, k, lama, masuk)
+}
+
+// PATCH diterapkan ke representasi SCIM group, misalnya add/remove members
+func scimPatchGroup(ctx context.Context, k konteksScim, id string, patch requestPatchScim) (*responses.ScimGroup, error) {
+	resource, lama, err := scimAmbilGroup(ctx, k, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := mapScim(resource)
+	if err != nil {
+		return nil, err
+	}
+	if err := terapkanPatchScim(data, patch.Operations, multiScimGroup); err != nil {
+		return nil, err
+	}
+	masuk, err := bacaScimGroup(data, true)
+	if err != nil {
+		return nil, err
+	}
+	return scimSimpanGroup(ctx, k, lama, masuk)
+}
+
+// DELETE memakai aturan yang sama dengan DeleteJenisUser: group harus sudah kosong
+func scimHapusGroup(ctx context.Context, k konteksScim, id string) error {
+	_, lama, err := scimAmbilGroup(ctx, k, id)
+	if err != nil {
+		return err
+	}
+	return hapusJenisUser(ctx, lama.Kode, k.Actor)
+}
+
+// ScimGetGroups - Query group dengan filter, sortBy dan paginasi (GET /Groups dan POST /Groups/.search)
+func ScimGetGroups(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q, err := parseQueryScim(c)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	atribut := atributScimGroup(ctx)
+	filter := bson.M{}
+	if q.Filter != nil {
+		if filter, err = bsonFilterScim(q.Filter, atribut); err != nil {
+			return kirimErrorScim(c, err)
+		}
+	}
+	sort, err := sortScim(q, atribut, "created_at")
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+
+	total, err := JenisUserCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	daftar := []model.JenisUser{}
+	if q.Count > 0 {
+		cursor, err := JenisUserCollection.Find(ctx, filter, options.Find().SetSort(sort).SetSkip(q.StartIndex-1).SetLimit(int64(q.Count)))
+		if err != nil {
+			return kirimErrorScim(c, err)
+		}
+		if err := cursor.All(ctx, &daftar); err != nil {
+			return kirimErrorScim(c, err)
+		}
+	}
+
+	// Anggota hanya dibaca jika diminta, klien biasanya memakai excludedAttributes=members
+	anggota := map[string][]model.User{}
+	if q.dikirim("members") {
+		kode := make([]string, 0, len(daftar))
+		for _, jenisUser := range daftar {
+			kode = append(kode, jenisUser.Kode)
+		}
+		if anggota, err = anggotaJenisUser(ctx, kode); err != nil {
+			return kirimErrorScim(c, err)
+		}
+	}
+	namaModul, err := namaModulScim(ctx, daftar)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+
+	k := konteksDari(c)
+	resources := make([]interface{}, 0, len(daftar))
+	for _, jenisUser := range daftar {
+		resources = append(resources, resourceScimGroup(jenisUser, anggota[jenisUser.Kode], namaModul, k.BaseURL))
+	}
+	hasil, err := listResponseScim(q, total, resources)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimScim(c, http.StatusOK, hasil)
+}
+
+// ScimGetGroup - Satu group beserta anggotanya
+func ScimGetGroup(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resource, _, err := scimAmbilGroup(ctx, konteksDari(c), c.Params("id"))
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimResourceScim(c, http.StatusOK, resource)
+}
+
+// ScimCreateGroup - Buat jenis_user baru
+func ScimCreateGroup(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var data map[string]interface{}
+	if err := bacaBodyScim(c.Body(), &data); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	resource, err := scimBuatGroup(ctx, konteksDari(c), data)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimResourceScim(c, http.StatusCreated, resource)
+}
+
+// ScimReplaceGroup - Ganti anggota dan bundle group (PUT)
+func ScimReplaceGroup(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var data map[string]interface{}
+	if err := bacaBodyScim(c.Body(), &data); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	resource, err := scimGantiGroup(ctx, konteksDari(c), c.Params("id"), data)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimResourceScim(c, http.StatusOK, resource)
+}
+
+// ScimPatchGroup - Ubah sebagian atribut group dengan operasi PatchOp
+func ScimPatchGroup(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var patch requestPatchScim
+	if err := bacaBodyScim(c.Body(), &patch); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	resource, err := scimPatchGroup(ctx, konteksDari(c), c.Params("id"), patch)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimResourceScim(c, http.StatusOK, resource)
+}
+
+// ScimDeleteGroup - Hapus jenis_user yang sudah tidak memiliki anggota, dibalas 204
+func ScimDeleteGroup(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := scimHapusGroup(ctx, konteksDari(c), c.Params("id")); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Satu operasi di body PATCH SCIM (RFC 7644 bagian 3.5.2)
+type operasiPatchScim struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+type requestPatchScim struct {
+	Schemas    []string           `json:"schemas"`
+	Operations []operasiPatchScim `json:"Operations"`
+}
+
+func errorPatchScim(scimType string, format string, args ...interface{}) *errorScim {
+	return &errorScim{Status: http.StatusBadRequest, ScimType: scimType, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Terapkan operasi PATCH ke representasi resource dalam bentuk map. multi berisi nama atribut
+// multi-valued (huruf kecil). Hasilnya dibaca ulang oleh pemetaan resource ke model.
+func terapkanPatchScim(resource map[string]interface{}, operasi []operasiPatchScim, multi map[string]bool) error {
+	if len(operasi) == 0 {
+		return errorPatchScim("invalidValue", "PATCH request must contain at least one operation")
+	}
+	for _, o := range operasi {
+		op := strings.ToLower(o.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return errorPatchScim("invalidSyntax", "unsupported PATCH operation %q", o.Op)
+		}
+
+		if o.Path == "" {
+			if op == "remove" {
+				return errorPatchScim("noTarget", "remove operation requires a path")
+			}
+			// Tanpa path, value berisi atribut yang ditambahkan atau diganti
+			nilai, ok := o.Value.(map[string]interface{})
+			if !ok {
+				return errorPatchScim("invalidValue", "value must be an object when path is omitted")
+			}
+			for kunci, isi := range nilai {
+				path, err := parsePathScim(kunci)
+				if err != nil {
+					return err
+				}
+				if err := terapkanOperasiScim(resource, op, path, isi, multi); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		path, err := parsePathScim(o.Path)
+		if err != nil {
+			return err
+		}
+		if err := terapkanOperasiScim(resource, op, path, o.Value, multi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set atribut tanpa membedakan huruf besar kecil nama atributnya
+func setAttrScim(objek map[string]interface{}, nama string, nilai interface{}) {
+	kunci, _, _ := ambilAttrScim(objek, nama)
+	objek[kunci] = nilai
+}
+
+func hapusAttrScim(objek map[string]interface{}, nama string) {
+	if kunci, _, ada := ambilAttrScim(objek, nama); ada {
+		delete(objek, kunci)
+	}
+}
+
+func terapkanOperasiScim(resource map[string]interface{}, op string, path pathScim, nilai interface{}, multi map[string]bool) error {
+	wadah := resource
+	if path.Ext != "" {
+		_, ext, _ := ambilAttrScim(resource, path.Ext)
+		objek, ok := ext.(map[string]interface{})
+		if !ok {
+			objek = map[string]interface{}{}
+			setAttrScim(resource, path.Ext, objek)
+		}
+		wadah = objek
+	}
+	kunci, lama, ada := ambilAttrScim(wadah, path.Attr)
+	isMulti := multi[path.Attr]
+	daftar, _ := lama.([]interface{})
+
+	if op == "remove" {
+		switch {
+		case path.Filter == nil && path.Sub == "":
+			// Hapus sebagian nilai multi-valued, misalnya {"path":"members","value":[{"value":"id"}]}
+			if hapus, ok := nilai.([]interface{}); ok && isMulti {
+				sisa := []interface{}{}
+				for _, item := range daftar {
+					if !mengandungNilaiScim(hapus, item) {
+						sisa = append(sisa, item)
+					}
+				}
+				simpanDaftarScim(wadah, kunci, sisa)
+				return nil
+			}
+			delete(wadah, kunci)
+		case path.Filter == nil:
+			if objek, ok := lama.(map[string]interface{}); ok {
+				hapusAttrScim(objek, path.Sub)
+			}
+			for _, item := range daftar {
+				if objek, ok := item.(map[string]interface{}); ok {
+					hapusAttrScim(objek, path.Sub)
+				}
+			}
+		default:
+			sisa := []interface{}{}
+			for _, item := range daftar {
+				objek, ok := item.(map[string]interface{})
+				if !ok || !cocokFilterScim(path.Filter, objek) {
+					sisa = append(sisa, item)
+					continue
+				}
+				if path.Sub != "" {
+					hapusAttrScim(objek, path.Sub)
+					sisa = append(sisa, objek)
+				}
+			}
+			simpanDaftarScim(wadah, kunci, sisa)
+		}
+		return nil
+	}
+
+	// add dan replace
+	if path.Filter != nil {
+		if !isMulti {
+			return errorPatchScim("invalidFilter", "value filter can only be used on multi-valued attribute %q", path.Attr)
+		}
+		cocok := 0
+		for i, item := range daftar {
+			objek, ok := item.(map[string]interface{})
+			if !ok || !cocokFilterScim(path.Filter, objek) {
+				continue
+			}
+			cocok++
+			if path.Sub != "" {
+				setAttrScim(objek, path.Sub, nilai)
+				continue
+			}
+			baru, ok := nilai.(map[string]interface{})
+			if !ok {
+				return errorPatchScim("invalidValue", "value for %q must be an object", path.Attr)
+			}
+			if op == "add" {
+				for k, v := range baru {
+					setAttrScim(objek, k, v)
+				}
+			} else {
+				daftar[i] = baru
+			}
+		}
+		if cocok > 0 {
+			simpanDaftarScim(wadah, kunci, daftar)
+			return nil
+		}
+		// Tidak ada nilai yang cocok: filter berupa kesamaan sederhana dipakai sebagai nilai baru,
+		// misalnya emails[type eq "work"].value pada user yang belum punya email work
+		item, ok := objekDariFilterScim(path.Filter)
+		if !ok {
+			return errorPatchScim("noTarget", "no value of %q matches the filter", path.Attr)
+		}
+		if path.Sub != "" {
+			setAttrScim(item, path.Sub, nilai)
+		} else if baru, ok := nilai.(map[string]interface{}); ok {
+			for k, v := range baru {
+				setAttrScim(item, k, v)
+			}
+		}
+		simpanDaftarScim(wadah, kunci, append(daftar, item))
+		return nil
+	}
+
+	if path.Sub != "" {
+		if isMulti {
+			if len(daftar) == 0 {
+				daftar = []interface{}{map[string]interface{}{}}
+			}
+			for _, item := range daftar {
+				if objek, ok := item.(map[string]interface{}); ok {
+					setAttrScim(objek, path.Sub, nilai)
+				}
+			}
+			simpanDaftarScim(wadah, kunci, daftar)
+			return nil
+		}
+		objek, ok := lama.(map[string]interface{})
+		if !ok {
+			objek = map[string]interface{}{}
+		}
+		setAttrScim(objek, path.Sub, nilai)
+		wadah[kunci] = objek
+		return nil
+	}
+
+	if isMulti {
+		baru, ok := nilai.([]interface{})
+		if !ok {
+			baru = []interface{}{nilai}
+		}
+		if op == "replace" {
+			simpanDaftarScim(wadah, kunci, baru)
+			return nil
+		}
+		// add menambahkan nilai yang belum ada, nilai primary baru menggantikan primary lama
+		for _, item := range baru {
+			if mengandungNilaiScim(daftar, item) {
+				continue
+			}
+			if objek, ok := item.(map[string]interface{}); ok && objek["primary"] == true {
+				for _, lamaItem := range daftar {
+					if lamaObjek, ok := lamaItem.(map[string]interface{}); ok {
+						delete(lamaObjek, "primary")
+					}
+				}
+			}
+			daftar = append(daftar, item)
+		}
+		simpanDaftarScim(wadah, kunci, daftar)
+		return nil
+	}
+
+	// Atribut kompleks digabung per sub atribut, atribut biasa diganti
+	lamaObjek, lamaKompleks := lama.(map[string]interface{})
+	baruObjek, baruKompleks := nilai.(map[string]interface{})
+	if ada && lamaKompleks && baruKompleks {
+		for k, v := range baruObjek {
+			setAttrScim(lamaObjek, k, v)
+		}
+		return nil
+	}
+	wadah[kunci] = nilai
+	return nil
+}
+
+// Daftar kosong berarti atribut tidak lagi memiliki nilai
+func simpanDaftarScim(wadah map[string]interface{}, kunci string, daftar []interface{}) {
+	if len(daftar) == 0 {
+		delete(wadah, kunci)
+		return
+	}
+	wadah[kunci] = daftar
+}
+
+// Nilai multi-valued dianggap sama jika sub atribut value-nya sama
+func mengandungNilaiScim(daftar []interface{}, item interface{}) bool {
+	kunciItem := nilaiUtamaScim(item)
+	for _, lama := range daftar {
+		if nilaiUtamaScim(lama) == kunciItem {
+			return true
+		}
+	}
+	return false
+}
+
+func nilaiUtamaScim(item interface{}) string {
+	if objek, ok := item.(map[string]interface{}); ok {
+		_, nilai, _ := ambilAttrScim(objek, "value")
+		return fmt.Sprint(nilai)
+	}
+	return fmt.Sprint(item)
+}
+
+// Objek baru dari filter berupa satu atau beberapa kesamaan yang digabung and
+func objekDariFilterScim(f *filterScim) (map[string]interface{}, bool) {
+	switch f.Op {
+	case "eq":
+		if strings.Contains(f.Attr, ".") {
+			return nil, false
+		}
+		return map[string]interface{}{f.Attr: f.Nilai}, true
+	case "and":
+		kiri, ok := objekDariFilterScim(f.Kiri)
+		if !ok {
+			return nil, false
+		}
+		kanan, ok := objekDariFilterScim(f.Kanan)
+		if !ok {
+			return nil, false
+		}
+		for k, v := range kanan {
+			kiri[k] = v
+		}
+		return kiri, true
+	}
+	return nil, false
+}
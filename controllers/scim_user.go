@@ -0,0 +1,792 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/model"
+	"demoapp/responses"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Atribut multi-valued pada SCIM User, dipakai saat menerapkan PATCH
+var multiScimUser = map[string]bool{"emails": true, "phonenumbers": true, "photos": true, "roles": true, "groups": true}
+
+// Role user baru dari SCIM jika klien tidak mengirim roles, sama dengan register
+const roleScimDefault = "user"
+
+// Role dengan akses /admin, tidak pernah bisa diberikan atau dikelola lewat SCIM
+const roleAdmin = "admin"
+
+// Role yang boleh dikirim klien SCIM dari env SCIM_ROLES (dipisah koma), bawaan hanya roleScimDefault.
+// Role admin selalu ditolak walaupun tercantum.
+func roleScimDiizinkan(role string) bool {
+	if role == roleAdmin {
+		return false
+	}
+	daftar := os.Getenv("SCIM_ROLES")
+	if strings.TrimSpace(daftar) == "" {
+		return role == roleScimDefault
+	}
+	for _, item := range strings.Split(daftar, ",") {
+		if strings.TrimSpace(item) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// User dengan role di luar daftar SCIM_ROLES (misalnya admin) tidak bisa diubah atau dihapus klien SCIM
+func cekUserDikelolaScim(user model.User) error {
+	if user.Role != "" && !roleScimDiizinkan(user.Role) {
+		return &errorScim{Status: http.StatusForbidden, Detail: fmt.Sprintf("users with role %q cannot be managed via SCIM", user.Role)}
+	}
+	return nil
+}
+
+// Status yang dianggap active=true, user lama tanpa status dianggap active
+var filterAktifScim = bson.M{"status": bson.M{"$in": bson.A{model.StatusActive, model.StatusGraduated, "", nil}}}
+
+// Pemetaan atribut SCIM User ke field koleksi users untuk filter dan sortBy
+func atributScimUser(ctx context.Context) map[string]atributScim {
+	ext := strings.ToLower(responses.ScimSchemaUserExt) + ":"
+	return map[string]atributScim{
+		"id":                 {Field: "_id", Tipe: tipeScimID},
+		"externalid":         {Field: "external_id", Tipe: tipeScimStringExact},
+		"username":           {Field: "username", Tipe: tipeScimString},
+		"displayname":        {Field: "nm_user", Tipe: tipeScimString},
+		"name.formatted":     {Field: "nm_user", Tipe: tipeScimString},
+		"emails":             {Field: "email", Tipe: tipeScimString},
+		"emails.value":       {Field: "email", Tipe: tipeScimString},
+		"emails.type":        {Tipe: tipeScimKonstanta, Konstanta: "work"},
+		"emails.primary":     {Tipe: tipeScimKonstanta, Konstanta: true},
+		"phonenumbers":       {Field: "phone", Tipe: tipeScimString},
+		"phonenumbers.value": {Field: "phone", Tipe: tipeScimString},
+		"usertype":           {Field: "jenis_user", Tipe: tipeScimString},
+		"groups.display":     {Field: "jenis_user", Tipe: tipeScimString},
+		"roles":              {Field: "role", Tipe: tipeScimString},
+		"roles.value":        {Field: "role", Tipe: tipeScimString},
+		"meta.created":       {Field: "created_at", Tipe: tipeScimWaktu},
+		ext + "jeniskelamin": {Field: "jenis_kelamin", Tipe: tipeScimAngka},
+		ext + "status":       {Field: "status", Tipe: tipeScimString},
+		"active":             {Khusus: filterActiveScim},
+		"groups":             {Khusus: filterGroupUserScim(ctx)},
+		"groups.value":       {Khusus: filterGroupUserScim(ctx)},
+	}
+}
+
+func filterActiveScim(op string, nilai interface{}) (bson.M, error) {
+	if op == "pr" {
+		return bson.M{}, nil
+	}
+	target, ok := nilai.(bool)
+	if !ok || (op != "eq" && op != "ne") {
+		return nil, errorFilterScim("active can only be compared with eq or ne against true or false")
+	}
+	if target == (op == "eq") {
+		return filterAktifScim, nil
+	}
+	return bson.M{"$nor": bson.A{filterAktifScim}}, nil
+}
+
+// groups.value berisi ID jenis_user, diterjemahkan ke kode jenis_user milik user
+func filterGroupUserScim(ctx context.Context) func(op string, nilai interface{}) (bson.M, error) {
+	return func(op string, nilai interface{}) (bson.M, error) {
+		if op == "pr" {
+			return bson.M{}, nil
+		}
+		if op != "eq" {
+			return nil, errorFilterScim("groups can only be filtered with eq")
+		}
+		teks, _ := nilai.(string)
+		id, err := primitive.ObjectIDFromHex(teks)
+		if err != nil {
+			return filterKosongScim, nil
+		}
+		var jenisUser model.JenisUser
+		err = JenisUserCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&jenisUser)
+		if err == mongo.ErrNoDocuments {
+			return filterKosongScim, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"jenis_user": jenisUser.Kode}, nil
+	}
+}
+
+// Resource SCIM User dari model.User. katalog dipakai untuk ID group (jenis_user).
+func resourceScimUser(user model.User, katalog map[string]model.JenisUser, lastModified time.Time, base string) *responses.ScimUser {
+	created := user.CreatedAt.Time()
+	if lastModified.IsZero() {
+		lastModified = created
+	}
+	resource := &responses.ScimUser{
+		Schemas:     []string{responses.ScimSchemaUser, responses.ScimSchemaUserExt},
+		ID:          user.ID.Hex(),
+		ExternalID:  user.ExternalID,
+		UserName:    user.Username,
+		Name:        responses.ScimName{Formatted: user.NmUser},
+		DisplayName: user.NmUser,
+		UserType:    user.JenisUser,
+		Active:      model.StatusBolehLogin(user.StatusAkun()),
+		Emails:      []responses.ScimMultiValue{},
+		Ext:         responses.ScimUserExt{JenisKelamin: user.JenisKelamin, Status: user.StatusAkun()},
+		Meta: responses.ScimMeta{
+			ResourceType: "User",
+			Created:      waktuScim(created),
+			LastModified: waktuScim(lastModified),
+			Version:      etagVersi(user.Version),
+			Location:     base + "/Users/" + user.ID.Hex(),
+		},
+	}
+	if user.Email != "" {
+		resource.Emails = append(resource.Emails, responses.ScimMultiValue{Value: user.Email, Type: "work", Primary: true})
+	}
+	if user.Phone != "" {
+		resource.PhoneNumbers = []responses.ScimMultiValue{{Value: user.Phone, Type: "work", Primary: true}}
+	}
+	if user.Photo != "" {
+		resource.Photos = []responses.ScimMultiValue{{Value: user.Photo, Type: "photo", Primary: true}}
+	}
+	if user.Role != "" {
+		resource.Roles = []responses.ScimMultiValue{{Value: user.Role, Primary: true}}
+	}
+	if jenisUser, ok := katalog[user.JenisUser]; ok {
+		resource.Groups = []responses.ScimMultiValue{{
+			Value:   jenisUser.ID.Hex(),
+			Display: jenisUser.Kode,
+			Type:    "direct",
+			Ref:     base + "/Groups/" + jenisUser.ID.Hex(),
+		}}
+	}
+	return resource
+}
+
+// Atribut user dari body SCIM. Field pointer bernilai nil jika atributnya tidak dikirim
+// dan nilai lama dipertahankan, field lain selalu diganti.
+type masukanScimUser struct {
+	Username     string
+	NmUser       string
+	Email        string
+	ExternalID   string
+	Phone        string
+	Photo        string
+	Role         *string
+	JenisUser    *string
+	Active       *bool
+	Password     *string
+	JenisKelamin *int
+}
+
+func errorNilaiScim(format string, args ...interface{}) *errorScim {
+	return &errorScim{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: fmt.Sprintf(format, args...)}
+}
+
+// Atribut string opsional, nilai selain string ditolak
+func stringScim(objek map[string]interface{}, nama string) (string, error) {
+	_, nilai, ada := ambilAttrScim(objek, nama)
+	if !ada || nilai == nil {
+		return "", nil
+	}
+	teks, ok := nilai.(string)
+	if !ok {
+		return "", errorNilaiScim("%s must be a string", nama)
+	}
+	return strings.TrimSpace(teks), nil
+}
+
+// value dari nilai primary (atau nilai pertama) atribut multi-valued seperti emails
+func nilaiPrimaryScim(objek map[string]interface{}, nama string) (string, bool, error) {
+	_, nilai, ada := ambilAttrScim(objek, nama)
+	if !ada || nilai == nil {
+		return "", false, nil
+	}
+	daftar, ok := nilai.([]interface{})
+	if !ok {
+		return "", false, errorNilaiScim("%s must be an array", nama)
+	}
+	var pilihan map[string]interface{}
+	for _, item := range daftar {
+		objekItem, ok := item.(map[string]interface{})
+		if !ok {
+			return "", false, errorNilaiScim("each value of %s must be an object", nama)
+		}
+		_, primary, _ := ambilAttrScim(objekItem, "primary")
+		if pilihan == nil || primary == true {
+			pilihan = objekItem
+		}
+	}
+	if pilihan == nil {
+		return "", true, nil
+	}
+	teks, err := stringScim(pilihan, "value")
+	return teks, true, err
+}
+
+// Sebagian klien mengirim boolean sebagai string, misalnya "False"
+func boolScim(nilai interface{}) (bool, bool) {
+	switch v := nilai.(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// Baca atribut user dari body SCIM. nmLama adalah nama user sebelum diubah: name.formatted,
+// givenName+familyName, displayName dan userName dicoba berurutan dan nama pertama yang
+// berbeda dari nmLama dipakai, sehingga PATCH pada salah satunya tetap terbaca.
+func bacaScimUser(data map[string]interface{}, nmLama string) (masukanScimUser, error) {
+	var masuk masukanScimUser
+	var err error
+
+	if masuk.Username, err = stringScim(data, "userName"); err != nil {
+		return masuk, err
+	}
+	if masuk.Username == "" {
+		return masuk, errorNilaiScim("userName is required")
+	}
+	if masuk.ExternalID, err = stringScim(data, "externalId"); err != nil {
+		return masuk, err
+	}
+
+	kandidat := []string{}
+	if _, nama, ada := ambilAttrScim(data, "name"); ada && nama != nil {
+		objek, ok := nama.(map[string]interface{})
+		if !ok {
+			return masuk, errorNilaiScim("name must be an object")
+		}
+		formatted, err := stringScim(objek, "formatted")
+		if err != nil {
+			return masuk, err
+		}
+		given, err := stringScim(objek, "givenName")
+		if err != nil {
+			return masuk, err
+		}
+		family, err := stringScim(objek, "familyName")
+		if err != nil {
+			return masuk, err
+		}
+		kandidat = append(kandidat, formatted, strings.TrimSpace(given+" "+family))
+	}
+	displayName, err := stringScim(data, "displayName")
+	if err != nil {
+		return masuk, err
+	}
+	kandidat = append(kandidat, displayName, masuk.Username)
+	for _, nama := range kandidat {
+		if nama == "" {
+			continue
+		}
+		if masuk.NmUser == "" {
+			masuk.NmUser = nama
+		}
+		if nama != nmLama {
+			masuk.NmUser = nama
+			break
+		}
+	}
+
+	if masuk.Email, _, err = nilaiPrimaryScim(data, "emails"); err != nil {
+		return masuk, err
+	}
+	if masuk.Email == "" {
+		return masuk, errorNilaiScim("emails must contain at least one value")
+	}
+	if err := validate.Var(masuk.Email, "email"); err != nil {
+		return masuk, errorNilaiScim("%q is not a valid email address", masuk.Email)
+	}
+	if masuk.Phone, _, err = nilaiPrimaryScim(data, "phoneNumbers"); err != nil {
+		return masuk, err
+	}
+	if masuk.Photo, _, err = nilaiPrimaryScim(data, "photos"); err != nil {
+		return masuk, err
+	}
+	if role, ada, err := nilaiPrimaryScim(data, "roles"); err != nil {
+		return masuk, err
+	} else if ada && role != "" {
+		if !roleScimDiizinkan(role) {
+			return masuk, errorNilaiScim("role %q cannot be assigned via SCIM", role)
+		}
+		masuk.Role = &role
+	}
+
+	if _, nilai, ada := ambilAttrScim(data, "userType"); ada && nilai != nil {
+		jenisUser, ok := nilai.(string)
+		if !ok {
+			return masuk, errorNilaiScim("userType must be a string")
+		}
+		masuk.JenisUser = &jenisUser
+	}
+	if _, nilai, ada := ambilAttrScim(data, "active"); ada && nilai != nil {
+		aktif, ok := boolScim(nilai)
+		if !ok {
+			return masuk, errorNilaiScim("active must be a boolean")
+		}
+		masuk.Active = &aktif
+	}
+	if _, nilai, ada := ambilAttrScim(data, "password"); ada && nilai != nil {
+		password, ok := nilai.(string)
+		if !ok || password == "" {
+			return masuk, errorNilaiScim("password must be a non-empty string")
+		}
+		masuk.Password = &password
+	}
+
+	if _, nilai, ada := ambilAttrScim(data, responses.ScimSchemaUserExt); ada && nilai != nil {
+		ext, ok := nilai.(map[string]interface{})
+		if !ok {
+			return masuk, errorNilaiScim("%s must be an object", responses.ScimSchemaUserExt)
+		}
+		if _, nilai, ada := ambilAttrScim(ext, "jenisKelamin"); ada && nilai != nil {
+			angka, ok := nilai.(float64)
+			if !ok || (angka != 1 && angka != 2) {
+				return masuk, errorNilaiScim("jenisKelamin must be 1 or 2")
+			}
+			jenisKelamin := int(angka)
+			masuk.JenisKelamin = &jenisKelamin
+		}
+	}
+	return masuk, nil
+}
+
+// Username harus unik di antara semua user, termasuk yang sudah dihapus
+func cekUsernameScim(ctx context.Context, username string, kecuali primitive.ObjectID) error {
+	count, err := userCollection.CountDocuments(ctx, bson.M{"username": username, "_id": bson.M{"$ne": kecuali}})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return &errorScim{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "userName is already taken"}
+	}
+	return nil
+}
+
+func errorDuplikatScim(err error) error {
+	if field := fieldDuplikatUser(err); field != "" {
+		return &errorScim{Status: http.StatusConflict, ScimType: "uniqueness", Detail: pesanDuplikatUser(field)}
+	}
+	return err
+}
+
+// Baca satu user beserta representasi SCIM-nya. ID yang tidak valid dianggap tidak ditemukan.
+func scimAmbilUser(ctx context.Context, k konteksScim, id string) (*responses.ScimUser, model.User, error) {
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, model.User{}, errorNotFoundScim("User", id)
+	}
+	user, err := cariUserByID(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return nil, user, errorNotFoundScim("User", id)
+	}
+	if err != nil {
+		return nil, user, err
+	}
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		return nil, user, err
+	}
+	waktu, err := waktuUbahScim(ctx, userCollection.Name(), []primitive.ObjectID{user.ID})
+	if err != nil {
+		return nil, user, err
+	}
+	return resourceScimUser(user, katalog, waktu[user.ID], k.BaseURL), user, nil
+}
+
+// Buat user dari resource SCIM. Tanpa userType user masuk ke jenis_user bawaan,
+// tanpa password dibuatkan password acak karena login memakai SSO sistem sumber.
+func scimBuatUser(ctx context.Context, k konteksScim, data map[string]interface{}) (*responses.ScimUser, error) {
+	masuk, err := bacaScimUser(data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	kodeJenis := DefaultJenisUser
+	if masuk.JenisUser != nil {
+		kodeJenis = *masuk.JenisUser
+	}
+	jenisUser, err := cariJenisUser(ctx, kodeJenis)
+	if err != nil {
+		return nil, err
+	}
+	if err := cekUsernameScim(ctx, masuk.Username, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+
+	password := ""
+	if masuk.Password != nil {
+		password = *masuk.Password
+	} else if password, err = buatPasswordAwal(); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := model.User{
+		ID:         primitive.NewObjectID(),
+		Username:   masuk.Username,
+		NmUser:     masuk.NmUser,
+		Password:   string(hashedPassword),
+		Email:      masuk.Email,
+		Role:       roleScimDefault,
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+		Photo:      masuk.Photo,
+		Phone:      masuk.Phone,
+		JenisUser:  jenisUser.Kode,
+		Status:     model.StatusActive,
+		ExternalID: masuk.ExternalID,
+		Version:    1,
+	}
+	if masuk.Role != nil {
+		user.Role = *masuk.Role
+	}
+	if masuk.JenisKelamin != nil {
+		user.JenisKelamin = *masuk.JenisKelamin
+	}
+	if masuk.Active != nil && !*masuk.Active {
+		user.Status = model.StatusPending
+	}
+	user.SearchNgram = ngramUser(user)
+
+	if _, err := userCollection.InsertOne(ctx, user); err != nil {
+		return nil, errorDuplikatScim(err)
+	}
+	catatHistori(ctx, userCollection, AksiCreate, k.Actor, user.ID)
+
+	if err := masukkanKeBundle(ctx, user.ID, jenisUser, k.Actor); err != nil {
+		return nil, fmt.Errorf("failed to add user to jenis_user bundle: %w", err)
+	}
+	resource, _, err := scimAmbilUser(ctx, k, user.ID.Hex())
+	return resource, err
+}
+
+// Simpan atribut SCIM ke user yang sudah ada. Perubahan userType lewat pindahkanJenisUser
+// dan perubahan active lewat ubahStatusUser agar bundle modul dan riwayat status ikut tercatat.
+func scimSimpanUser(ctx context.Context, k konteksScim, lama model.User, masuk masukanScimUser, ifMatch string) (*responses.ScimUser, error) {
+	if !cocokEtag(ifMatch, lama.Version) {
+		return nil, errorVersiScim()
+	}
+
+	var jenisUser *model.JenisUser
+	if masuk.JenisUser != nil && *masuk.JenisUser != lama.JenisUser {
+		baru, err := cariJenisUser(ctx, *masuk.JenisUser)
+		if err != nil {
+			return nil, err
+		}
+		jenisUser = &baru
+	}
+	if masuk.Username != lama.Username {
+		if err := cekUsernameScim(ctx, masuk.Username, lama.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	baru := lama
+	baru.Username, baru.NmUser, baru.Email, baru.Phone = masuk.Username, masuk.NmUser, masuk.Email, masuk.Phone
+	set := bson.M{"username": masuk.Username, "nm_user": masuk.NmUser, "email": masuk.Email, "phone": masuk.Phone}
+	unset := bson.M{}
+	for field, nilai := range map[string]string{"photo": masuk.Photo, "external_id": masuk.ExternalID} {
+		if nilai == "" {
+			unset[field] = ""
+		} else {
+			set[field] = nilai
+		}
+	}
+	if masuk.Role != nil {
+		set["role"] = *masuk.Role
+	}
+	if masuk.JenisKelamin != nil {
+		set["jenis_kelamin"] = *masuk.JenisKelamin
+	}
+	if masuk.Password != nil {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*masuk.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		set["pass"] = string(hashedPassword)
+	}
+	update := bson.M{"$set": set, "$inc": naikkanVersi}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": lama.ID, "deleted_at": nil, "version": syaratVersi(lama.Version)}, update)
+	if err != nil {
+		return nil, errorDuplikatScim(err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, errorVersiScim()
+	}
+	catatHistori(ctx, userCollection, AksiUpdate, k.Actor, lama.ID)
+	if err := perbaruiNgramUser(ctx, baru); err != nil {
+		fmt.Println("Error updating search ngram:", err)
+	}
+
+	if jenisUser != nil {
+		if err := pindahkanJenisUser(ctx, lama.ID, *jenisUser, k.Actor); err != nil {
+			return nil, err
+		}
+	}
+
+	if masuk.Active != nil && *masuk.Active != model.StatusBolehLogin(lama.StatusAkun()) {
+		ke := model.StatusSuspended
+		if *masuk.Active {
+			ke = model.StatusActive
+		}
+		_, err := ubahStatusUser(ctx, lama, ke, "SCIM provisioning by "+k.Actor, k.Actor)
+		if errors.Is(err, errRiwayatStatus) {
+			fmt.Println("Error recording status history:", err)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	resource, _, err := scimAmbilUser(ctx, k, lama.ID.Hex())
+	return resource, err
+}
+
+// PUT: seluruh atribut yang bisa diubah diganti dengan isi body
+func scimGantiUser(ctx context.Context, k konteksScim, id string, data map[string]interface{}, ifMatch string) (*responses.ScimUser, error) {
+	_, lama, err := scimAmbilUser(ctx, k, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := cekUserDikelolaScim(lama); err != nil {
+		return nil, err
+	}
+	masuk, err := bacaScimUser(data, lama.NmUser)
+	if err != nil {
+		return nil, err
+	}
+	return scimSimpanUser(ctx, k, lama, masuk, ifMatch)
+}
+
+// PATCH diterapkan ke representasi SCIM user, hasilnya disimpan seperti PUT
+func scimPatchUser(ctx context.Context, k konteksScim, id string, patch requestPatchScim, ifMatch string) (*responses.ScimUser, error) {
+	resource, lama, err := scimAmbilUser(ctx, k, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := cekUserDikelolaScim(lama); err != nil {
+		return nil, err
+	}
+	data, err := mapScim(resource)
+	if err != nil {
+		return nil, err
+	}
+	if err := terapkanPatchScim(data, patch.Operations, multiScimUser); err != nil {
+		return nil, err
+	}
+	masuk, err := bacaScimUser(data, lama.NmUser)
+	if err != nil {
+		return nil, err
+	}
+	return scimSimpanUser(ctx, k, lama, masuk, ifMatch)
+}
+
+// DELETE memakai soft delete yang sama dengan DeleteAUser
+func scimHapusUser(ctx context.Context, k konteksScim, id string, ifMatch string) error {
+	_, lama, err := scimAmbilUser(ctx, k, id)
+	if err != nil {
+		return err
+	}
+	if err := cekUserDikelolaScim(lama); err != nil {
+		return err
+	}
+	if !cocokEtag(ifMatch, lama.Version) {
+		return errorVersiScim()
+	}
+	result, err := userCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": lama.ID, "deleted_at": nil, "version": syaratVersi(lama.Version)},
+		bson.M{
+			"$set":   bson.M{"deleted_at": primitive.NewDateTimeFromTime(time.Now()), "deleted_by": k.Actor},
+			"$unset": bson.M{"token": ""},
+			"$inc":   naikkanVersi,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errorVersiScim()
+	}
+	catatHistori(ctx, userCollection, AksiDelete, k.Actor, lama.ID)
+	return nil
+}
+
+// Urutan hasil query dari sortBy, atribut yang tidak bisa diurutkan ditolak
+func sortScim(q queryScim, atribut map[string]atributScim, bawaan string) (bson.D, error) {
+	field := bawaan
+	if q.SortBy != "" {
+		attr, ok := atribut[normalisasiAttrScim(q.SortBy)]
+		if !ok || attr.Field == "" {
+			return nil, &errorScim{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: "sorting on attribute " + q.SortBy + " is not supported"}
+		}
+		field = attr.Field
+	}
+	arah := 1
+	if q.Descending {
+		arah = -1
+	}
+	return bson.D{{Key: field, Value: arah}, {Key: "_id", Value: arah}}, nil
+}
+
+// ScimGetUsers - Query user dengan filter, sortBy dan paginasi startIndex/count (GET /Users dan POST /Users/.search)
+func ScimGetUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q, err := parseQueryScim(c)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	atribut := atributScimUser(ctx)
+	filter := bson.M{"deleted_at": nil}
+	if q.Filter != nil {
+		hasil, err := bsonFilterScim(q.Filter, atribut)
+		if err != nil {
+			return kirimErrorScim(c, err)
+		}
+		filter = bson.M{"$and": bson.A{filter, hasil}}
+	}
+	sort, err := sortScim(q, atribut, "created_at")
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+
+	total, err := userCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	users := []model.User{}
+	if q.Count > 0 {
+		cursor, err := userCollection.Find(ctx, filter, options.Find().SetSort(sort).SetSkip(q.StartIndex-1).SetLimit(int64(q.Count)))
+		if err != nil {
+			return kirimErrorScim(c, err)
+		}
+		if err := cursor.All(ctx, &users); err != nil {
+			return kirimErrorScim(c, err)
+		}
+	}
+
+	katalog := map[string]model.JenisUser{}
+	if q.dikirim("groups") {
+		if katalog, err = loadKatalogJenisUser(ctx); err != nil {
+			return kirimErrorScim(c, err)
+		}
+	}
+	ids := make([]primitive.ObjectID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	waktu, err := waktuUbahScim(ctx, userCollection.Name(), ids)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+
+	k := konteksDari(c)
+	resources := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, resourceScimUser(user, katalog, waktu[user.ID], k.BaseURL))
+	}
+	hasil, err := listResponseScim(q, total, resources)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimScim(c, http.StatusOK, hasil)
+}
+
+// ScimGetUser - Satu user, dibalas 304 jika If-None-Match masih sama
+func ScimGetUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resource, user, err := scimAmbilUser(ctx, konteksDari(c), c.Params("id"))
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	if belumBerubah(c, user.Version) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+	return kirimResourceScim(c, http.StatusOK, resource)
+}
+
+// ScimCreateUser - Buat user baru
+func ScimCreateUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var data map[string]interface{}
+	if err := bacaBodyScim(c.Body(), &data); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	resource, err := scimBuatUser(ctx, konteksDari(c), data)
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimResourceScim(c, http.StatusCreated, resource)
+}
+
+// ScimReplaceUser - Ganti seluruh atribut user (PUT)
+func ScimReplaceUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var data map[string]interface{}
+	if err := bacaBodyScim(c.Body(), &data); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	resource, err := scimGantiUser(ctx, konteksDari(c), c.Params("id"), data, c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimResourceScim(c, http.StatusOK, resource)
+}
+
+// ScimPatchUser - Ubah sebagian atribut user dengan operasi PatchOp
+func ScimPatchUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var patch requestPatchScim
+	if err := bacaBodyScim(c.Body(), &patch); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	resource, err := scimPatchUser(ctx, konteksDari(c), c.Params("id"), patch, c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return kirimResourceScim(c, http.StatusOK, resource)
+}
+
+// ScimDeleteUser - Soft delete user, dibalas 204
+func ScimDeleteUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := scimHapusUser(ctx, konteksDari(c), c.Params("id"), c.Get(fiber.HeaderIfMatch)); err != nil {
+		return kirimErrorScim(c, err)
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var UserStatusLogCollection = config.GetCollection(config.DB, "user_status_log")
+
+var (
+	errStatusTidakDikenal = errors.New("unknown status")
+	errTransisiStatus     = errors.New("status transition is not allowed")
+	errStatusBerubah      = errors.New("user status was changed by another request")
+	errRiwayatStatus      = errors.New("status changed but history was not recorded")
+)
+
+// Request perubahan status akun
+type UbahStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+	Alasan string `json:"alasan" validate:"required"`
+}
+
+// Pindahkan status akun user sesuai TransisiStatusUser lalu catat riwayatnya.
+// Update hanya berhasil jika status di database masih sama dengan status yang dibaca.
+func ubahStatusUser(ctx context.Context, user model.User, ke string, alasan string, actor string) (model.UserStatusLog, error) {
+	dari := user.StatusAkun()
+	if _, ok := model.TransisiStatusUser[ke]; !ok {
+		return model.UserStatusLog{}, fmt.Errorf("%w: %s", errStatusTidakDikenal, ke)
+	}
+	if !model.TransisiStatusValid(dari, ke) {
+		return model.UserStatusLog{}, fmt.Errorf("%w: %s -> %s", errTransisiStatus, dari, ke)
+	}
+
+	// User lama tanpa field status tersimpan sebagai null/kosong
+	filter := bson.M{"_id": user.ID, "deleted_at": nil, "status": user.Status}
+	if user.Status == "" {
+		filter["status"] = nil
+	}
+	result, err := userCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": ke}, "$inc": naikkanVersi})
+	if err != nil {
+		return model.UserStatusLog{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.UserStatusLog{}, errStatusBerubah
+	}
+	catatHistori(ctx, userCollection, AksiStatus, actor, user.ID)
+
+	log := model.UserStatusLog{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Dari:      dari,
+		Ke:        ke,
+		Alasan:    alasan,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+	}
+	if _, err := UserStatusLogCollection.InsertOne(ctx, log); err != nil {
+		return log, fmt.Errorf("%w: %v", errRiwayatStatus, err)
+	}
+	return log, nil
+}
+
+// ChangeUserStatus - Ubah status akun user (pending, active, suspended, locked, graduated)
+func ChangeUserStatus(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	var req UbahStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.Status = strings.TrimSpace(req.Status)
+	req.Alasan = strings.TrimSpace(req.Alasan)
+	if err := validate.Struct(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user, err := cariUserByID(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user"})
+	}
+
+	log, err := ubahStatusUser(ctx, user, req.Status, req.Alasan, aktorDari(c))
+	switch {
+	case errors.Is(err, errStatusTidakDikenal):
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, errTransisiStatus):
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   err.Error(),
+			"allowed": model.TransisiStatusUser[user.StatusAkun()],
+		})
+	case errors.Is(err, errStatusBerubah):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, errRiwayatStatus):
+		// Status sudah berubah, kegagalan mencatat riwayat cukup dicatat di log
+		fmt.Println("Error recording status history:", err)
+	case err != nil:
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to change user status: " + err.Error()})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "User status changed successfully",
+		"change":  log,
+	})
+}
+
+// GetUserStatusHistory - Riwayat perubahan status akun user, terbaru lebih dulu
+func GetUserStatusHistory(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	// Riwayat tetap bisa dilihat untuk user yang sudah dihapus
+	var user model.User
+	err = userCollection.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"status": 1})).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user"})
+	}
+
+	cursor, err := UserStatusLogCollection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch status history"})
+	}
+	history := []model.UserStatusLog{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode status history"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"user_id":     userID,
+		"status":      user.StatusAkun(),
+		"allowed":     model.TransisiStatusUser[user.StatusAkun()],
+		"history":     history,
+		"total_count": len(history),
+	})
+}
@@ -0,0 +1,1245 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var SyncSumberCollection = config.GetCollection(config.DB, "sync_sumber")
+var SyncRunCollection = config.GetCollection(config.DB, "sync_run")
+
+// Status laporan sync
+const (
+	StatusSyncRunning   = "running"
+	StatusSyncCompleted = "completed"
+	StatusSyncFailed    = "failed"
+)
+
+// Aksi pada laporan sync
+const (
+	AksiSyncCreate  = "create"
+	AksiSyncUpdate  = "update"
+	AksiSyncSuspend = "suspend"
+	AksiSyncError   = "error"
+)
+
+const (
+	direktoriSync = "./storage/sync"
+	// Folder berkas feed untuk mock feed service (go run . mock-feed)
+	direktoriMockFeed = "./storage/sync/mock"
+	// Jumlah maksimum perubahan yang disimpan di laporan, hitungan tetap lengkap
+	batasPerubahanSync = 5000
+	// Ukuran maksimum feed yang diunduh dari URL
+	batasUkuranFeed = 50 << 20
+	// Jarak pemeriksaan jadwal sync
+	intervalCekSync = time.Minute
+	// Batas waktu satu kali sync
+	batasWaktuSync = time.Hour
+)
+
+// Field user yang bisa diisi dari feed
+var fieldSync = map[string]bool{
+	"external_id":   true,
+	"username":      true,
+	"nm_user":       true,
+	"email":         true,
+	"phone":         true,
+	"jenis_kelamin": true,
+	"jenis_user":    true,
+	"angkatan":      true,
+	"role":          true,
+	"status":        true,
+}
+
+// Nama kolom feed akademik/HR selain alias kolom import
+var aliasKolomSync = map[string]string{
+	"id":                 "external_id",
+	"nomor_induk":        "external_id",
+	"status_akademik":    "status",
+	"status_mahasiswa":   "status",
+	"status_kepegawaian": "status",
+	"status_pegawai":     "status",
+	"tipe":               "jenis_user",
+	"tipe_user":          "jenis_user",
+	"tahun_masuk":        "angkatan",
+	"cohort":             "angkatan",
+}
+
+// Status di feed ke status akun. Status locked hanya diatur admin sehingga tidak bisa dikirim feed.
+var aliasStatusSync = map[string]string{
+	"active":    model.StatusActive,
+	"aktif":     model.StatusActive,
+	"graduated": model.StatusGraduated,
+	"lulus":     model.StatusGraduated,
+	"alumni":    model.StatusGraduated,
+	"suspended": model.StatusSuspended,
+	"cuti":      model.StatusSuspended,
+	"leave":     model.StatusSuspended,
+	"nonaktif":  model.StatusSuspended,
+	"keluar":    model.StatusSuspended,
+	"resign":    model.StatusSuspended,
+	"do":        model.StatusSuspended,
+}
+
+var (
+	errSyncBerjalan  = errors.New("sync for this source is already running")
+	errFeedKosong    = errors.New("no feed file in the drop folder")
+	errSumberSyncDup = errors.New("sync source name is already used")
+)
+
+// Sumber yang sedang disinkronkan di proses ini, mencegah satu sumber dijalankan dua kali
+var syncBerjalan sync.Map
+
+// Satu record feed yang sudah dipetakan ke field user
+type recordSync struct {
+	Nomor int
+	Nilai map[string]string
+}
+
+// Berkas feed yang siap dibaca
+type feedSync struct {
+	Path   string
+	Format string
+	Asal   string   // Berkas atau URL sumber, dicatat di laporan
+	Drop   []string // Berkas di folder drop yang dipindahkan ke processed setelah sync berhasil
+	Hapus  bool     // Berkas sementara hasil unduhan
+}
+
+// Field user dari nama kolom atau key feed: mapping sumber, nama field, lalu alias
+func fieldFeedSync(kolom string, mapping map[string]string) (string, bool) {
+	if field, ok := mapping[kolom]; ok {
+		return field, true
+	}
+	kunci := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(kolom)), " ", "_")
+	if field, ok := mapping[kunci]; ok {
+		return field, true
+	}
+	if fieldSync[kunci] {
+		return kunci, true
+	}
+	if field, ok := aliasKolomSync[kunci]; ok {
+		return field, true
+	}
+	field, ok := aliasKolomImport[kunci]
+	return field, ok && fieldSync[field]
+}
+
+// Format feed dari ekstensi berkas
+func formatFeedDariNama(nama string) string {
+	switch strings.ToLower(filepath.Ext(nama)) {
+	case ".csv":
+		return "csv"
+	case ".xlsx":
+		return "xlsx"
+	case ".json":
+		return "json"
+	}
+	return ""
+}
+
+// Berkas feed terbaru di folder drop. Berkas yang lebih lama ikut dipindahkan ke processed
+// karena setiap feed berisi data lengkap dan sudah digantikan oleh berkas terbaru.
+func berkasDrop(folder string) (string, []string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return "", nil, err
+	}
+	type berkas struct {
+		path  string
+		waktu time.Time
+	}
+	daftar := []berkas{}
+	for _, entry := range entries {
+		if entry.IsDir() || formatFeedDariNama(entry.Name()) == "" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", nil, err
+		}
+		daftar = append(daftar, berkas{filepath.Join(folder, entry.Name()), info.ModTime()})
+	}
+	if len(daftar) == 0 {
+		return "", nil, errFeedKosong
+	}
+	sort.Slice(daftar, func(i, j int) bool { return daftar[i].waktu.Before(daftar[j].waktu) })
+	drop := make([]string, 0, len(daftar))
+	for _, b := range daftar {
+		drop = append(drop, b.path)
+	}
+	return daftar[len(daftar)-1].path, drop, nil
+}
+
+// Unduh feed URL ke berkas sementara agar bisa dibaca dengan pembaca berkas import
+func unduhFeed(ctx context.Context, sumber model.SyncSumber) (feedSync, error) {
+	feed := feedSync{Asal: sumber.Lokasi, Format: sumber.Format, Hapus: true}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sumber.Lokasi, nil)
+	if err != nil {
+		return feed, err
+	}
+	for kunci, nilai := range sumber.Header {
+		req.Header.Set(kunci, nilai)
+	}
+	resp, err := (&http.Client{Timeout: 5 * time.Minute}).Do(req)
+	if err != nil {
+		return feed, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return feed, fmt.Errorf("feed returned HTTP %d", resp.StatusCode)
+	}
+
+	if feed.Format == "" {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get(fiber.HeaderContentType))
+		switch {
+		case strings.Contains(mediaType, "json"):
+			feed.Format = "json"
+		case strings.Contains(mediaType, "csv"):
+			feed.Format = "csv"
+		case strings.Contains(mediaType, "spreadsheetml"):
+			feed.Format = "xlsx"
+		default:
+			feed.Format = formatFeedDariNama(req.URL.Path)
+		}
+	}
+	if feed.Format == "" {
+		return feed, fmt.Errorf("cannot determine feed format, set format on the source")
+	}
+
+	if err := os.MkdirAll(direktoriSync, os.ModePerm); err != nil {
+		return feed, err
+	}
+	berkas, err := os.CreateTemp(direktoriSync, "feed-*."+feed.Format)
+	if err != nil {
+		return feed, err
+	}
+	defer berkas.Close()
+	feed.Path = berkas.Name()
+	n, err := io.Copy(berkas, io.LimitReader(resp.Body, batasUkuranFeed+1))
+	if err == nil && n > batasUkuranFeed {
+		err = fmt.Errorf("feed exceeds %d bytes", batasUkuranFeed)
+	}
+	if err != nil {
+		os.Remove(feed.Path)
+	}
+	return feed, err
+}
+
+// Siapkan berkas feed dari sumber: berkas tunggal, berkas terbaru di folder drop, atau URL
+func siapkanFeed(ctx context.Context, sumber model.SyncSumber) (feedSync, error) {
+	if sumber.Jenis == "url" {
+		return unduhFeed(ctx, sumber)
+	}
+
+	feed := feedSync{Path: sumber.Lokasi, Asal: sumber.Lokasi, Format: sumber.Format}
+	info, err := os.Stat(sumber.Lokasi)
+	if err != nil {
+		return feed, err
+	}
+	if info.IsDir() {
+		if feed.Path, feed.Drop, err = berkasDrop(sumber.Lokasi); err != nil {
+			return feed, err
+		}
+		feed.Asal = feed.Path
+	}
+	if feed.Format == "" {
+		feed.Format = formatFeedDariNama(feed.Path)
+	}
+	if feed.Format == "" {
+		return feed, fmt.Errorf("unsupported feed file %s, use .csv, .xlsx or .json", filepath.Base(feed.Path))
+	}
+	return feed, nil
+}
+
+// Pindahkan berkas drop yang sudah diproses ke subfolder processed
+func arsipkanFeed(feed feedSync) {
+	for _, path := range feed.Drop {
+		folder := filepath.Join(filepath.Dir(path), "processed")
+		if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+			fmt.Println("Error creating processed feed folder:", err)
+			return
+		}
+		if err := os.Rename(path, filepath.Join(folder, time.Now().Format("20060102-150405-")+filepath.Base(path))); err != nil {
+			fmt.Println("Error archiving feed file:", err)
+		}
+	}
+}
+
+// Nilai JSON feed sebagai teks, angka tanpa desimal ditulis sebagai bilangan bulat
+func teksNilaiFeed(nilai interface{}) string {
+	switch v := nilai.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(nilai)
+}
+
+// Baca seluruh record feed. Feed JSON berupa array objek atau objek dengan array "users"/"data".
+func bacaRecordSync(feed feedSync, mapping map[string]string) ([]recordSync, error) {
+	records := []recordSync{}
+	adaKunci := false
+
+	if feed.Format == "json" {
+		data, err := os.ReadFile(feed.Path)
+		if err != nil {
+			return nil, err
+		}
+		var isi interface{}
+		if err := json.Unmarshal(data, &isi); err != nil {
+			return nil, fmt.Errorf("invalid JSON feed: %w", err)
+		}
+		if objek, ok := isi.(map[string]interface{}); ok {
+			for _, kunci := range []string{"users", "data"} {
+				if daftar, ok := objek[kunci]; ok {
+					isi = daftar
+					break
+				}
+			}
+		}
+		daftar, ok := isi.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("JSON feed must be an array of objects or an object with a users array")
+		}
+		for i, item := range daftar {
+			objek, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("record %d is not an object", i+1)
+			}
+			nilai := map[string]string{}
+			for kunci, isi := range objek {
+				if field, ok := fieldFeedSync(kunci, mapping); ok {
+					nilai[field] = teksNilaiFeed(isi)
+				}
+			}
+			adaKunci = adaKunci || nilai["username"] != "" || nilai["external_id"] != ""
+			records = append(records, recordSync{Nomor: i + 1, Nilai: nilai})
+		}
+	} else {
+		rows, err := bacaBerkasImport(feed.Path, feed.Format)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return records, nil
+		}
+		kolom := make([]string, len(rows[0]))
+		for i, header := range rows[0] {
+			if field, ok := fieldFeedSync(header, mapping); ok {
+				kolom[i] = field
+				adaKunci = adaKunci || field == "username" || field == "external_id"
+			}
+		}
+		for i, row := range rows[1:] {
+			if barisKosong(row) {
+				continue
+			}
+			nilai := map[string]string{}
+			for k, field := range kolom {
+				if field != "" && k < len(row) {
+					nilai[field] = strings.TrimSpace(row[k])
+				}
+			}
+			records = append(records, recordSync{Nomor: i + 2, Nilai: nilai})
+		}
+	}
+
+	if len(records) > 0 && !adaKunci {
+		return nil, fmt.Errorf("feed must contain a username or external_id column")
+	}
+	return records, nil
+}
+
+// Pelaksana satu kali sync. Pada dry run semua rencana perubahan dicatat tanpa menulis apa pun.
+type pelaksanaSync struct {
+	ctx     context.Context
+	sumber  model.SyncSumber
+	run     *model.SyncRun
+	katalog map[string]model.JenisUser
+	actor   string
+
+	byExternalID map[string]*model.User
+	byUsername   map[string]*model.User
+	dipakai      map[primitive.ObjectID]bool // User yang ada di feed, sisanya kandidat suspend
+}
+
+func (p *pelaksanaSync) catat(perubahan model.SyncPerubahan) {
+	switch perubahan.Aksi {
+	case AksiSyncCreate:
+		p.run.Dibuat++
+	case AksiSyncUpdate:
+		p.run.Diubah++
+	case AksiSyncSuspend:
+		p.run.Disuspend++
+	case AksiSyncError:
+		p.run.Gagal++
+	}
+	if len(p.run.Perubahan) < batasPerubahanSync {
+		p.run.Perubahan = append(p.run.Perubahan, perubahan)
+	}
+}
+
+func (p *pelaksanaSync) gagal(record recordSync, user *model.User, pesan string) {
+	perubahan := model.SyncPerubahan{
+		Aksi:       AksiSyncError,
+		Record:     record.Nomor,
+		Username:   record.Nilai["username"],
+		ExternalID: record.Nilai["external_id"],
+		Pesan:      pesan,
+	}
+	if user != nil {
+		perubahan.UserID = &user.ID
+	}
+	p.catat(perubahan)
+}
+
+func (p *pelaksanaSync) alasan() string {
+	return "sync from " + p.sumber.Nama
+}
+
+// Role dari feed harus role bawaan user atau tercantum di role_diizinkan sumber, admin selalu ditolak
+func (p *pelaksanaSync) roleDiizinkan(role string) bool {
+	if role == roleAdmin {
+		return false
+	}
+	return role == roleImportDefault || slices.Contains(p.sumber.RoleDiizinkan, role)
+}
+
+// Baca user yang mungkin cocok dengan record feed, termasuk user terhapus agar konflik
+// username sudah terlihat saat dry run
+func (p *pelaksanaSync) muatUser(records []recordSync) error {
+	externalIDs, usernames := []string{}, []string{}
+	for _, record := range records {
+		if nilai := record.Nilai["external_id"]; nilai != "" {
+			externalIDs = append(externalIDs, nilai)
+		}
+		if nilai := record.Nilai["username"]; nilai != "" {
+			usernames = append(usernames, nilai)
+		}
+	}
+	cursor, err := userCollection.Find(p.ctx, bson.M{"$or": bson.A{
+		bson.M{"external_id": bson.M{"$in": externalIDs}},
+		bson.M{"username": bson.M{"$in": usernames}},
+	}})
+	if err != nil {
+		return err
+	}
+	var users []model.User
+	if err := cursor.All(p.ctx, &users); err != nil {
+		return err
+	}
+
+	p.byExternalID, p.byUsername, p.dipakai = map[string]*model.User{}, map[string]*model.User{}, map[primitive.ObjectID]bool{}
+	for i := range users {
+		user := &users[i]
+		p.byUsername[user.Username] = user
+		// External ID milik user aktif didahulukan daripada akun lama yang sudah dihapus
+		if lama, ada := p.byExternalID[user.ExternalID]; user.ExternalID != "" && (!ada || lama.DeletedAt != nil) {
+			p.byExternalID[user.ExternalID] = user
+		}
+	}
+	return nil
+}
+
+// Cari user untuk satu record: external_id lebih dulu, lalu username untuk user yang
+// belum punya external_id (misalnya user lama sebelum sumber ini dipakai)
+func (p *pelaksanaSync) cariUser(record recordSync) (*model.User, error) {
+	externalID, username := record.Nilai["external_id"], record.Nilai["username"]
+	if externalID != "" {
+		if user, ok := p.byExternalID[externalID]; ok {
+			return user, nil
+		}
+	}
+	user, ok := p.byUsername[username]
+	if username == "" || !ok {
+		return nil, nil
+	}
+	if externalID != "" && user.ExternalID != "" && user.ExternalID != externalID {
+		return user, fmt.Errorf("username %s belongs to a user with external_id %s", username, user.ExternalID)
+	}
+	return user, nil
+}
+
+// Status tujuan dari record, kosong jika feed tidak mengirim status
+func statusRecordSync(record recordSync) (string, error) {
+	nilai := strings.ToLower(strings.TrimSpace(record.Nilai["status"]))
+	if nilai == "" {
+		return "", nil
+	}
+	status, ok := aliasStatusSync[nilai]
+	if !ok {
+		return "", fmt.Errorf("unknown status %q", record.Nilai["status"])
+	}
+	return status, nil
+}
+
+// Buat user baru dari record. User dibuat active lalu dipindahkan ke status dari feed
+// lewat ubahStatusUser agar riwayat statusnya tercatat.
+func (p *pelaksanaSync) buatUser(record recordSync, status string) {
+	nilai := record.Nilai
+	user := model.User{
+		Username:   nilai["username"],
+		NmUser:     nilai["nm_user"],
+		Email:      nilai["email"],
+		Role:       nilai["role"],
+		Phone:      nilai["phone"],
+		JenisUser:  nilai["jenis_user"],
+		ExternalID: nilai["external_id"],
+		SumberSync: p.sumber.Nama,
+		Status:     model.StatusActive,
+	}
+	if user.Role == "" {
+		user.Role = roleImportDefault
+	}
+	if !p.roleDiizinkan(user.Role) {
+		p.gagal(record, nil, fmt.Sprintf("role %q is not allowed for this source", user.Role))
+		return
+	}
+	if user.JenisUser == "" {
+		user.JenisUser = DefaultJenisUser
+	}
+	if nilai["jenis_kelamin"] != "" {
+		jenisKelamin, ok := parseJenisKelamin(nilai["jenis_kelamin"])
+		if !ok {
+			p.gagal(record, nil, "jenis_kelamin must be 1/2, L/P or laki-laki/perempuan")
+			return
+		}
+		user.JenisKelamin = jenisKelamin
+	}
+	if nilai["angkatan"] != "" {
+		angkatan, err := strconv.Atoi(nilai["angkatan"])
+		if err != nil {
+			p.gagal(record, nil, "angkatan must be a year")
+			return
+		}
+		user.Angkatan = angkatan
+	}
+	password, err := buatPasswordAwal()
+	if err != nil {
+		p.gagal(record, nil, "failed to generate password")
+		return
+	}
+	user.Password = password
+
+	if err := validate.Struct(&user); err != nil {
+		pesan := []string{}
+		for _, e := range errorValidasiImport(err, record.Nomor) {
+			pesan = append(pesan, e.Field+" "+e.Pesan)
+		}
+		p.gagal(record, nil, strings.Join(pesan, "; "))
+		return
+	}
+	jenisUser, ok := p.katalog[user.JenisUser]
+	if !ok {
+		p.gagal(record, nil, fmt.Sprintf("%v: %s", errJenisUserTidakDikenal, user.JenisUser))
+		return
+	}
+
+	statusAwal := status
+	if statusAwal == "" {
+		statusAwal = model.StatusActive
+	}
+	perubahan := model.SyncPerubahan{Aksi: AksiSyncCreate, Record: record.Nomor, Username: user.Username, ExternalID: user.ExternalID}
+	for _, field := range []struct {
+		Nama  string
+		Nilai interface{}
+	}{{"nm_user", user.NmUser}, {"email", user.Email}, {"jenis_user", user.JenisUser}, {"status", statusAwal}} {
+		perubahan.Diff = append(perubahan.Diff, model.HistoriDiff{Field: field.Nama, Baru: field.Nilai})
+	}
+	if p.run.DryRun {
+		p.catat(perubahan)
+		return
+	}
+
+	// Password acak tidak pernah dikirim, login memakai reset password atau SSO
+	user.ID = primitive.NewObjectID()
+	if err := simpanUserSync(p.ctx, &user); err != nil {
+		p.gagal(record, nil, err.Error())
+		return
+	}
+	perubahan.UserID = &user.ID
+	catatHistori(p.ctx, userCollection, AksiCreate, p.actor, user.ID)
+	p.byUsername[user.Username] = &user
+	if user.ExternalID != "" {
+		p.byExternalID[user.ExternalID] = &user
+	}
+	p.dipakai[user.ID] = true
+
+	if err := masukkanKeBundle(p.ctx, user.ID, jenisUser, p.actor); err != nil {
+		perubahan.Pesan = "user created but not added to the jenis_user bundle: " + err.Error()
+	}
+	if status != "" && status != model.StatusActive {
+		if _, err := ubahStatusUser(p.ctx, user, status, p.alasan(), p.actor); err != nil && !errors.Is(err, errRiwayatStatus) {
+			perubahan.Pesan = "user created but status was not changed: " + err.Error()
+		}
+	}
+	p.catat(perubahan)
+}
+
+// Simpan user baru dengan password ter-hash, ngram dan versi awal
+func simpanUserSync(ctx context.Context, user *model.User) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	user.Password = string(hashedPassword)
+	user.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	user.SearchNgram = ngramUser(*user)
+	user.Version = 1
+	if _, err := userCollection.InsertOne(ctx, user); err != nil {
+		if field := fieldDuplikatUser(err); field != "" {
+			return errors.New(strings.ToLower(pesanDuplikatUser(field)))
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// Bandingkan record dengan user lalu terapkan perbedaannya. Field yang tidak ada di feed tidak diubah.
+func (p *pelaksanaSync) perbaruiUser(record recordSync, user *model.User, status string) {
+	// User di luar sumber ini hanya diambil alih jika ambil_alih diaktifkan, dan akun dengan
+	// role yang tidak boleh dikirim feed (misalnya admin) tidak pernah diubah sync
+	if user.SumberSync != p.sumber.Nama && !p.sumber.AmbilAlih {
+		p.gagal(record, user, "user is not managed by this source, enable ambil_alih to adopt it")
+		return
+	}
+	if user.Role != "" && !p.roleDiizinkan(user.Role) {
+		p.gagal(record, user, fmt.Sprintf("users with role %q cannot be managed by sync", user.Role))
+		return
+	}
+
+	nilai := record.Nilai
+	perubahan := model.SyncPerubahan{Aksi: AksiSyncUpdate, Record: record.Nomor, UserID: &user.ID, Username: user.Username, ExternalID: user.ExternalID}
+	set := bson.M{}
+	ubah := func(field string, lama, baru interface{}) {
+		set[field] = baru
+		perubahan.Diff = append(perubahan.Diff, model.HistoriDiff{Field: field, Lama: lama, Baru: baru})
+	}
+
+	baru := *user
+	if username := nilai["username"]; username != "" && username != user.Username {
+		if lain, ada := p.byUsername[username]; ada && lain.ID != user.ID {
+			p.gagal(record, user, "username "+username+" is already used by another user")
+			return
+		}
+		ubah("username", user.Username, username)
+		baru.Username = username
+	}
+	for _, field := range []struct {
+		Nama string
+		Lama *string
+	}{{"nm_user", &baru.NmUser}, {"email", &baru.Email}, {"phone", &baru.Phone}, {"role", &baru.Role}} {
+		if isi := nilai[field.Nama]; isi != "" && isi != *field.Lama {
+			if field.Nama == "email" && validate.Var(isi, "email") != nil {
+				p.gagal(record, user, fmt.Sprintf("%q is not a valid email address", isi))
+				return
+			}
+			if field.Nama == "role" && !p.roleDiizinkan(isi) {
+				p.gagal(record, user, fmt.Sprintf("role %q is not allowed for this source", isi))
+				return
+			}
+			ubah(field.Nama, *field.Lama, isi)
+			*field.Lama = isi
+		}
+	}
+	if isi := nilai["jenis_kelamin"]; isi != "" {
+		jenisKelamin, ok := parseJenisKelamin(isi)
+		if !ok {
+			p.gagal(record, user, "jenis_kelamin must be 1/2, L/P or laki-laki/perempuan")
+			return
+		}
+		if jenisKelamin != user.JenisKelamin {
+			ubah("jenis_kelamin", user.JenisKelamin, jenisKelamin)
+		}
+	}
+	if isi := nilai["angkatan"]; isi != "" {
+		angkatan, err := strconv.Atoi(isi)
+		if err != nil {
+			p.gagal(record, user, "angkatan must be a year")
+			return
+		}
+		if angkatan != user.Angkatan {
+			ubah("angkatan", user.Angkatan, angkatan)
+		}
+	}
+	if isi := nilai["external_id"]; isi != "" && user.ExternalID == "" {
+		ubah("external_id", nil, isi)
+	}
+	if user.SumberSync != p.sumber.Nama {
+		ubah("sumber_sync", user.SumberSync, p.sumber.Nama)
+	}
+
+	var jenisUser *model.JenisUser
+	if isi := nilai["jenis_user"]; isi != "" && isi != user.JenisUser {
+		tujuan, ok := p.katalog[isi]
+		if !ok {
+			p.gagal(record, user, fmt.Sprintf("%v: %s", errJenisUserTidakDikenal, isi))
+			return
+		}
+		jenisUser = &tujuan
+		perubahan.Diff = append(perubahan.Diff, model.HistoriDiff{Field: "jenis_user", Lama: user.JenisUser, Baru: isi})
+	}
+
+	// Status locked diatur admin karena alasan keamanan dan tidak diubah oleh sync
+	dari := user.StatusAkun()
+	if status == dari || dari == model.StatusLocked {
+		status = ""
+	}
+	if status != "" {
+		if !model.TransisiStatusValid(dari, status) {
+			p.gagal(record, user, fmt.Sprintf("%v: %s -> %s", errTransisiStatus, dari, status))
+			return
+		}
+		perubahan.Diff = append(perubahan.Diff, model.HistoriDiff{Field: "status", Lama: dari, Baru: status})
+	}
+
+	if len(perubahan.Diff) == 0 {
+		p.run.TidakBerubah++
+		return
+	}
+	if p.run.DryRun {
+		p.catat(perubahan)
+		return
+	}
+
+	if len(set) > 0 {
+		result, err := userCollection.UpdateOne(
+			p.ctx,
+			bson.M{"_id": user.ID, "deleted_at": nil, "version": syaratVersi(user.Version)},
+			bson.M{"$set": set, "$inc": naikkanVersi},
+		)
+		if field := fieldDuplikatUser(err); field != "" {
+			p.gagal(record, user, strings.ToLower(pesanDuplikatUser(field)))
+			return
+		}
+		if err != nil {
+			p.gagal(record, user, "failed to update user: "+err.Error())
+			return
+		}
+		if result.MatchedCount == 0 {
+			p.gagal(record, user, "user was changed by another request during sync, it will be retried on the next run")
+			return
+		}
+		catatHistori(p.ctx, userCollection, AksiUpdate, p.actor, user.ID)
+		if err := perbaruiNgramUser(p.ctx, baru); err != nil {
+			fmt.Println("Error updating search ngram:", err)
+		}
+	}
+	if jenisUser != nil {
+		if err := pindahkanJenisUser(p.ctx, user.ID, *jenisUser, p.actor); err != nil {
+			p.gagal(record, user, err.Error())
+			return
+		}
+	}
+	if status != "" {
+		if _, err := ubahStatusUser(p.ctx, *user, status, p.alasan(), p.actor); errors.Is(err, errRiwayatStatus) {
+			fmt.Println("Error recording status history:", err)
+		} else if err != nil {
+			p.gagal(record, user, err.Error())
+			return
+		}
+	}
+	p.catat(perubahan)
+}
+
+// Proses satu record feed
+func (p *pelaksanaSync) prosesRecord(record recordSync, sudah map[string]int) {
+	kunci := "external_id:" + record.Nilai["external_id"]
+	if record.Nilai["external_id"] == "" {
+		kunci = "username:" + record.Nilai["username"]
+	}
+	if kunci == "username:" {
+		p.gagal(record, nil, "record has no username or external_id")
+		return
+	}
+	if pertama, ada := sudah[kunci]; ada {
+		p.gagal(record, nil, fmt.Sprintf("duplicate record, first seen at record %d", pertama))
+		return
+	}
+	sudah[kunci] = record.Nomor
+
+	status, err := statusRecordSync(record)
+	if err != nil {
+		p.gagal(record, nil, err.Error())
+		return
+	}
+	user, err := p.cariUser(record)
+	if err != nil {
+		p.gagal(record, user, err.Error())
+		return
+	}
+	if user == nil {
+		p.buatUser(record, status)
+		return
+	}
+	p.dipakai[user.ID] = true
+	if user.DeletedAt != nil {
+		p.gagal(record, user, "matching user is deleted, restore it before syncing")
+		return
+	}
+	p.perbaruiUser(record, user, status)
+}
+
+// Suspend user dari sumber ini yang tidak ada lagi di feed
+func (p *pelaksanaSync) suspendHilang() error {
+	cursor, err := userCollection.Find(p.ctx, bson.M{
+		"sumber_sync": p.sumber.Nama,
+		"deleted_at":  nil,
+		"status":      bson.M{"$ne": model.StatusSuspended},
+	})
+	if err != nil {
+		return err
+	}
+	var users []model.User
+	if err := cursor.All(p.ctx, &users); err != nil {
+		return err
+	}
+	for _, user := range users {
+		if p.dipakai[user.ID] || !model.TransisiStatusValid(user.StatusAkun(), model.StatusSuspended) {
+			continue
+		}
+		perubahan := model.SyncPerubahan{
+			Aksi:       AksiSyncSuspend,
+			UserID:     &user.ID,
+			Username:   user.Username,
+			ExternalID: user.ExternalID,
+			Diff:       []model.HistoriDiff{{Field: "status", Lama: user.StatusAkun(), Baru: model.StatusSuspended}},
+			Pesan:      "not present in the feed",
+		}
+		if !p.run.DryRun {
+			if _, err := ubahStatusUser(p.ctx, user, model.StatusSuspended, p.alasan()+": not present in the feed", p.actor); errors.Is(err, errRiwayatStatus) {
+				fmt.Println("Error recording status history:", err)
+			} else if err != nil {
+				perubahan.Aksi, perubahan.Pesan = AksiSyncError, "failed to suspend: "+err.Error()
+			}
+		}
+		p.catat(perubahan)
+	}
+	return nil
+}
+
+// Cocokkan seluruh record feed dengan koleksi users
+func rekonsiliasiSync(ctx context.Context, sumber model.SyncSumber, run *model.SyncRun, records []recordSync, actor string) error {
+	katalog, err := loadKatalogJenisUser(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load jenis_user catalog: %w", err)
+	}
+	p := &pelaksanaSync{ctx: ctx, sumber: sumber, run: run, katalog: katalog, actor: actor}
+	if err := p.muatUser(records); err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+
+	sudah := map[string]int{}
+	for _, record := range records {
+		p.prosesRecord(record, sudah)
+	}
+
+	// Feed kosong hampir selalu berarti kesalahan di sistem sumber, bukan semua user keluar
+	if sumber.SuspendHilang && len(records) > 0 {
+		if err := p.suspendHilang(); err != nil {
+			return fmt.Errorf("failed to suspend missing users: %w", err)
+		}
+	}
+	return nil
+}
+
+// Jalankan sync satu sumber dan simpan laporannya. errFeedKosong dikembalikan tanpa laporan
+// agar folder drop yang kosong tidak memenuhi riwayat sync terjadwal.
+func syncSumber(ctx context.Context, sumber model.SyncSumber, dryRun bool, actor string) (model.SyncRun, error) {
+	run := model.SyncRun{
+		ID:          primitive.NewObjectID(),
+		SumberID:    sumber.ID,
+		Sumber:      sumber.Nama,
+		DryRun:      dryRun,
+		Status:      StatusSyncRunning,
+		Perubahan:   []model.SyncPerubahan{},
+		Actor:       actor,
+		DimulaiPada: time.Now(),
+	}
+	if _, berjalan := syncBerjalan.LoadOrStore(sumber.ID, true); berjalan {
+		return run, errSyncBerjalan
+	}
+	defer syncBerjalan.Delete(sumber.ID)
+
+	feed, err := siapkanFeed(ctx, sumber)
+	if errors.Is(err, errFeedKosong) {
+		return run, err
+	}
+	if feed.Hapus && feed.Path != "" {
+		defer os.Remove(feed.Path)
+	}
+	run.Berkas = feed.Asal
+	if _, errInsert := SyncRunCollection.InsertOne(ctx, run); errInsert != nil {
+		return run, errInsert
+	}
+
+	if err == nil {
+		var records []recordSync
+		if records, err = bacaRecordSync(feed, sumber.Mapping); err == nil {
+			run.Total = len(records)
+			err = rekonsiliasiSync(ctx, sumber, &run, records, actor)
+		}
+	}
+
+	selesai := time.Now()
+	run.SelesaiPada = &selesai
+	run.Status = StatusSyncCompleted
+	if err != nil {
+		run.Status, run.Pesan = StatusSyncFailed, err.Error()
+		fmt.Println("Sync from", sumber.Nama, "failed:", err)
+	}
+	if _, errSimpan := SyncRunCollection.ReplaceOne(context.Background(), bson.M{"_id": run.ID}, run); errSimpan != nil {
+		fmt.Println("Error saving sync report:", errSimpan)
+	}
+	if err == nil && !dryRun {
+		arsipkanFeed(feed)
+	}
+	return run, err
+}
+
+// Klaim jadwal sumber dengan memperbarui terakhir_jalan hanya jika belum diubah proses lain
+func klaimJadwalSync(ctx context.Context, sumber model.SyncSumber) (bool, error) {
+	filter := bson.M{"_id": sumber.ID, "terakhir_jalan": nil}
+	if sumber.TerakhirJalan != nil {
+		filter["terakhir_jalan"] = *sumber.TerakhirJalan
+	}
+	result, err := SyncSumberCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"terakhir_jalan": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Jalankan sumber aktif yang sudah waktunya disinkronkan
+func jalankanSyncTerjadwal() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := SyncSumberCollection.Find(ctx, bson.M{"aktif": true, "interval": bson.M{"$nin": bson.A{"", nil}}})
+	if err != nil {
+		fmt.Println("Error fetching sync sources:", err)
+		return
+	}
+	var daftar []model.SyncSumber
+	if err := cursor.All(ctx, &daftar); err != nil {
+		fmt.Println("Error decoding sync sources:", err)
+		return
+	}
+	for _, sumber := range daftar {
+		interval, err := time.ParseDuration(sumber.Interval)
+		if err != nil || interval <= 0 {
+			continue
+		}
+		if sumber.TerakhirJalan != nil && time.Since(*sumber.TerakhirJalan) < interval {
+			continue
+		}
+		if _, berjalan := syncBerjalan.Load(sumber.ID); berjalan {
+			continue
+		}
+		if ok, err := klaimJadwalSync(ctx, sumber); err != nil || !ok {
+			continue
+		}
+		go func(sumber model.SyncSumber) {
+			ctx, cancel := context.WithTimeout(context.Background(), batasWaktuSync)
+			defer cancel()
+			run, err := syncSumber(ctx, sumber, false, aktorSistem)
+			if err == nil {
+				fmt.Println("Sync from", sumber.Nama, "completed:", run.Dibuat, "created,", run.Diubah, "updated,", run.Disuspend, "suspended,", run.Gagal, "failed")
+			}
+		}(sumber)
+	}
+}
+
+// MulaiSyncTerjadwal memeriksa jadwal sync di background. Dipanggil sekali saat aplikasi mulai.
+func MulaiSyncTerjadwal() {
+	go func() {
+		for {
+			jalankanSyncTerjadwal()
+			time.Sleep(intervalCekSync)
+		}
+	}()
+}
+
+// JalankanMockFeed menyajikan berkas di storage/sync/mock sebagai feed HTTP lokal,
+// misalnya http://localhost:3100/mahasiswa.json, untuk mencoba sumber url tanpa sistem akademik
+func JalankanMockFeed(alamat string) error {
+	if err := os.MkdirAll(direktoriMockFeed, os.ModePerm); err != nil {
+		return err
+	}
+	app := fiber.New()
+	app.Static("/", direktoriMockFeed, fiber.Static{Browse: true})
+	fmt.Println("Serving mock feeds from", direktoriMockFeed, "on", alamat)
+	return app.Listen(alamat)
+}
+
+// Validasi sumber sebelum disimpan
+func cekSumberSync(ctx context.Context, sumber model.SyncSumber) error {
+	if err := validate.Struct(&sumber); err != nil {
+		return err
+	}
+	for kolom, field := range sumber.Mapping {
+		if !fieldSync[field] {
+			return fmt.Errorf("mapping for %q targets unknown field %q", kolom, field)
+		}
+	}
+	if slices.Contains(sumber.RoleDiizinkan, roleAdmin) {
+		return fmt.Errorf("role_diizinkan cannot contain %q", roleAdmin)
+	}
+	if sumber.Interval != "" {
+		if interval, err := time.ParseDuration(sumber.Interval); err != nil || interval < intervalCekSync {
+			return fmt.Errorf("interval must be a duration of at least %s, for example \"6h\"", intervalCekSync)
+		}
+	}
+	if sumber.Jenis == "url" {
+		u, err := url.Parse(sumber.Lokasi)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("lokasi must be an http or https URL")
+		}
+	}
+
+	count, err := SyncSumberCollection.CountDocuments(ctx, bson.M{"nama": sumber.Nama, "_id": bson.M{"$ne": sumber.ID}})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errSumberSyncDup
+	}
+	return nil
+}
+
+// Ambil sumber sync dari parameter :sumberId beserta status HTTP jika gagal
+func cariSumberSync(ctx context.Context, c *fiber.Ctx) (model.SyncSumber, int, error) {
+	var sumber model.SyncSumber
+	sumberID, err := primitive.ObjectIDFromHex(c.Params("sumberId"))
+	if err != nil {
+		return sumber, http.StatusBadRequest, fmt.Errorf("Invalid sync source ID")
+	}
+	err = SyncSumberCollection.FindOne(ctx, bson.M{"_id": sumberID}).Decode(&sumber)
+	if err == mongo.ErrNoDocuments {
+		return sumber, http.StatusNotFound, fmt.Errorf("Sync source not found")
+	}
+	if err != nil {
+		return sumber, http.StatusInternalServerError, fmt.Errorf("Failed to fetch sync source")
+	}
+	return sumber, http.StatusOK, nil
+}
+
+// GetSyncSources - Daftar sumber sync
+func GetSyncSources(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := SyncSumberCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "nama", Value: 1}}))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch sync sources"})
+	}
+	daftar := []model.SyncSumber{}
+	if err := cursor.All(ctx, &daftar); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode sync sources"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"sources": daftar, "total_count": len(daftar)})
+}
+
+// CreateSyncSource - Daftarkan feed baru
+func CreateSyncSource(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var sumber model.SyncSumber
+	if err := c.BodyParser(&sumber); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	sumber.ID = primitive.NewObjectID()
+	sumber.Nama = strings.TrimSpace(sumber.Nama)
+	if sumber.Mapping == nil {
+		sumber.Mapping = map[string]string{}
+	}
+	if err := cekSumberSync(ctx, sumber); errors.Is(err, errSumberSyncDup) {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	} else if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sumber.TerakhirJalan = nil
+	sumber.CreatedBy = aktorDari(c)
+	sumber.CreatedAt = time.Now()
+	sumber.UpdatedAt = time.Now()
+	if _, err := SyncSumberCollection.InsertOne(ctx, sumber); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create sync source"})
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"message": "Sync source created", "source": sumber})
+}
+
+// UpdateSyncSource - Ubah konfigurasi sumber. Nama tidak bisa diganti karena tercatat di user.
+func UpdateSyncSource(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lama, status, err := cariSumberSync(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	var sumber model.SyncSumber
+	if err := c.BodyParser(&sumber); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if nama := strings.TrimSpace(sumber.Nama); nama != "" && nama != lama.Nama {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "nama cannot be changed because it is recorded on synced users"})
+	}
+	sumber.ID, sumber.Nama = lama.ID, lama.Nama
+	if sumber.Mapping == nil {
+		sumber.Mapping = map[string]string{}
+	}
+	if err := cekSumberSync(ctx, sumber); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sumber.TerakhirJalan, sumber.CreatedBy, sumber.CreatedAt = lama.TerakhirJalan, lama.CreatedBy, lama.CreatedAt
+	sumber.UpdatedAt = time.Now()
+	if _, err := SyncSumberCollection.ReplaceOne(ctx, bson.M{"_id": lama.ID}, sumber); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update sync source"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Sync source updated", "source": sumber})
+}
+
+// DeleteSyncSource - Hapus sumber. User dari sumber ini tidak diubah, laporan sync tetap disimpan.
+func DeleteSyncSource(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sumber, status, err := cariSumberSync(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, berjalan := syncBerjalan.Load(sumber.ID); berjalan {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": errSyncBerjalan.Error()})
+	}
+	if _, err := SyncSumberCollection.DeleteOne(ctx, bson.M{"_id": sumber.ID}); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete sync source"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Sync source deleted"})
+}
+
+// RunSyncSource - Jalankan sync sekarang. Dry run langsung mengembalikan laporan rencana
+// perubahan, sync biasa berjalan di background dan laporannya dibaca lewat /sync/runs/:runId.
+func RunSyncSource(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sumber, status, err := cariSumberSync(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	dryRun, err := strconv.ParseBool(c.Query("dry_run", "false"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "dry_run must be true or false"})
+	}
+	if _, berjalan := syncBerjalan.Load(sumber.ID); berjalan {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": errSyncBerjalan.Error()})
+	}
+
+	if dryRun {
+		ctxSync, cancelSync := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancelSync()
+		run, err := syncSumber(ctxSync, sumber, true, aktorDari(c))
+		switch {
+		case errors.Is(err, errFeedKosong):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, errSyncBerjalan):
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case err != nil && run.Status == "":
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusOK).JSON(fiber.Map{"run": run})
+	}
+
+	// Jadwal berikutnya dihitung dari sync manual ini
+	if _, err := SyncSumberCollection.UpdateOne(ctx, bson.M{"_id": sumber.ID}, bson.M{"$set": bson.M{"terakhir_jalan": time.Now()}}); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start sync"})
+	}
+	actor := aktorDari(c)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), batasWaktuSync)
+		defer cancel()
+		if _, err := syncSumber(ctx, sumber, false, actor); err != nil && !errors.Is(err, errFeedKosong) {
+			fmt.Println("Error running sync from", sumber.Nama, ":", err)
+		}
+	}()
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"message": "Sync started", "source": sumber.Nama})
+}
+
+// GetSyncRuns - Riwayat sync terbaru tanpa daftar perubahan, bisa difilter ?sumber_id=
+func GetSyncRuns(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if nilai := c.Query("sumber_id"); nilai != "" {
+		sumberID, err := primitive.ObjectIDFromHex(nilai)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid sync source ID"})
+		}
+		filter["sumber_id"] = sumberID
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "dimulai_pada", Value: -1}}).
+		SetLimit(50).
+		SetProjection(bson.M{"perubahan": 0})
+	cursor, err := SyncRunCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch sync runs"})
+	}
+	runs := []model.SyncRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode sync runs"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"runs": runs, "total_count": len(runs)})
+}
+
+// GetSyncRun - Laporan lengkap satu sync beserta diff per user
+func GetSyncRun(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	runID, err := primitive.ObjectIDFromHex(c.Params("runId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid sync run ID"})
+	}
+	var run model.SyncRun
+	err = SyncRunCollection.FindOne(ctx, bson.M{"_id": runID}).Decode(&run)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Sync run not found"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch sync run"})
+	}
+	_, berjalan := syncBerjalan.Load(run.SumberID)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"run": run, "berjalan": berjalan && run.Status == StatusSyncRunning})
+}
@@ -0,0 +1,908 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"demoapp/responses"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var TransisiCollection = config.GetCollection(config.DB, "transisi_jenis_user")
+var HasilTransisiCollection = config.GetCollection(config.DB, "transisi_hasil")
+
+// Status aturan transisi jenis_user
+const (
+	StatusTransisiScheduled   = "scheduled"
+	StatusTransisiRunning     = "running"
+	StatusTransisiCompleted   = "completed"
+	StatusTransisiFailed      = "failed"
+	StatusTransisiRollingBack = "rolling_back"
+	StatusTransisiRolledBack  = "rolled_back"
+)
+
+// Status hasil transisi per user
+const (
+	HasilTransisiPending         = "pending"
+	HasilTransisiMoved           = "moved"
+	HasilTransisiSkipped         = "skipped"
+	HasilTransisiFailed          = "failed"
+	HasilTransisiRolledBack      = "rolled_back"
+	HasilTransisiRollbackSkipped = "rollback_skipped"
+	HasilTransisiRollbackFailed  = "rollback_failed"
+)
+
+const (
+	// Jarak pemeriksaan transisi yang sudah jatuh tempo
+	intervalCekTransisi = time.Minute
+	// Jumlah hasil per user yang dibaca sekaligus saat transisi berjalan
+	batchTransisi = 100
+)
+
+var (
+	errTransisiDilewati       = errors.New("skipped")
+	errHasilTransisiBerubah   = errors.New("user result was already processed")
+	errTransaksiTidakDidukung = errors.New("MongoDB transactions are not available, run MongoDB as a replica set to use jenis_user transitions")
+)
+
+// Transisi yang sedang dijalankan atau di-rollback di proses ini
+var transisiBerjalan sync.Map
+
+// Field hasil transisi yang boleh dipakai untuk sort
+var hasilTransisiSortFields = map[string]bool{
+	"username":      true,
+	"status":        true,
+	"diproses_pada": true,
+}
+
+// Error "Transaction numbers are only allowed on a replica set member or mongos" pada MongoDB standalone
+func transaksiTidakDidukung(err error) bool {
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == 20 {
+		return true
+	}
+	return err != nil && strings.Contains(err.Error(), "Transaction numbers are only allowed")
+}
+
+// Jalankan fn dalam satu transaksi MongoDB. Transaksi diulang otomatis jika terjadi write conflict,
+// sehingga fn harus membaca ulang semua data yang dipakainya.
+func dalamTransaksi(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	session, err := config.DB.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	if transaksiTidakDidukung(err) {
+		return errTransaksiTidakDidukung
+	}
+	return err
+}
+
+// Dokumen usermodul yang berisi user. Berbeda dengan idDokumen, error dikembalikan
+// karena hasilnya dipakai untuk rollback.
+func idUserModul(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	raw, err := UserModulCollection.Distinct(ctx, "_id", bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(raw))
+	for _, nilai := range raw {
+		if id, ok := nilai.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// ID di a yang tidak ada di b
+func selisihID(a []primitive.ObjectID, b []primitive.ObjectID) []primitive.ObjectID {
+	ada := map[primitive.ObjectID]bool{}
+	for _, id := range b {
+		ada[id] = true
+	}
+	hasil := []primitive.ObjectID{}
+	for _, id := range a {
+		if !ada[id] {
+			hasil = append(hasil, id)
+		}
+	}
+	return hasil
+}
+
+// Filter user kohort: jenis_user asal ditambah semua kriteria kohort yang diisi
+func filterKohort(transisi model.TransisiJenisUser) bson.M {
+	filter := bson.M{"deleted_at": nil, "jenis_user": transisi.Dari}
+	kohort := transisi.Kohort
+	if len(kohort.Angkatan) > 0 {
+		filter["angkatan"] = bson.M{"$in": kohort.Angkatan}
+	}
+	if len(kohort.Status) > 0 {
+		nilai := bson.A{}
+		for _, status := range kohort.Status {
+			nilai = append(nilai, status)
+			// User lama tanpa field status dianggap active
+			if status == model.StatusActive {
+				nilai = append(nilai, nil)
+			}
+		}
+		filter["status"] = bson.M{"$in": nilai}
+	}
+	if kohort.SumberSync != "" {
+		filter["sumber_sync"] = kohort.SumberSync
+	}
+	if len(kohort.UserIDs) > 0 {
+		filter["_id"] = bson.M{"$in": kohort.UserIDs}
+	}
+	return filter
+}
+
+// Validasi aturan transisi sebelum disimpan beserta status HTTP jika tidak valid
+func cekTransisi(ctx context.Context, transisi model.TransisiJenisUser) (int, error) {
+	if err := validate.Struct(&transisi); err != nil {
+		return http.StatusBadRequest, err
+	}
+	kohort := transisi.Kohort
+	if len(kohort.Angkatan) == 0 && len(kohort.Status) == 0 && kohort.SumberSync == "" && len(kohort.UserIDs) == 0 {
+		return http.StatusBadRequest, fmt.Errorf("kohort must have at least one criterion: angkatan, status, sumber_sync or user_ids")
+	}
+	for _, status := range kohort.Status {
+		if _, ok := model.TransisiStatusUser[status]; !ok {
+			return http.StatusBadRequest, fmt.Errorf("%w: %s", errStatusTidakDikenal, status)
+		}
+	}
+	for _, kode := range []string{transisi.Dari, transisi.Ke} {
+		if _, err := cariJenisUser(ctx, kode); err != nil {
+			return statusJenisUserError(err), err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// Simpan daftar user kohort sebagai hasil pending. Daftar diambil sekali saat transisi mulai
+// sehingga user yang baru masuk kohort setelahnya tidak ikut dipindahkan. Aman diulang karena
+// pasangan transisi_id dan user_id unik.
+func simpanKohortTransisi(ctx context.Context, transisi model.TransisiJenisUser) (int, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "username": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := userCollection.Find(ctx, filterKohort(transisi), opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	batch := []interface{}{}
+	simpan := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := HasilTransisiCollection.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+		batch = []interface{}{}
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		return nil
+	}
+	for cursor.Next(ctx) {
+		var user model.User
+		if err := cursor.Decode(&user); err != nil {
+			return 0, err
+		}
+		batch = append(batch, model.HasilTransisi{
+			ID:         primitive.NewObjectID(),
+			TransisiID: transisi.ID,
+			UserID:     user.ID,
+			Username:   user.Username,
+			Status:     HasilTransisiPending,
+		})
+		if len(batch) >= 1000 {
+			if err := simpan(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+	if err := simpan(); err != nil {
+		return 0, err
+	}
+
+	total, err := HasilTransisiCollection.CountDocuments(ctx, bson.M{"transisi_id": transisi.ID})
+	return int(total), err
+}
+
+// Simpan hasil yang tidak mengubah user (dilewati atau gagal) beserta hitungannya
+func catatHasilTransisi(ctx context.Context, hasil model.HasilTransisi, status string, pesan string, waktu string, inc bson.M) error {
+	result, err := HasilTransisiCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": hasil.ID, "status": hasil.Status},
+		bson.M{"$set": bson.M{"status": status, "pesan": pesan, waktu: time.Now()}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+	if len(inc) > 0 {
+		update["$inc"] = inc
+	}
+	_, err = TransisiCollection.UpdateOne(ctx, bson.M{"_id": hasil.TransisiID}, update)
+	return err
+}
+
+// Pindahkan satu user ke jenis_user tujuan. Perpindahan usermodul, field jenis_user, hasil
+// per user dan hitungan progress ditulis dalam satu transaksi: jika server mati di tengah jalan,
+// user tidak pernah setengah dipindahkan dan hasilnya tetap pending. Error yang dikembalikan
+// menghentikan seluruh transisi, error per user hanya dicatat di hasilnya.
+func pindahkanUserTransisi(ctx context.Context, transisi model.TransisiJenisUser, jenisUser model.JenisUser, hasil model.HasilTransisi) error {
+	// Hasil gagal yang dicoba lagi sudah terhitung di diproses
+	inc := bson.M{"diproses": 1}
+	if hasil.Status == HasilTransisiFailed {
+		inc = bson.M{"gagal": -1}
+	}
+
+	err := dalamTransaksi(ctx, func(sc mongo.SessionContext) error {
+		var user model.User
+		err := userCollection.FindOne(sc, bson.M{"_id": hasil.UserID, "deleted_at": nil}).Decode(&user)
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("%w: user was deleted", errTransisiDilewati)
+		}
+		if err != nil {
+			return err
+		}
+		if user.JenisUser != transisi.Dari {
+			return fmt.Errorf("%w: jenis_user is now %s", errTransisiDilewati, user.JenisUser)
+		}
+
+		sebelum, err := idUserModul(sc, user.ID)
+		if err != nil {
+			return err
+		}
+		if err := pindahkanJenisUser(sc, user.ID, jenisUser, transisi.CreatedBy); err != nil {
+			return err
+		}
+		sesudah, err := idUserModul(sc, user.ID)
+		if err != nil {
+			return err
+		}
+
+		result, err := HasilTransisiCollection.UpdateOne(
+			sc,
+			bson.M{"_id": hasil.ID, "status": hasil.Status},
+			bson.M{
+				"$set": bson.M{
+					"status":         HasilTransisiMoved,
+					"modul_dilepas":  selisihID(sebelum, sesudah),
+					"modul_ditambah": selisihID(sesudah, sebelum),
+					"diproses_pada":  time.Now(),
+				},
+				"$unset": bson.M{"pesan": ""},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return errHasilTransisiBerubah
+		}
+
+		incBerhasil := bson.M{"berhasil": 1}
+		for field, n := range inc {
+			incBerhasil[field] = n
+		}
+		_, err = TransisiCollection.UpdateOne(sc, bson.M{"_id": transisi.ID}, bson.M{"$inc": incBerhasil, "$set": bson.M{"updated_at": time.Now()}})
+		return err
+	})
+
+	switch {
+	case err == nil || errors.Is(err, errHasilTransisiBerubah):
+		return nil
+	case errors.Is(err, errTransaksiTidakDidukung):
+		return err
+	case errors.Is(err, errTransisiDilewati):
+		inc["dilewati"] = 1
+		return catatHasilTransisi(ctx, hasil, HasilTransisiSkipped, err.Error(), "diproses_pada", inc)
+	}
+	if hasil.Status == HasilTransisiFailed {
+		inc = bson.M{}
+	} else {
+		inc["gagal"] = 1
+	}
+	return catatHasilTransisi(ctx, hasil, HasilTransisiFailed, err.Error(), "diproses_pada", inc)
+}
+
+// Kembalikan satu user ke jenis_user asal: keluarkan dari dokumen usermodul yang diikuti karena
+// transisi, masukkan lagi ke dokumen yang ditinggalkan, lalu kembalikan field jenis_user.
+// User yang jenis_user-nya sudah diubah lagi setelah transisi dilewati.
+func kembalikanUserTransisi(ctx context.Context, transisi model.TransisiJenisUser, hasil model.HasilTransisi) error {
+	inc := bson.M{}
+	if hasil.Status == HasilTransisiRollbackFailed {
+		inc["rollback_gagal"] = -1
+	}
+	actor := transisi.RollbackBy
+
+	err := dalamTransaksi(ctx, func(sc mongo.SessionContext) error {
+		// User terhapus tetap dikembalikan agar restore memulihkan jenis_user asalnya
+		var user model.User
+		err := userCollection.FindOne(sc, bson.M{"_id": hasil.UserID}).Decode(&user)
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("%w: user was purged", errTransisiDilewati)
+		}
+		if err != nil {
+			return err
+		}
+		if user.JenisUser != transisi.Ke {
+			return fmt.Errorf("%w: jenis_user was changed to %s after the transition", errTransisiDilewati, user.JenisUser)
+		}
+
+		if len(hasil.ModulDitambah) > 0 {
+			_, err := UserModulCollection.UpdateMany(
+				sc,
+				bson.M{"_id": bson.M{"$in": hasil.ModulDitambah}},
+				bson.M{"$pull": bson.M{"user_id": user.ID}, "$inc": naikkanVersi},
+			)
+			if err != nil {
+				return err
+			}
+			catatHistori(sc, UserModulCollection, AksiRevert, actor, hasil.ModulDitambah...)
+		}
+		if len(hasil.ModulDilepas) > 0 {
+			_, err := UserModulCollection.UpdateMany(
+				sc,
+				bson.M{"_id": bson.M{"$in": hasil.ModulDilepas}},
+				bson.M{"$addToSet": bson.M{"user_id": user.ID}, "$inc": naikkanVersi},
+			)
+			if err != nil {
+				return err
+			}
+			catatHistori(sc, UserModulCollection, AksiRevert, actor, hasil.ModulDilepas...)
+		}
+		if _, err := userCollection.UpdateOne(
+			sc,
+			bson.M{"_id": user.ID, "jenis_user": transisi.Ke},
+			bson.M{"$set": bson.M{"jenis_user": transisi.Dari}, "$inc": naikkanVersi},
+		); err != nil {
+			return err
+		}
+		catatHistori(sc, userCollection, AksiRevert, actor, user.ID)
+
+		result, err := HasilTransisiCollection.UpdateOne(
+			sc,
+			bson.M{"_id": hasil.ID, "status": hasil.Status},
+			bson.M{"$set": bson.M{"status": HasilTransisiRolledBack, "rollback_pada": time.Now()}, "$unset": bson.M{"pesan": ""}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return errHasilTransisiBerubah
+		}
+
+		incRollback := bson.M{"dirollback": 1}
+		for field, n := range inc {
+			incRollback[field] = n
+		}
+		_, err = TransisiCollection.UpdateOne(sc, bson.M{"_id": transisi.ID}, bson.M{"$inc": incRollback, "$set": bson.M{"updated_at": time.Now()}})
+		return err
+	})
+
+	switch {
+	case err == nil || errors.Is(err, errHasilTransisiBerubah):
+		return nil
+	case errors.Is(err, errTransaksiTidakDidukung):
+		return err
+	case errors.Is(err, errTransisiDilewati):
+		inc["rollback_dilewati"] = 1
+		return catatHasilTransisi(ctx, hasil, HasilTransisiRollbackSkipped, err.Error(), "rollback_pada", inc)
+	}
+	if hasil.Status == HasilTransisiRollbackFailed {
+		inc = bson.M{}
+	} else {
+		inc["rollback_gagal"] = 1
+	}
+	return catatHasilTransisi(ctx, hasil, HasilTransisiRollbackFailed, err.Error(), "rollback_pada", inc)
+}
+
+// Proses hasil transisi per batch, urut _id. Setiap hasil langsung berubah status sehingga
+// proses yang terhenti cukup dijalankan ulang.
+func prosesHasilTransisi(ctx context.Context, transisiID primitive.ObjectID, status []string, proses func(model.HasilTransisi) error) error {
+	terakhir := primitive.NilObjectID
+	for {
+		opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(batchTransisi)
+		cursor, err := HasilTransisiCollection.Find(ctx, bson.M{
+			"transisi_id": transisiID,
+			"status":      bson.M{"$in": status},
+			"_id":         bson.M{"$gt": terakhir},
+		}, opts)
+		if err != nil {
+			return err
+		}
+		var daftar []model.HasilTransisi
+		if err := cursor.All(ctx, &daftar); err != nil {
+			return err
+		}
+		if len(daftar) == 0 {
+			return nil
+		}
+		for _, hasil := range daftar {
+			if err := proses(hasil); err != nil {
+				return err
+			}
+			terakhir = hasil.ID
+		}
+	}
+}
+
+func gagalkanTransisi(ctx context.Context, transisiID primitive.ObjectID, pesan string) {
+	fmt.Println("Jenis_user transition", transisiID.Hex(), "failed:", pesan)
+	_, err := TransisiCollection.UpdateOne(ctx, bson.M{"_id": transisiID}, bson.M{"$set": bson.M{
+		"status":     StatusTransisiFailed,
+		"pesan":      pesan,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		fmt.Println("Error saving transition status:", err)
+	}
+}
+
+// Jalankan transisi yang berstatus salah satu dariStatus. User yang sebelumnya gagal
+// dipindahkan dicoba lagi.
+func jalankanTransisi(transisiID primitive.ObjectID, dariStatus ...string) {
+	if _, berjalan := transisiBerjalan.LoadOrStore(transisiID, true); berjalan {
+		return
+	}
+	defer transisiBerjalan.Delete(transisiID)
+
+	ctx := context.Background()
+
+	var transisi model.TransisiJenisUser
+	err := TransisiCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": transisiID, "status": bson.M{"$in": dariStatus}},
+		bson.M{"$set": bson.M{"status": StatusTransisiRunning, "updated_at": time.Now()}, "$unset": bson.M{"pesan": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&transisi)
+	if err != nil {
+		// Sudah diambil proses lain atau statusnya berubah
+		if err != mongo.ErrNoDocuments {
+			fmt.Println("Error starting jenis_user transition", transisiID.Hex(), ":", err)
+		}
+		return
+	}
+
+	jenisUser, err := cariJenisUser(ctx, transisi.Ke)
+	if err != nil {
+		gagalkanTransisi(ctx, transisiID, err.Error())
+		return
+	}
+
+	if transisi.DimulaiPada == nil {
+		total, err := simpanKohortTransisi(ctx, transisi)
+		if err != nil {
+			gagalkanTransisi(ctx, transisiID, "failed to collect cohort: "+err.Error())
+			return
+		}
+		mulai := time.Now()
+		if _, err := TransisiCollection.UpdateOne(ctx, bson.M{"_id": transisiID}, bson.M{"$set": bson.M{"total": total, "dimulai_pada": mulai}}); err != nil {
+			gagalkanTransisi(ctx, transisiID, "failed to save cohort: "+err.Error())
+			return
+		}
+		fmt.Println("Jenis_user transition", transisi.Nama, "started for", total, "users")
+	}
+
+	err = prosesHasilTransisi(ctx, transisiID, []string{HasilTransisiPending, HasilTransisiFailed}, func(hasil model.HasilTransisi) error {
+		return pindahkanUserTransisi(ctx, transisi, jenisUser, hasil)
+	})
+	if err != nil {
+		gagalkanTransisi(ctx, transisiID, err.Error())
+		return
+	}
+
+	selesai := time.Now()
+	if _, err := TransisiCollection.UpdateOne(ctx, bson.M{"_id": transisiID}, bson.M{"$set": bson.M{
+		"status":       StatusTransisiCompleted,
+		"selesai_pada": selesai,
+		"updated_at":   selesai,
+	}}); err != nil {
+		fmt.Println("Error completing jenis_user transition:", err)
+	}
+}
+
+// Jalankan rollback transisi yang berstatus rolling_back. User yang sebelumnya gagal
+// dikembalikan dicoba lagi.
+func jalankanRollbackTransisi(transisiID primitive.ObjectID) {
+	if _, berjalan := transisiBerjalan.LoadOrStore(transisiID, true); berjalan {
+		return
+	}
+	defer transisiBerjalan.Delete(transisiID)
+
+	ctx := context.Background()
+
+	var transisi model.TransisiJenisUser
+	err := TransisiCollection.FindOne(ctx, bson.M{"_id": transisiID, "status": StatusTransisiRollingBack}).Decode(&transisi)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			fmt.Println("Error starting jenis_user transition rollback", transisiID.Hex(), ":", err)
+		}
+		return
+	}
+
+	err = prosesHasilTransisi(ctx, transisiID, []string{HasilTransisiMoved, HasilTransisiRollbackFailed}, func(hasil model.HasilTransisi) error {
+		return kembalikanUserTransisi(ctx, transisi, hasil)
+	})
+	if err != nil {
+		gagalkanTransisi(ctx, transisiID, "rollback stopped: "+err.Error())
+		return
+	}
+
+	selesai := time.Now()
+	if _, err := TransisiCollection.UpdateOne(ctx, bson.M{"_id": transisiID}, bson.M{"$set": bson.M{
+		"status":        StatusTransisiRolledBack,
+		"rollback_pada": selesai,
+		"updated_at":    selesai,
+	}}); err != nil {
+		fmt.Println("Error completing jenis_user transition rollback:", err)
+	}
+}
+
+// Jalankan transisi terjadwal yang waktunya sudah tiba
+func jalankanTransisiJatuhTempo() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := TransisiCollection.Find(ctx, bson.M{"status": StatusTransisiScheduled, "jadwal": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		fmt.Println("Error fetching scheduled jenis_user transitions:", err)
+		return
+	}
+	var daftar []model.TransisiJenisUser
+	if err := cursor.All(ctx, &daftar); err != nil {
+		fmt.Println("Error decoding scheduled jenis_user transitions:", err)
+		return
+	}
+	for _, transisi := range daftar {
+		go jalankanTransisi(transisi.ID, StatusTransisiScheduled)
+	}
+}
+
+// Lanjutkan transisi dan rollback yang terhenti saat server mati
+func lanjutkanTransisiTertunda() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := TransisiCollection.Find(ctx, bson.M{"status": bson.M{"$in": bson.A{StatusTransisiRunning, StatusTransisiRollingBack}}})
+	if err != nil {
+		fmt.Println("Error fetching unfinished jenis_user transitions:", err)
+		return
+	}
+	var daftar []model.TransisiJenisUser
+	if err := cursor.All(ctx, &daftar); err != nil {
+		fmt.Println("Error decoding unfinished jenis_user transitions:", err)
+		return
+	}
+	for _, transisi := range daftar {
+		fmt.Println("Resuming jenis_user transition", transisi.Nama, "("+transisi.Status+")")
+		if transisi.Status == StatusTransisiRollingBack {
+			go jalankanRollbackTransisi(transisi.ID)
+		} else {
+			go jalankanTransisi(transisi.ID, StatusTransisiRunning)
+		}
+	}
+}
+
+// MulaiTransisiTerjadwal melanjutkan transisi yang terhenti lalu memeriksa jadwal transisi
+// di background. Dipanggil sekali saat aplikasi mulai.
+func MulaiTransisiTerjadwal() {
+	lanjutkanTransisiTertunda()
+	go func() {
+		for {
+			jalankanTransisiJatuhTempo()
+			time.Sleep(intervalCekTransisi)
+		}
+	}()
+}
+
+// Ambil transisi dari parameter :transisiId beserta status HTTP jika gagal
+func cariTransisi(ctx context.Context, c *fiber.Ctx) (model.TransisiJenisUser, int, error) {
+	var transisi model.TransisiJenisUser
+	transisiID, err := primitive.ObjectIDFromHex(c.Params("transisiId"))
+	if err != nil {
+		return transisi, http.StatusBadRequest, fmt.Errorf("Invalid transition ID")
+	}
+	err = TransisiCollection.FindOne(ctx, bson.M{"_id": transisiID}).Decode(&transisi)
+	if err == mongo.ErrNoDocuments {
+		return transisi, http.StatusNotFound, fmt.Errorf("Transition not found")
+	}
+	if err != nil {
+		return transisi, http.StatusInternalServerError, fmt.Errorf("Failed to fetch transition")
+	}
+	return transisi, http.StatusOK, nil
+}
+
+// Isi aturan transisi dari body request
+type TransisiRequest struct {
+	Nama   string               `json:"nama"`
+	Dari   string               `json:"dari"`
+	Ke     string               `json:"ke"`
+	Kohort model.KohortTransisi `json:"kohort"`
+	Jadwal time.Time            `json:"jadwal"`
+}
+
+func (req TransisiRequest) terapkan(transisi *model.TransisiJenisUser) {
+	transisi.Nama = strings.TrimSpace(req.Nama)
+	transisi.Dari = strings.TrimSpace(req.Dari)
+	transisi.Ke = strings.TrimSpace(req.Ke)
+	transisi.Kohort = req.Kohort
+	transisi.Jadwal = req.Jadwal
+}
+
+// GetTransisi - Daftar transisi jenis_user terbaru
+func GetTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if status := nilaiQuery(c, "status"); len(status) > 0 {
+		filter["status"] = bson.M{"$in": status}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "jadwal", Value: -1}}).SetLimit(50)
+	cursor, err := TransisiCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transitions"})
+	}
+	daftar := []model.TransisiJenisUser{}
+	if err := cursor.All(ctx, &daftar); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode transitions"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"transitions": daftar, "total_count": len(daftar)})
+}
+
+// CreateTransisi - Jadwalkan transisi jenis_user untuk satu kohort
+func CreateTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var req TransisiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	transisi := model.TransisiJenisUser{ID: primitive.NewObjectID(), Status: StatusTransisiScheduled}
+	req.terapkan(&transisi)
+	if status, err := cekTransisi(ctx, transisi); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	transisi.CreatedBy = aktorDari(c)
+	transisi.CreatedAt = time.Now()
+	transisi.UpdatedAt = time.Now()
+	if _, err := TransisiCollection.InsertOne(ctx, transisi); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create transition"})
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"message": "Transition scheduled", "transition": transisi})
+}
+
+// GetTransisiByID - Status dan progress satu transisi
+func GetTransisiByID(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transisi, status, err := cariTransisi(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Saat rollback, progress dihitung dari user yang sudah berhasil dipindahkan
+	selesai, total := transisi.Diproses, transisi.Total
+	if transisi.Status == StatusTransisiRollingBack || transisi.Status == StatusTransisiRolledBack {
+		selesai, total = transisi.DiRollback+transisi.RollbackDilewati+transisi.RollbackGagal, transisi.Berhasil
+	}
+	progress := 0.0
+	if total > 0 {
+		progress = float64(selesai) / float64(total) * 100
+		if progress > 100 {
+			progress = 100
+		}
+	}
+	_, berjalan := transisiBerjalan.Load(transisi.ID)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"transition": transisi,
+		"progress":   progress,
+		"berjalan":   berjalan,
+	})
+}
+
+// UpdateTransisi - Ubah transisi yang belum berjalan
+func UpdateTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transisi, status, err := cariTransisi(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req TransisiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.terapkan(&transisi)
+	if status, err := cekTransisi(ctx, transisi); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	transisi.UpdatedAt = time.Now()
+	result, err := TransisiCollection.ReplaceOne(ctx, bson.M{"_id": transisi.ID, "status": StatusTransisiScheduled}, transisi)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update transition"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Only scheduled transitions can be changed"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Transition updated", "transition": transisi})
+}
+
+// DeleteTransisi - Batalkan transisi yang belum berjalan. Transisi yang sudah berjalan
+// disimpan sebagai catatan dan dibatalkan lewat rollback.
+func DeleteTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transisi, status, err := cariTransisi(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	result, err := TransisiCollection.DeleteOne(ctx, bson.M{"_id": transisi.ID, "status": StatusTransisiScheduled})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete transition"})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Only scheduled transitions can be deleted, use rollback for transitions that already ran"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Transition deleted"})
+}
+
+// PreviewTransisi - Jumlah dan contoh user yang saat ini masuk kohort transisi
+func PreviewTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transisi, status, err := cariTransisi(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter := filterKohort(transisi)
+	total, err := userCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count cohort"})
+	}
+	cursor, err := userCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "username", Value: 1}}).SetLimit(50))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch cohort"})
+	}
+	var users []model.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode cohort"})
+	}
+	contoh := make([]responses.UserDTO, 0, len(users))
+	for _, user := range users {
+		contoh = append(contoh, responses.NewUserDTO(user))
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"total": total, "users": contoh})
+}
+
+// RunTransisi - Jalankan transisi sekarang tanpa menunggu jadwal, atau lanjutkan transisi yang gagal
+func RunTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transisi, status, err := cariTransisi(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, berjalan := transisiBerjalan.Load(transisi.ID); berjalan {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Transition is already running"})
+	}
+	if transisi.Status != StatusTransisiScheduled && transisi.Status != StatusTransisiFailed {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Only scheduled or failed transitions can be run, status is " + transisi.Status})
+	}
+
+	go jalankanTransisi(transisi.ID, StatusTransisiScheduled, StatusTransisiFailed)
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"message": "Transition started"})
+}
+
+// RollbackTransisi - Kembalikan semua user yang sudah dipindahkan ke jenis_user asal
+func RollbackTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transisi, status, err := cariTransisi(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, berjalan := transisiBerjalan.Load(transisi.ID); berjalan {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Transition is running, wait until it finishes"})
+	}
+
+	// Rollback yang sudah selesai boleh diulang untuk mencoba lagi user yang gagal dikembalikan
+	result, err := TransisiCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": transisi.ID, "status": bson.M{"$in": bson.A{StatusTransisiCompleted, StatusTransisiFailed, StatusTransisiRolledBack}}},
+		bson.M{
+			"$set":   bson.M{"status": StatusTransisiRollingBack, "rollback_by": aktorDari(c), "updated_at": time.Now()},
+			"$unset": bson.M{"pesan": ""},
+		},
+	)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start rollback"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "Only completed or failed transitions can be rolled back, status is " + transisi.Status})
+	}
+
+	go jalankanRollbackTransisi(transisi.ID)
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{"message": "Rollback started"})
+}
+
+// GetHasilTransisi - Hasil per user satu transisi, berhalaman dan bisa difilter ?status=
+func GetHasilTransisi(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transisi, status, err := cariTransisi(ctx, c)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter := bson.M{"transisi_id": transisi.ID}
+	if status := nilaiQuery(c, "status"); len(status) > 0 {
+		filter["status"] = bson.M{"$in": status}
+	}
+
+	halaman, err := parseHalaman(c, hasilTransisiSortFields, "_id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	hasil, err := cariHalaman(ctx, c, HasilTransisiCollection, filter, halaman)
+	if err == errCursorTidakValid {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("Error fetching transition results:", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transition results"})
+	}
+
+	daftar := make([]model.HasilTransisi, 0, len(hasil.Dokumen))
+	for _, dokumen := range hasil.Dokumen {
+		var item model.HasilTransisi
+		if err := bson.Unmarshal(dokumen, &item); err != nil {
+			fmt.Println("Error decoding transition results:", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode transition results"})
+		}
+		daftar = append(daftar, item)
+	}
+
+	return c.Status(http.StatusOK).JSON(responses.PaginatedResponse{
+		Status:  http.StatusOK,
+		Message: "success",
+		Data:    daftar,
+		Meta:    hasil.Meta,
+		Links:   hasil.Links,
+	})
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"demoapp/model"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSelisihID(t *testing.T) {
+	id1, id2, id3 := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	tests := []struct {
+		nama string
+		a, b []primitive.ObjectID
+		want []primitive.ObjectID
+	}{
+		{"keduanya kosong", nil, nil, []primitive.ObjectID{}},
+		{"b kosong", []primitive.ObjectID{id1, id2}, nil, []primitive.ObjectID{id1, id2}},
+		{"a kosong", nil, []primitive.ObjectID{id1}, []primitive.ObjectID{}},
+		{"sebagian sama", []primitive.ObjectID{id1, id2, id3}, []primitive.ObjectID{id2}, []primitive.ObjectID{id1, id3}},
+		{"semua sama", []primitive.ObjectID{id1, id2}, []primitive.ObjectID{id2, id1}, []primitive.ObjectID{}},
+		{"urutan a dipertahankan", []primitive.ObjectID{id3, id1}, []primitive.ObjectID{id2}, []primitive.ObjectID{id3, id1}},
+		{"duplikat di a tetap muncul", []primitive.ObjectID{id1, id1}, []primitive.ObjectID{id2}, []primitive.ObjectID{id1, id1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := selisihID(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selisihID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterKohort(t *testing.T) {
+	userID := primitive.NewObjectID()
+	tests := []struct {
+		nama   string
+		kohort model.KohortTransisi
+		want   bson.M
+	}{
+		{
+			nama:   "tanpa kriteria tambahan",
+			kohort: model.KohortTransisi{},
+			want:   bson.M{"deleted_at": nil, "jenis_user": "mahasiswa"},
+		},
+		{
+			nama:   "angkatan dan sumber sync",
+			kohort: model.KohortTransisi{Angkatan: []int{2020, 2021}, SumberSync: "siakad"},
+			want: bson.M{
+				"deleted_at":  nil,
+				"jenis_user":  "mahasiswa",
+				"angkatan":    bson.M{"$in": []int{2020, 2021}},
+				"sumber_sync": "siakad",
+			},
+		},
+		{
+			nama:   "status active mencakup user tanpa status",
+			kohort: model.KohortTransisi{Status: []string{model.StatusActive, model.StatusGraduated}},
+			want: bson.M{
+				"deleted_at": nil,
+				"jenis_user": "mahasiswa",
+				"status":     bson.M{"$in": bson.A{model.StatusActive, nil, model.StatusGraduated}},
+			},
+		},
+		{
+			nama:   "daftar user",
+			kohort: model.KohortTransisi{UserIDs: []primitive.ObjectID{userID}},
+			want: bson.M{
+				"deleted_at": nil,
+				"jenis_user": "mahasiswa",
+				"_id":        bson.M{"$in": []primitive.ObjectID{userID}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			transisi := model.TransisiJenisUser{Dari: "mahasiswa", Ke: "alumni", Kohort: tt.kohort}
+			if got := filterKohort(transisi); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterKohort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"demoapp/config"
 	"demoapp/model"
 	"demoapp/responses"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -48,9 +51,31 @@ func CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// User baru hanya boleh berstatus pending atau active, bawaan active
+	if user.Status == "" {
+		user.Status = model.StatusActive
+	}
+	if user.Status != model.StatusActive && user.Status != model.StatusPending {
+		return c.Status(http.StatusBadRequest).JSON(responses.UserResponse{
+			Status:  http.StatusBadRequest,
+			Message: "error",
+			Data:    &fiber.Map{"error": "status of a new user must be pending or active"},
+		})
+	}
+
+	// Validasi jenis_user terhadap katalog
+	jenisUser, err := cariJenisUser(ctx, user.JenisUser)
+	if err != nil {
+		return c.Status(statusJenisUserError(err)).JSON(responses.UserResponse{
+			Status:  statusJenisUserError(err),
+			Message: "error",
+			Data:    &fiber.Map{"error": err.Error()},
+		})
+	}
+
 	// Periksa apakah username sudah ada di koleksi
 	var existingUser model.User
-	err := userCollection.FindOne(ctx, bson.M{"username": user.Username}).Decode(&existingUser)
+	err = userCollection.FindOne(ctx, bson.M{"username": user.Username}).Decode(&existingUser)
 	if err == nil {
 		// Jika username sudah ada
 		return c.Status(http.StatusConflict).JSON(responses.UserResponse{
@@ -91,10 +116,21 @@ func CreateUser(c *fiber.Ctx) error {
 		Phone:        user.Phone,
 		Token:        user.Token,
 		JenisUser:    user.JenisUser,
+		Status:       user.Status,
 	}
+	newUser.SearchNgram = ngramUser(newUser)
+	newUser.Version = 1
 
 	// Masukkan user baru ke koleksi MongoDB
 	result, err := userCollection.InsertOne(ctx, newUser)
+	if field := fieldDuplikatUser(err); field != "" {
+		// Username atau email dipakai request lain yang berjalan bersamaan
+		return c.Status(http.StatusConflict).JSON(responses.UserResponse{
+			Status:  http.StatusConflict,
+			Message: "error",
+			Data:    &fiber.Map{"error": pesanDuplikatUser(field)},
+		})
+	}
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
 			Status:  http.StatusInternalServerError,
@@ -102,6 +138,16 @@ func CreateUser(c *fiber.Ctx) error {
 			Data:    &fiber.Map{"error": err.Error()},
 		})
 	}
+	catatHistori(ctx, userCollection, AksiCreate, aktorDari(c), newUser.ID)
+
+	// Masukkan user ke bundle modul jenis_user-nya
+	if err := masukkanKeBundle(ctx, newUser.ID, jenisUser, aktorDari(c)); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "error",
+			Data:    &fiber.Map{"error": "Failed to add user to jenis_user bundle: " + err.Error()},
+		})
+	}
 
 	// Berikan respons sukses dengan ID user baru
 	return c.Status(http.StatusCreated).JSON(responses.UserResponse{
@@ -136,9 +182,18 @@ func GetAUser(c *fiber.Ctx) error {
 		})
 	}
 
+	opsi, err := parseOpsiUser(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responses.UserResponse{
+			Status:  http.StatusBadRequest,
+			Message: "error",
+			Data:    &fiber.Map{"error": err.Error()},
+		})
+	}
+
 	// Cari user berdasarkan ID di MongoDB
 	var user model.User
-	err = userCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&user)
+	err = userCollection.FindOne(ctx, bson.M{"_id": objId, "deleted_at": nil}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// Jika user tidak ditemukan
@@ -157,11 +212,26 @@ func GetAUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Modul efektif bergantung pada usermodul, grup, deny dan modul yang tidak menaikkan
+	// versi user, sehingga response dengan ?include=moduls tidak diberi ETag dan tidak dijawab 304
+	if !opsi.Moduls && belumBerubah(c, user.Version) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	data, err := opsi.representasi(ctx, user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "error",
+			Data:    &fiber.Map{"error": "Error building user response: " + err.Error()},
+		})
+	}
+
 	// Berikan respons sukses dengan data user
 	return c.Status(http.StatusOK).JSON(responses.UserResponse{
 		Status:  http.StatusOK,
 		Message: "success",
-		Data:    &fiber.Map{"user": user},
+		Data:    &fiber.Map{"user": data},
 	})
 }
 
@@ -190,16 +260,6 @@ func EditAUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Parsing body dan validasi input
-	var userUpdates map[string]interface{}
-	if err := c.BodyParser(&userUpdates); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responses.UserResponse{
-			Status:  http.StatusBadRequest,
-			Message: "error",
-			Data:    &fiber.Map{"error": "Invalid request body format. " + err.Error()},
-		})
-	}
-
 	// Filter field yang diizinkan untuk diupdate
 	allowedFields := map[string]bool{
 		"username":      true,
@@ -211,27 +271,125 @@ func EditAUser(c *fiber.Ctx) error {
 		"phone":         true,
 		"token":         true,
 		"jenis_user":    true,
+		"angkatan":      true,
 	}
 
-	// Buat objek update yang valid
-	update := bson.M{}
-	for key, value := range userUpdates {
-		if allowedFields[key] {
-			update[key] = value
+	return perbaruiUser(ctx, c, objId, allowedFields)
+}
+
+// Update field user yang diizinkan, dipakai oleh admin maupun user sendiri (/me).
+// Body didecode ke model.User sehingga tipe dan tag validator berlaku seperti pada patchUser.
+func perbaruiUser(ctx context.Context, c *fiber.Ctx, objId primitive.ObjectID, allowedFields map[string]bool) error {
+	filter := bson.M{"_id": objId, "deleted_at": nil}
+	var user model.User
+	if err := userCollection.FindOne(ctx, filter).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(responses.UserResponse{
+				Status:  http.StatusNotFound,
+				Message: "error",
+				Data:    &fiber.Map{"error": "User not found"},
+			})
 		}
+		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "error",
+			Data:    &fiber.Map{"error": "Error fetching user: " + err.Error()},
+		})
+	}
+	if !cocokIfMatch(c, user.Version) {
+		c.Set(fiber.HeaderETag, etagVersi(user.Version))
+		return c.Status(http.StatusPreconditionFailed).JSON(responses.UserResponse{
+			Status:  http.StatusPreconditionFailed,
+			Message: "error",
+			Data:    &fiber.Map{"error": "User has been modified by another request, fetch the latest version and retry"},
+		})
 	}
 
-	// Pastikan ada field yang diupdate
-	if len(update) == 0 {
-		return c.Status(http.StatusBadRequest).JSON(responses.UserResponse{
-			Status:  http.StatusBadRequest,
+	lama := user
+	set, err := terapkanUpdate(c.Body(), &user, allowedFields, validate)
+	if err != nil {
+		var errPatch *errorPatch
+		if !errors.As(err, &errPatch) {
+			return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "error",
+				Data:    &fiber.Map{"error": err.Error()},
+			})
+		}
+		data := fiber.Map{"error": errPatch.Pesan}
+		if len(errPatch.Fields) > 0 {
+			data["fields"] = errPatch.Fields
+		}
+		return c.Status(errPatch.Status).JSON(responses.UserResponse{
+			Status:  errPatch.Status,
 			Message: "error",
-			Data:    &fiber.Map{"error": "No valid fields to update"},
+			Data:    &data,
+		})
+	}
+	if len(set) == 0 {
+		c.Set(fiber.HeaderETag, etagVersi(user.Version))
+		return c.Status(http.StatusOK).JSON(responses.UserResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    &fiber.Map{"user": responses.NewUserDTO(user)},
 		})
 	}
 
-	// Update dokumen di database
-	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	// Username dan email dicek lebih dulu agar pesannya jelas walaupun index unik belum terpasang
+	for _, field := range []struct {
+		Nama   string
+		Lama   string
+		Baru   string
+		Syarat bson.M
+	}{
+		{"username", lama.Username, user.Username, bson.M{"username": user.Username}},
+		{"email", lama.Email, user.Email, bson.M{"email": user.Email, "deleted_at": nil}},
+	} {
+		if field.Baru == field.Lama {
+			continue
+		}
+		field.Syarat["_id"] = bson.M{"$ne": objId}
+		jumlah, err := userCollection.CountDocuments(ctx, field.Syarat)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "error",
+				Data:    &fiber.Map{"error": "Error checking " + field.Nama + ": " + err.Error()},
+			})
+		}
+		if jumlah > 0 {
+			return c.Status(http.StatusConflict).JSON(responses.UserResponse{
+				Status:  http.StatusConflict,
+				Message: "error",
+				Data:    &fiber.Map{"error": pesanDuplikatUser(field.Nama)},
+			})
+		}
+	}
+
+	// Validasi jenis_user terhadap katalog
+	var jenisUser *model.JenisUser
+	if user.JenisUser != lama.JenisUser {
+		found, err := cariJenisUser(ctx, user.JenisUser)
+		if err != nil {
+			return c.Status(statusJenisUserError(err)).JSON(responses.UserResponse{
+				Status:  statusJenisUserError(err),
+				Message: "error",
+				Data:    &fiber.Map{"error": err.Error()},
+			})
+		}
+		jenisUser = &found
+	}
+
+	// Update dokumen di database hanya jika versinya masih sama dengan yang divalidasi
+	filter["version"] = syaratVersi(user.Version)
+	result, err := userCollection.UpdateOne(ctx, filter, bson.M{"$set": set, "$inc": naikkanVersi})
+	if field := fieldDuplikatUser(err); field != "" {
+		return c.Status(http.StatusConflict).JSON(responses.UserResponse{
+			Status:  http.StatusConflict,
+			Message: "error",
+			Data:    &fiber.Map{"error": pesanDuplikatUser(field)},
+		})
+	}
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
 			Status:  http.StatusInternalServerError,
@@ -240,8 +398,24 @@ func EditAUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Jika tidak ada dokumen yang cocok, kembalikan error
+	// Jika tidak ada dokumen yang cocok, user sudah dihapus atau diubah request lain
 	if result.MatchedCount == 0 {
+		delete(filter, "version")
+		berubah, err := versiBerubah(ctx, c, userCollection, filter)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "error",
+				Data:    &fiber.Map{"error": "Error fetching user: " + err.Error()},
+			})
+		}
+		if berubah {
+			return c.Status(http.StatusPreconditionFailed).JSON(responses.UserResponse{
+				Status:  http.StatusPreconditionFailed,
+				Message: "error",
+				Data:    &fiber.Map{"error": "User has been modified by another request, fetch the latest version and retry"},
+			})
+		}
 		return c.Status(http.StatusNotFound).JSON(responses.UserResponse{
 			Status:  http.StatusNotFound,
 			Message: "error",
@@ -249,6 +423,17 @@ func EditAUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Pindahkan keanggotaan usermodul jika jenis_user berubah
+	if jenisUser != nil {
+		if err := pindahkanJenisUser(ctx, objId, *jenisUser, aktorDari(c)); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "error",
+				Data:    &fiber.Map{"error": err.Error()},
+			})
+		}
+	}
+
 	// Ambil detail user yang sudah diperbarui
 	var updatedUser model.User
 	err = userCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedUser)
@@ -260,23 +445,150 @@ func EditAUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Berikan respons sukses dengan data user yang diperbarui
+	// Index pencarian hanya pelengkap, kegagalan cukup dicatat
+	if err := perbaruiNgramUser(ctx, updatedUser); err != nil {
+		fmt.Println("Error updating search index:", err)
+	}
+	catatHistori(ctx, userCollection, AksiUpdate, aktorDari(c), objId)
+
+	// Berikan respons sukses dengan data user yang diperbarui, tanpa field rahasia
+	c.Set(fiber.HeaderETag, etagVersi(updatedUser.Version))
 	return c.Status(http.StatusOK).JSON(responses.UserResponse{
 		Status:  http.StatusOK,
 		Message: "success",
-		Data:    &fiber.Map{"user": updatedUser},
+		Data:    &fiber.Map{"user": responses.NewUserDTO(updatedUser)},
 	})
 }
 
-// DeleteAUser - Delete a single user by ID
+// Field user yang boleh diubah admin lewat PATCH
+var userPatchFields = map[string]bool{
+	"username":      true,
+	"nm_user":       true,
+	"email":         true,
+	"role":          true,
+	"jenis_kelamin": true,
+	"photo":         true,
+	"phone":         true,
+	"jenis_user":    true,
+	"angkatan":      true,
+}
+
+// PatchAUser - Ubah user dengan JSON Merge Patch (RFC 7396) atau JSON Patch (RFC 6902)
+func PatchAUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objId, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID format"})
+	}
+	return patchUser(ctx, c, objId, userPatchFields)
+}
+
+// Terapkan PATCH ke user, dipakai oleh admin maupun user sendiri (/me).
+// Update hanya berlaku jika versi user belum berubah sejak dibaca.
+func patchUser(ctx context.Context, c *fiber.Ctx, objId primitive.ObjectID, allowedFields map[string]bool) error {
+	filter := bson.M{"_id": objId, "deleted_at": nil}
+	var user model.User
+	if err := userCollection.FindOne(ctx, filter).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching user: " + err.Error()})
+	}
+	if !cocokIfMatch(c, user.Version) {
+		c.Set(fiber.HeaderETag, etagVersi(user.Version))
+		return responsVersiBerubah(c)
+	}
+
+	jenisUserLama := user.JenisUser
+	set, err := terapkanPatch(c, &user, allowedFields, validate)
+	if err != nil {
+		return responsPatchError(c, err)
+	}
+	if len(set) == 0 {
+		c.Set(fiber.HeaderETag, etagVersi(user.Version))
+		return c.Status(http.StatusOK).JSON(fiber.Map{"message": "No changes", "user": responses.NewUserDTO(user)})
+	}
+
+	// Validasi jenis_user terhadap katalog
+	var jenisUser *model.JenisUser
+	if user.JenisUser != jenisUserLama {
+		found, err := cariJenisUser(ctx, user.JenisUser)
+		if err != nil {
+			return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		jenisUser = &found
+	}
+
+	filter["version"] = syaratVersi(user.Version)
+	result, err := userCollection.UpdateOne(ctx, filter, bson.M{"$set": set, "$inc": naikkanVersi})
+	if field := fieldDuplikatUser(err); field != "" {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": pesanDuplikatUser(field)})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error updating user: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		delete(filter, "version")
+		berubah, err := versiBerubah(ctx, c, userCollection, filter)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching user: " + err.Error()})
+		}
+		if !berubah {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return responsVersiBerubah(c)
+	}
+
+	// Pindahkan keanggotaan usermodul jika jenis_user berubah
+	if jenisUser != nil {
+		if err := pindahkanJenisUser(ctx, objId, *jenisUser, aktorDari(c)); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	var updatedUser model.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedUser); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching updated user: " + err.Error()})
+	}
+
+	// Index pencarian hanya pelengkap, kegagalan cukup dicatat
+	if err := perbaruiNgramUser(ctx, updatedUser); err != nil {
+		fmt.Println("Error updating search index:", err)
+	}
+	catatHistori(ctx, userCollection, AksiUpdate, aktorDari(c), objId)
+
+	c.Set(fiber.HeaderETag, etagVersi(updatedUser.Version))
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User updated successfully", "user": responses.NewUserDTO(updatedUser)})
+}
+
+// DeleteAUser - Soft delete user: user ditandai terhapus dan disembunyikan dari semua query.
+// Data baru benar-benar dihapus oleh purge setelah masa retensi habis.
 func DeleteAUser(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	userId := c.Params("userId")
 	defer cancel()
 
-	objId, _ := primitive.ObjectIDFromHex(userId)
+	objId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responses.UserResponse{
+			Status:  http.StatusBadRequest,
+			Message: "error",
+			Data:    &fiber.Map{"data": "Invalid User ID"},
+		})
+	}
 
-	result, err := userCollection.DeleteOne(ctx, bson.M{"_id": objId})
+	sekarang := primitive.NewDateTimeFromTime(time.Now())
+	result, err := userCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": objId, "deleted_at": nil},
+		bson.M{
+			"$set":   bson.M{"deleted_at": sekarang, "deleted_by": aktorDari(c)},
+			"$unset": bson.M{"token": ""},
+			"$inc":   naikkanVersi,
+		},
+	)
 
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
@@ -286,43 +598,274 @@ func DeleteAUser(c *fiber.Ctx) error {
 		})
 	}
 
-	if result.DeletedCount < 1 {
+	if result.MatchedCount < 1 {
 		return c.Status(http.StatusNotFound).JSON(responses.UserResponse{
 			Status:  http.StatusNotFound,
 			Message: "error",
 			Data:    &fiber.Map{"data": "User with specified ID not found!"},
 		})
 	}
+	catatHistori(ctx, userCollection, AksiDelete, aktorDari(c), objId)
+
+	return c.Status(http.StatusOK).JSON(responses.UserResponse{
+		Status:  http.StatusOK,
+		Message: "success",
+		Data:    &fiber.Map{"data": "User successfully deleted!", "purge_after": sekarang.Time().Add(retensiUser())},
+	})
+}
+
+// RestoreAUser - Kembalikan user yang sudah di-soft delete dan belum di-purge
+func RestoreAUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objId, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responses.UserResponse{
+			Status:  http.StatusBadRequest,
+			Message: "error",
+			Data:    &fiber.Map{"data": "Invalid User ID"},
+		})
+	}
+
+	// Akun hasil merge sudah dipindahkan ke user lain dan tidak bisa dipulihkan
+	merged, err := userCollection.CountDocuments(ctx, bson.M{"_id": objId, "merged_into": bson.M{"$exists": true}})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "error",
+			Data:    &fiber.Map{"data": err.Error()},
+		})
+	}
+	if merged > 0 {
+		return c.Status(http.StatusConflict).JSON(responses.UserResponse{
+			Status:  http.StatusConflict,
+			Message: "error",
+			Data:    &fiber.Map{"data": "User has been merged into another account and cannot be restored"},
+		})
+	}
+
+	result, err := userCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": objId, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{
+			"$set":   bson.M{"deleted_at": nil},
+			"$unset": bson.M{"deleted_by": ""},
+			"$inc":   naikkanVersi,
+		},
+	)
+	if field := fieldDuplikatUser(err); field != "" {
+		// Email sudah dipakai user aktif lain selama user ini dihapus
+		return c.Status(http.StatusConflict).JSON(responses.UserResponse{
+			Status:  http.StatusConflict,
+			Message: "error",
+			Data:    &fiber.Map{"data": pesanDuplikatUser(field)},
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "error",
+			Data:    &fiber.Map{"data": err.Error()},
+		})
+	}
+	if result.MatchedCount < 1 {
+		return c.Status(http.StatusNotFound).JSON(responses.UserResponse{
+			Status:  http.StatusNotFound,
+			Message: "error",
+			Data:    &fiber.Map{"data": "Deleted user with specified ID not found!"},
+		})
+	}
+	catatHistori(ctx, userCollection, AksiRestore, aktorDari(c), objId)
+
+	var user model.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&user); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "error",
+			Data:    &fiber.Map{"data": "Error fetching restored user: " + err.Error()},
+		})
+	}
 
 	return c.Status(http.StatusOK).JSON(responses.UserResponse{
 		Status:  http.StatusOK,
 		Message: "success",
-		Data:    &fiber.Map{"data": "User successfully deleted!"},
+		Data:    &fiber.Map{"user": responses.NewUserDTO(user)},
 	})
 }
 
-// GetUsers - Get all users
+// Field user yang boleh dipakai untuk sort
+var userSortFields = map[string]bool{
+	"username":      true,
+	"nm_user":       true,
+	"email":         true,
+	"role":          true,
+	"status":        true,
+	"jenis_user":    true,
+	"jenis_kelamin": true,
+	"created_at":    true,
+}
+
+// Pisahkan nilai query yang dipisah koma, misalnya role=admin,civitas
+func nilaiQuery(c *fiber.Ctx, kunci string) []string {
+	var hasil []string
+	for _, nilai := range strings.Split(c.Query(kunci), ",") {
+		if nilai = strings.TrimSpace(nilai); nilai != "" {
+			hasil = append(hasil, nilai)
+		}
+	}
+	return hasil
+}
+
+// Baca waktu dari query, boleh RFC3339 atau tanggal saja (2006-01-02)
+func waktuQuery(nilai string) (t time.Time, tanggalSaja bool, err error) {
+	if t, err = time.Parse(time.RFC3339, nilai); err == nil {
+		return t, false, nil
+	}
+	if t, err = time.Parse("2006-01-02", nilai); err == nil {
+		return t, true, nil
+	}
+	return t, false, err
+}
+
+// Bangun filter user dari query string:
+// role, status, jenis_user, angkatan, jenis_kelamin (boleh beberapa nilai dipisah koma), created_from dan created_to.
+// User yang sudah dihapus hanya tampil dengan deleted=true.
+func userFilterFromQuery(c *fiber.Ctx) (bson.M, error) {
+	filter := bson.M{"deleted_at": nil}
+	if nilai := c.Query("deleted"); nilai != "" {
+		deleted, err := strconv.ParseBool(nilai)
+		if err != nil {
+			return nil, fmt.Errorf("deleted must be true or false")
+		}
+		if deleted {
+			filter["deleted_at"] = bson.M{"$ne": nil}
+		}
+	}
+
+	if roles := nilaiQuery(c, "role"); len(roles) > 0 {
+		filter["role"] = bson.M{"$in": roles}
+	}
+	if statuses := nilaiQuery(c, "status"); len(statuses) > 0 {
+		nilai := bson.A{}
+		for _, status := range statuses {
+			if _, ok := model.TransisiStatusUser[status]; !ok {
+				return nil, fmt.Errorf("invalid status: %s", status)
+			}
+			nilai = append(nilai, status)
+			// User lama tanpa field status dianggap active
+			if status == model.StatusActive {
+				nilai = append(nilai, nil)
+			}
+		}
+		filter["status"] = bson.M{"$in": nilai}
+	}
+	if jenisUsers := nilaiQuery(c, "jenis_user"); len(jenisUsers) > 0 {
+		filter["jenis_user"] = bson.M{"$in": jenisUsers}
+	}
+	if nilai := nilaiQuery(c, "angkatan"); len(nilai) > 0 {
+		angkatan := []int{}
+		for _, n := range nilai {
+			tahun, err := strconv.Atoi(n)
+			if err != nil {
+				return nil, fmt.Errorf("invalid angkatan: %s", n)
+			}
+			angkatan = append(angkatan, tahun)
+		}
+		filter["angkatan"] = bson.M{"$in": angkatan}
+	}
+	if nilai := nilaiQuery(c, "jenis_kelamin"); len(nilai) > 0 {
+		jenisKelamin := []int{}
+		for _, n := range nilai {
+			jk, err := strconv.Atoi(n)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jenis_kelamin: %s", n)
+			}
+			jenisKelamin = append(jenisKelamin, jk)
+		}
+		filter["jenis_kelamin"] = bson.M{"$in": jenisKelamin}
+	}
+
+	createdAt := bson.M{}
+	if nilai := c.Query("created_from"); nilai != "" {
+		dari, _, err := waktuQuery(nilai)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_from: %s", nilai)
+		}
+		createdAt["$gte"] = dari
+	}
+	if nilai := c.Query("created_to"); nilai != "" {
+		sampai, tanggalSaja, err := waktuQuery(nilai)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_to: %s", nilai)
+		}
+		// Tanggal saja berarti sampai akhir hari tersebut
+		if tanggalSaja {
+			createdAt["$lt"] = sampai.AddDate(0, 0, 1)
+		} else {
+			createdAt["$lte"] = sampai
+		}
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	return filter, nil
+}
+
+// GetUsers - Daftar user berhalaman (offset atau cursor) dengan filter, sort dan total
 func GetUsers(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var users []model.User
-	cursor, err := userCollection.Find(ctx, bson.M{})
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	halaman, err := parseHalaman(c, userSortFields, "_id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	opsi, err := parseOpsiUser(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	hasil, err := cariHalaman(ctx, c, userCollection, filter, halaman)
+	if err == errCursorTidakValid {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		// Log error if there's an issue with the MongoDB query
 		fmt.Println("Error fetching users:", err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch users from DB"})
 	}
-	defer cursor.Close(ctx)
 
-	err = cursor.All(ctx, &users)
-	if err != nil {
-		// Log error if there's an issue decoding the results
-		fmt.Println("Error decoding users:", err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to decode users"})
+	users := make([]interface{}, 0, len(hasil.Dokumen))
+	for _, dokumen := range hasil.Dokumen {
+		var user model.User
+		if err := bson.Unmarshal(dokumen, &user); err != nil {
+			// Log error if there's an issue decoding the results
+			fmt.Println("Error decoding users:", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to decode users"})
+		}
+		data, err := opsi.representasi(ctx, user)
+		if err != nil {
+			fmt.Println("Error building user response:", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to build user response"})
+		}
+		users = append(users, data)
 	}
 
-	return c.Status(http.StatusOK).JSON(users)
+	return c.Status(http.StatusOK).JSON(responses.PaginatedResponse{
+		Status:  http.StatusOK,
+		Message: "success",
+		Data:    users,
+		Meta:    hasil.Meta,
+		Links:   hasil.Links,
+	})
 }
 
 // LoginHandler untuk login dan menghasilkan token
@@ -341,6 +884,11 @@ func EditPassword(c *fiber.Ctx) error {
 		})
 	}
 
+	return ubahPassword(c, objId)
+}
+
+// Ganti password setelah memverifikasi password lama
+func ubahPassword(c *fiber.Ctx, objId primitive.ObjectID) error {
 	// Bind body JSON ke struct untuk request
 	var req struct {
 		OldPassword string `json:"old_password"`
@@ -368,7 +916,7 @@ func EditPassword(c *fiber.Ctx) error {
 	defer cancel()
 
 	var user model.User
-	err = userCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&user)
+	err := userCollection.FindOne(ctx, bson.M{"_id": objId, "deleted_at": nil}).Decode(&user)
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(responses.UserResponse{
 			Status:  http.StatusNotFound,
@@ -397,9 +945,9 @@ func EditPassword(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update password ke database
-	update := bson.M{"password": string(hashedPassword)}
-	_, err = userCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	// Update password ke database, field password disimpan dengan nama "pass"
+	update := bson.M{"pass": string(hashedPassword)}
+	_, err = userCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update, "$inc": naikkanVersi})
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
 			Status:  http.StatusInternalServerError,
@@ -407,6 +955,7 @@ func EditPassword(c *fiber.Ctx) error {
 			Data:    &fiber.Map{"data": "Failed to update password: " + err.Error()},
 		})
 	}
+	catatHistori(ctx, userCollection, AksiPassword, aktorDari(c), objId)
 
 	return c.Status(http.StatusOK).JSON(responses.UserResponse{
 		Status:  http.StatusOK,
@@ -433,6 +982,11 @@ func UploadPhoto(c *fiber.Ctx) error {
 		})
 	}
 
+	return simpanFotoUser(ctx, c, objID)
+}
+
+// Simpan foto dari form-data "photo" lalu catat path-nya di dokumen user
+func simpanFotoUser(ctx context.Context, c *fiber.Ctx, objID primitive.ObjectID) error {
 	// Ambil file dari form-data dengan key "photo"
 	file, err := c.FormFile("photo")
 	if err != nil {
@@ -471,8 +1025,8 @@ func UploadPhoto(c *fiber.Ctx) error {
 	}
 
 	// Update field photo pada user document
-	update := bson.M{"$set": bson.M{"photo": filePath}}
-	_, err = userCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	update := bson.M{"$set": bson.M{"photo": filePath}, "$inc": naikkanVersi}
+	_, err = userCollection.UpdateOne(ctx, bson.M{"_id": objID, "deleted_at": nil}, update)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(responses.UserResponse{
 			Status:  http.StatusInternalServerError,
@@ -480,6 +1034,7 @@ func UploadPhoto(c *fiber.Ctx) error {
 			Data:    &fiber.Map{"data": "Failed to update user photo"},
 		})
 	}
+	catatHistori(ctx, userCollection, AksiPhoto, aktorDari(c), objID)
 
 	return c.Status(http.StatusOK).JSON(responses.UserResponse{
 		Status:  http.StatusOK,
@@ -12,6 +12,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Inisialisasi koleksi dan validator
@@ -33,15 +34,22 @@ func CreateUserModul(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	// Validasi jenis_user terhadap katalog
+	if _, err := cariJenisUser(context.TODO(), userModul.JenisUser); err != nil {
+		return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Set timestamp
 	userModul.ID = primitive.NewObjectID()
 	userModul.CreatedAt = time.Now()
+	userModul.Version = 1
 
 	// Simpan data ke MongoDB
 	result, err := userModulCollection.InsertOne(context.TODO(), userModul)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create user modul"})
 	}
+	catatHistori(context.TODO(), userModulCollection, AksiCreate, aktorDari(c), userModul.ID)
 
 	return c.Status(http.StatusCreated).JSON(fiber.Map{
 		"message": "UserModul created successfully",
@@ -75,33 +83,30 @@ func GetUserModules(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
 	}
 
-	// Cari semua data usermodul yang berisi user_id
-	var userModules []model.UserModul
-	cursor, err := UserModulCollection.Find(c.Context(), bson.M{"user_id": userID})
+	// Ambil data user untuk mengetahui jenis_user-nya
+	user, err := cariUserByID(c.Context(), userID)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user"})
 	}
 
-	// Decode hasil query
-	if err := cursor.All(c.Context(), &userModules); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode user modules"})
+	// Resolusi modul efektif dari bundle jenis_user dan grant usermodul
+	moduls, err := modulEfektif(c.Context(), user)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user modules"})
 	}
 
 	// Jika tidak ada modul
-	if len(userModules) == 0 {
+	if len(moduls) == 0 {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{"message": "No modules found for this user"})
 	}
 
-	// Ambil nama modul dari ModulCollection
+	// Ambil nama modul
 	var moduleNames []string
-	for _, userModul := range userModules {
-		for _, modulID := range userModul.ModulID {
-			var modul model.Modul
-			err := ModulCollection.FindOne(c.Context(), bson.M{"_id": modulID}).Decode(&modul)
-			if err == nil {
-				moduleNames = append(moduleNames, modul.NmModul)
-			}
-		}
+	for _, modul := range moduls {
+		moduleNames = append(moduleNames, modul.NmModul)
 	}
 
 	// Response sukses
@@ -114,7 +119,7 @@ func GetUserModules(c *fiber.Ctx) error {
 
 // Update UserModul
 func UpdateUserModul(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id := c.Params("usermodulId")
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -131,6 +136,11 @@ func UpdateUserModul(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	// Validasi jenis_user terhadap katalog
+	if _, err := cariJenisUser(context.TODO(), userModul.JenisUser); err != nil {
+		return c.Status(statusJenisUserError(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Update timestamp
 	userModul.CreatedAt = time.Now()
 
@@ -141,21 +151,51 @@ func UpdateUserModul(c *fiber.Ctx) error {
 			"user_id":    userModul.UserID,
 			"modul_id":   userModul.ModulID,
 			"catatan":    userModul.Catatan,
+			"expired_at": userModul.ExpiredAt,
 			"created_at": userModul.CreatedAt,
 		},
+		"$inc": naikkanVersi,
+	}
+
+	// Dengan If-Match update hanya berlaku jika versi dokumen belum berubah
+	filter := bson.M{"_id": objectID}
+	syarat, bersyarat := syaratIfMatch(c)
+	if bersyarat {
+		filter["version"] = syarat
+	}
+
+	var hasil model.UserModul
+	err = userModulCollection.FindOneAndUpdate(
+		context.TODO(),
+		filter,
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&hasil)
+	if err == mongo.ErrNoDocuments {
+		if bersyarat {
+			berubah, err := versiBerubah(context.TODO(), c, userModulCollection, bson.M{"_id": objectID})
+			if err != nil {
+				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update user modul"})
+			}
+			if berubah {
+				return responsVersiBerubah(c)
+			}
+		}
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "UserModul not found"})
 	}
-
-	_, err = userModulCollection.UpdateOne(context.TODO(), bson.M{"_id": objectID}, update)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update user modul"})
 	}
 
-	return c.JSON(fiber.Map{"message": "UserModul updated successfully"})
+	catatHistori(context.TODO(), userModulCollection, AksiUpdate, aktorDari(c), objectID)
+
+	c.Set(fiber.HeaderETag, etagVersi(hasil.Version))
+	return c.JSON(fiber.Map{"message": "UserModul updated successfully", "usermodul": hasil})
 }
 
 // Delete UserModul
 func DeleteUserModul(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id := c.Params("usermodulId")
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -166,6 +206,7 @@ func DeleteUserModul(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete user modul"})
 	}
+	catatHistori(context.TODO(), userModulCollection, AksiDelete, aktorDari(c), objectID)
 
 	return c.JSON(fiber.Map{"message": "UserModul deleted successfully"})
 }
@@ -191,12 +232,18 @@ func AddModuleToUser(c *fiber.Ctx) error {
 
 	// Tambahkan modul ke usermodul
 	filter := bson.M{"user_id": userID}
-	update := bson.M{"$addToSet": bson.M{"modul_id": modulID}}
+	update := bson.M{"$addToSet": bson.M{"modul_id": modulID}, "$inc": naikkanVersi}
 
-	_, err = userModulCollection.UpdateOne(c.Context(), filter, update)
-	if err != nil {
+	var diubah struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = userModulCollection.FindOneAndUpdate(c.Context(), filter, update, options.FindOneAndUpdate().SetProjection(bson.M{"_id": 1})).Decode(&diubah)
+	if err != nil && err != mongo.ErrNoDocuments {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add module"})
 	}
+	if err == nil {
+		catatHistori(c.Context(), userModulCollection, AksiUpdate, aktorDari(c), diubah.ID)
+	}
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Module added successfully"})
 }
\ No newline at end of file
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var UserModulDenyCollection = config.GetCollection(config.DB, "usermodul_deny")
+
+// Username admin yang sedang login, diambil dari JWT
+func aktorDari(c *fiber.Ctx) string {
+	username, _ := c.Locals("username").(string)
+	return username
+}
+
+// Ambil semua deny milik user, dikelompokkan per modul
+func denyUser(ctx context.Context, userID primitive.ObjectID) (map[primitive.ObjectID]model.UserModulDeny, error) {
+	cursor, err := UserModulDenyCollection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	var denies []model.UserModulDeny
+	if err := cursor.All(ctx, &denies); err != nil {
+		return nil, err
+	}
+
+	hasil := map[primitive.ObjectID]model.UserModulDeny{}
+	for _, deny := range denies {
+		hasil[deny.ModulID] = deny
+	}
+	return hasil, nil
+}
+
+// Get deny user - daftar modul yang ditolak untuk user tertentu
+func GetUserDenies(c *fiber.Ctx) error {
+	userID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	cursor, err := UserModulDenyCollection.Find(c.Context(), bson.M{"user_id": userID})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user denies"})
+	}
+
+	denies := []model.UserModulDeny{}
+	if err := cursor.All(c.Context(), &denies); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode user denies"})
+	}
+
+	return c.JSON(fiber.Map{
+		"user_id":     userID,
+		"denies":      denies,
+		"total_count": len(denies),
+	})
+}
+
+// Create deny user - tolak akses user ke satu atau beberapa modul
+func CreateUserDeny(c *fiber.Ctx) error {
+	userID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	var body struct {
+		ModulIDs []string `json:"modul_ids" validate:"required,min=1"`
+		Alasan   string   `json:"alasan"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := validate.Struct(&body); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, id := range body.ModulIDs {
+		if _, err := primitive.ObjectIDFromHex(id); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID: " + id})
+		}
+	}
+
+	// Pastikan user dan modul ada
+	if _, err := cariUserByID(c.Context(), userID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch user"})
+	}
+	modulIDs := parseObjectIDs(body.ModulIDs)
+	if err := cekModulAda(c.Context(), modulIDs); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Satu deny per pasangan user dan modul, alasan terakhir yang dipakai
+	for _, modulID := range modulIDs {
+		// Dokumen sebelum update dipakai untuk membedakan deny baru dari deny yang diperbarui
+		denyID := primitive.NewObjectID()
+		var lama model.UserModulDeny
+		err := UserModulDenyCollection.FindOneAndUpdate(
+			c.Context(),
+			bson.M{"user_id": userID, "modul_id": modulID},
+			bson.M{
+				"$set": bson.M{"alasan": body.Alasan, "created_by": aktorDari(c)},
+				"$setOnInsert": bson.M{
+					"_id":        denyID,
+					"created_at": time.Now(),
+				},
+				"$inc": naikkanVersi,
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+		).Decode(&lama)
+		aksi := AksiCreate
+		switch {
+		case err == nil:
+			denyID, aksi = lama.ID, AksiUpdate
+		case err != mongo.ErrNoDocuments:
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create user deny"})
+		}
+		catatHistori(c.Context(), UserModulDenyCollection, aksi, aktorDari(c), denyID)
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"message": "User module denies successfully created"})
+}
+
+// Delete deny user - cabut pengecualian sehingga grant kembali berlaku
+func DeleteUserDeny(c *fiber.Ctx) error {
+	userID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+	modulID, err := primitive.ObjectIDFromHex(c.Params("modulId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID"})
+	}
+
+	var deny model.UserModulDeny
+	err = UserModulDenyCollection.FindOneAndDelete(c.Context(), bson.M{"user_id": userID, "modul_id": modulID}).Decode(&deny)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User deny not found"})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete user deny"})
+	}
+	catatHistori(c.Context(), UserModulDenyCollection, AksiDelete, aktorDari(c), deny.ID)
+
+	return c.JSON(fiber.Map{"message": "User module deny successfully deleted"})
+}
+
+// Get all deny - daftar semua pengecualian, bisa difilter dengan ?modul_id=
+func GetAllDenies(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if modulParam := c.Query("modul_id"); modulParam != "" {
+		modulID, err := primitive.ObjectIDFromHex(modulParam)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul ID"})
+		}
+		filter["modul_id"] = modulID
+	}
+
+	cursor, err := UserModulDenyCollection.Find(c.Context(), filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch denies"})
+	}
+
+	denies := []model.UserModulDeny{}
+	if err := cursor.All(c.Context(), &denies); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode denies"})
+	}
+
+	return c.JSON(fiber.Map{
+		"denies":      denies,
+		"total_count": len(denies),
+	})
+}
@@ -1,20 +1,82 @@
 package main
 
 import (
+	"context"
 	"demoapp/config"
+	"demoapp/controllers"
 	"demoapp/routes"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 
 func main() {
+	// `go run . migrate` hanya menjalankan migrasi database lalu keluar
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		config.ConnectDB()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		diterapkan, err := controllers.JalankanMigrasi(ctx)
+		for _, nama := range diterapkan {
+			fmt.Println("Applied migration", nama)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Migrations are up to date")
+		return
+	}
+
+	// `go run . mock-feed [alamat]` menyajikan storage/sync/mock sebagai feed HTTP lokal
+	if len(os.Args) > 1 && os.Args[1] == "mock-feed" {
+		alamat := ":3100"
+		if len(os.Args) > 2 {
+			alamat = os.Args[2]
+		}
+		log.Fatal(controllers.JalankanMockFeed(alamat))
+	}
+
 	//  Initialize a new Fiber app
 	app := fiber.New()
 
 	config.ConnectDB()
+
+	// Icon modul yang diupload bisa diakses dari dashboard
+	app.Static("/uploads", "./uploads")
+
 	routes.AdminRoute(app)
+	routes.SCIMRoute(app)
+
+	// Index dan backfill data dijalankan sebelum menerima request. Migrasi yang gagal (misalnya
+	// email ganda) hanya menunda migrasi yang bergantung padanya, server tetap jalan agar admin
+	// bisa merge akun lalu menjalankan ulang lewat /admin/migrations/run.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	if _, err := controllers.JalankanMigrasi(ctx); err != nil {
+		fmt.Println("Error running migrations:", err)
+	}
+	cancel()
+
+	// Katalog jenis_user diisi dengan jenis_user default dan jenis_user milik user lama
+	controllers.SeedJenisUser()
+
+	// Lanjutkan job import user yang terhenti saat server mati
+	controllers.LanjutkanImportTertunda()
+
+	// Hapus password awal hasil import yang tidak diambil
+	controllers.MulaiHapusKredensialImport()
+
+	// Hapus permanen user yang masa retensinya habis
+	controllers.MulaiPurgeUser()
+
+	// Sync user dari feed akademik/HR sesuai interval tiap sumber
+	controllers.MulaiSyncTerjadwal()
+
+	// Transisi jenis_user terjadwal, termasuk melanjutkan transisi yang terhenti
+	controllers.MulaiTransisiTerjadwal()
 
 	// Start the server on port 3000
 	log.Fatal(app.Listen(":3000"))
@@ -1,13 +1,21 @@
 package middlewares
 
 import (
+	"context"
+	"demoapp/config"
+	"demoapp/model"
 	"time"
 	"os"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"strings"
 )
 
+var userCollection = config.GetCollection(config.DB, "users")
+
 // Fungsi untuk membuat token JWT
 func GenerateJWT(username, role, jenisUser string) (string, error) {
 	claims := jwt.MapClaims{
@@ -51,5 +59,24 @@ func JWTMiddleware(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Role not found"})
 	}
 
+	// Token tetap ditolak jika akun sudah dihapus atau statusnya tidak boleh login
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var user model.User
+	err = userCollection.FindOne(
+		ctx,
+		bson.M{"username": claims["username"], "deleted_at": nil},
+		options.FindOne().SetProjection(bson.M{"status": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Account no longer exists"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify account"})
+	}
+	if !model.StatusBolehLogin(user.StatusAkun()) {
+		return TolakStatusAkun(c, user.StatusAkun())
+	}
+
 	return c.Next() // Lanjutkan ke handler berikutnya
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"demoapp/responses"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Kredensial service untuk klien SCIM dari env SCIM_TOKENS dengan format
+// "nama:token,nama2:token2", misalnya "hr:xxxx,akademik:yyyy"
+func kredensialSCIM() map[string]string {
+	hasil := map[string]string{}
+	for _, item := range strings.Split(os.Getenv("SCIM_TOKENS"), ",") {
+		nama, token, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if ok && nama != "" && token != "" {
+			hasil[nama] = token
+		}
+	}
+	return hasil
+}
+
+// SCIMAuth memeriksa bearer token klien SCIM. Nama klien disimpan sebagai username
+// "scim:<nama>" sehingga tercatat sebagai actor di histori.
+func SCIMAuth(c *fiber.Ctx) error {
+	token := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	if token != "" {
+		for nama, rahasia := range kredensialSCIM() {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(rahasia)) == 1 {
+				c.Locals("username", "scim:"+nama)
+				return c.Next()
+			}
+		}
+	}
+
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="scim"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(responses.ScimError{
+		Schemas: []string{responses.ScimSchemaError},
+		Status:  "401",
+		Detail:  "Invalid or missing SCIM credentials",
+	}, "application/scim+json")
+}
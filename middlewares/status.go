@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"demoapp/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TolakStatusAkun mengirim response untuk akun yang statusnya tidak boleh login
+func TolakStatusAkun(c *fiber.Ctx, status string) error {
+	switch status {
+	case model.StatusPending:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Account is pending activation", "status": status})
+	case model.StatusLocked:
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{"error": "Account is locked", "status": status})
+	}
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Account is " + status, "status": status})
+}
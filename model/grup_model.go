@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Grup adalah kumpulan user lintas jenis_user, misalnya "Panitia PKKMB 2026"
+type Grup struct {
+	ID        primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
+	NmGrup    string               `json:"nm_grup" bson:"nm_grup" validate:"required"`
+	KetGrup   string               `json:"ket_grup" bson:"ket_grup"`
+	UserID    []primitive.ObjectID `json:"user_id" bson:"user_id"`   // Anggota grup
+	ModulID   []primitive.ObjectID `json:"modul_id" bson:"modul_id"` // Modul yang diberikan ke semua anggota
+	CreatedAt time.Time            `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time            `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
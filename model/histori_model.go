@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Histori adalah satu versi dokumen users, modul atau usermodul beserta perubahannya
+type Histori struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Koleksi   string             `json:"koleksi" bson:"koleksi"`               // users, modul atau usermodul
+	DokumenID primitive.ObjectID `json:"dokumen_id" bson:"dokumen_id"`         // ID dokumen yang berubah
+	Version   int64              `json:"version" bson:"version"`               // Versi dokumen setelah perubahan
+	Aksi      string             `json:"aksi" bson:"aksi"`                     // create, update, delete, revert, ...
+	Diff      []HistoriDiff      `json:"diff" bson:"diff"`                     // Field yang berubah dibanding versi sebelumnya
+	Data      bson.M             `json:"data,omitempty" bson:"data,omitempty"` // Isi dokumen setelah perubahan, kosong jika dokumen dihapus
+	Dihapus   bool               `json:"dihapus,omitempty" bson:"dihapus"`     // Dokumen dihapus permanen pada versi ini
+	Actor     string             `json:"actor" bson:"actor"`                   // Username yang melakukan perubahan, "system" untuk proses latar
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// HistoriDiff adalah perubahan satu field. Nilai rahasia hanya disimpan sebagai sidik jari.
+type HistoriDiff struct {
+	Field string      `json:"field" bson:"field"`
+	Lama  interface{} `json:"lama" bson:"lama"`
+	Baru  interface{} `json:"baru" bson:"baru"`
+}
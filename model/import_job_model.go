@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportJob mencatat proses import user massal dari berkas CSV/XLSX
+type ImportJob struct {
+	ID                primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Status            string             `json:"status" bson:"status"`           // pending, running, completed, failed
+	NamaBerkas        string             `json:"nama_berkas" bson:"nama_berkas"` // Nama berkas asli yang diupload
+	Format            string             `json:"format" bson:"format"`           // csv atau xlsx
+	Berkas            string             `json:"-" bson:"berkas"`                // Lokasi berkas di storage, dibaca ulang saat resume
+	Mapping           map[string]string  `json:"mapping" bson:"mapping"`         // Kolom berkas ke field user
+	Total             int                `json:"total" bson:"total"`             // Jumlah baris data
+	Diproses          int                `json:"diproses" bson:"diproses"`       // Jumlah baris yang sudah diproses, resume mulai dari sini
+	Berhasil          int                `json:"berhasil" bson:"berhasil"`
+	Gagal             int                `json:"gagal" bson:"gagal"`
+	Errors            []ImportError      `json:"errors" bson:"errors"`
+	Kredensial        []ImportKredensial `json:"-" bson:"kredensial,omitempty"`                // Password yang dibuat sistem, dihapus setelah diambil
+	KredensialDiambil bool               `json:"kredensial_diambil" bson:"kredensial_diambil"` // Kredensial hanya bisa diambil sekali
+	Pesan             string             `json:"pesan,omitempty" bson:"pesan,omitempty"`       // Penyebab job gagal
+	CreatedBy         string             `json:"created_by" bson:"created_by"`
+	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at" bson:"updated_at"`
+	SelesaiPada       *time.Time         `json:"selesai_pada,omitempty" bson:"selesai_pada,omitempty"`
+	KedaluwarsaPada   *time.Time         `json:"kedaluwarsa_pada,omitempty" bson:"kedaluwarsa_pada,omitempty"` // Kredensial yang belum diambil dihapus setelah waktu ini
+}
+
+// ImportError adalah kesalahan pada satu baris berkas import
+type ImportError struct {
+	Baris int    `json:"baris" bson:"baris"` // Nomor baris di berkas, header adalah baris 1
+	Field string `json:"field,omitempty" bson:"field,omitempty"`
+	Pesan string `json:"pesan" bson:"pesan"`
+}
+
+// ImportKredensial adalah password awal untuk user yang passwordnya dibuat sistem
+type ImportKredensial struct {
+	Baris    int    `json:"baris" bson:"baris"`
+	Username string `json:"username" bson:"username"`
+	Password string `json:"password" bson:"password"` // Terenkripsi selama disimpan di dokumen job
+}
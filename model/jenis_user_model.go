@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JenisUser adalah katalog jenis pengguna beserta bundle modul bawaannya
+type JenisUser struct {
+	ID          primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
+	Kode        string               `json:"kode" bson:"kode" validate:"required"`                   // Contoh: "Mahasiswa"
+	NmJenisUser string               `json:"nm_jenis_user" bson:"nm_jenis_user" validate:"required"` // Nama tampilan
+	ModulID     []primitive.ObjectID `json:"modul_id" bson:"modul_id"`                               // Bundle modul bawaan
+	Parent      []string             `json:"parent,omitempty" bson:"parent,omitempty"`               // Kode jenis_user induk, bundle-nya ikut diwariskan
+	CreatedAt   time.Time            `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt   time.Time            `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
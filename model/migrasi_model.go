@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Status migrasi di koleksi migrations
+const (
+	MigrasiRunning = "running" // Sedang dijalankan oleh salah satu proses
+	MigrasiApplied = "applied" // Selesai dan tidak akan dijalankan lagi
+	MigrasiFailed  = "failed"  // Gagal, dicoba lagi pada start berikutnya atau lewat `migrate`
+)
+
+// Migrasi mencatat satu migrasi database yang pernah dijalankan
+type Migrasi struct {
+	Versi       int        `json:"versi" bson:"_id"`
+	Nama        string     `json:"nama" bson:"nama"`
+	Status      string     `json:"status" bson:"status"`
+	DimulaiPada time.Time  `json:"dimulai_pada" bson:"dimulai_pada"`
+	SelesaiPada *time.Time `json:"selesai_pada,omitempty" bson:"selesai_pada,omitempty"`
+	Error       string     `json:"error,omitempty" bson:"error,omitempty"`
+}
@@ -16,4 +16,5 @@ type Modul struct {
 	Gbr_Icon  string             `json:"gbr_icon" bson:"gbr_icon"`
 	CreatedAt time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt time.Time          `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+	Version   int64              `json:"version" bson:"version"` // Naik setiap kali modul diubah, dipakai untuk ETag
 }
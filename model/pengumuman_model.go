@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Pengumuman untuk user, ditargetkan ke jenis_user, grup, atau pemakai modul tertentu.
+// Tanpa target sama sekali berarti ditampilkan ke semua user.
+type Pengumuman struct {
+	ID              primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
+	Judul           string               `json:"judul" bson:"judul" validate:"required"`
+	Isi             string               `json:"isi" bson:"isi" validate:"required"`
+	Severity        string               `json:"severity" bson:"severity" validate:"required,oneof=info warning critical"`
+	MulaiTayang     time.Time            `json:"mulai_tayang" bson:"mulai_tayang" validate:"required"`
+	AkhirTayang     *time.Time           `json:"akhir_tayang,omitempty" bson:"akhir_tayang,omitempty"` // Kosong berarti tanpa batas
+	TargetJenisUser []string             `json:"target_jenis_user" bson:"target_jenis_user"`
+	TargetGrup      []primitive.ObjectID `json:"target_grup" bson:"target_grup"`
+	TargetModul     []primitive.ObjectID `json:"target_modul" bson:"target_modul"`
+	CreatedBy       string               `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt       time.Time            `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt       time.Time            `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// PengumumanStatus menyimpan status baca dan tutup pengumuman per user
+type PengumumanStatus struct {
+	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	PengumumanID primitive.ObjectID `json:"pengumuman_id" bson:"pengumuman_id"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	DibacaPada   *time.Time         `json:"dibaca_pada,omitempty" bson:"dibaca_pada,omitempty"`
+	DitutupPada  *time.Time         `json:"ditutup_pada,omitempty" bson:"ditutup_pada,omitempty"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Preferensi menyimpan pengaturan tampilan dan notifikasi milik user
+type Preferensi struct {
+	ID         primitive.ObjectID   `json:"-" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	Bahasa     string               `json:"bahasa" bson:"bahasa" validate:"required,oneof=id en"`         // Bahasa UI
+	Tema       string               `json:"tema" bson:"tema" validate:"required,oneof=light dark system"` // Tema UI
+	ZonaWaktu  string               `json:"zona_waktu" bson:"zona_waktu" validate:"required,timezone"`    // Nama zona IANA, misalnya Asia/Jakarta
+	Notifikasi PreferensiNotifikasi `json:"notifikasi" bson:"notifikasi"`                                 // Kanal notifikasi yang diikuti
+	UpdatedAt  time.Time            `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// PreferensiNotifikasi berisi opt-in per kanal notifikasi
+type PreferensiNotifikasi struct {
+	Email    bool `json:"email" bson:"email"`
+	Push     bool `json:"push" bson:"push"`
+	WhatsApp bool `json:"whatsapp" bson:"whatsapp"`
+}
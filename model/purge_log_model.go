@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PurgeLog mencatat penghapusan permanen satu user beserta data terkait yang ikut dibersihkan
+type PurgeLog struct {
+	ID                primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID            primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username          string             `json:"username" bson:"username"`
+	DeletedAt         time.Time          `json:"deleted_at" bson:"deleted_at"` // Waktu soft delete
+	DeletedBy         string             `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+	PurgedAt          time.Time          `json:"purged_at" bson:"purged_at"`
+	UserModulDiubah   int64              `json:"usermodul_diubah" bson:"usermodul_diubah"`   // Dokumen usermodul yang user_id-nya dikurangi
+	UserModulDihapus  int64              `json:"usermodul_dihapus" bson:"usermodul_dihapus"` // Dokumen usermodul khusus yang menjadi kosong lalu dihapus
+	GrupDiubah        int64              `json:"grup_diubah" bson:"grup_diubah"`
+	DenyDihapus       int64              `json:"deny_dihapus" bson:"deny_dihapus"`
+	PengumumanDihapus int64              `json:"pengumuman_status_dihapus" bson:"pengumuman_status_dihapus"`
+	StatusLogDihapus  int64              `json:"status_log_dihapus" bson:"status_log_dihapus"` // Riwayat perubahan status user
+	Foto              string             `json:"foto,omitempty" bson:"foto,omitempty"`         // Path foto yang dihapus dari storage
+	Errors            []string           `json:"errors,omitempty" bson:"errors,omitempty"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SyncSumber adalah feed data user dari sistem akademik/HR yang disinkronkan ke koleksi users
+type SyncSumber struct {
+	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Nama          string             `json:"nama" bson:"nama" validate:"required"`                                              // Dicatat di field sumber_sync user yang dikelola sumber ini
+	Jenis         string             `json:"jenis" bson:"jenis" validate:"required,oneof=file url"`                             // file (berkas atau folder drop) atau url
+	Lokasi        string             `json:"lokasi" bson:"lokasi" validate:"required"`                                          // Path berkas/folder atau URL feed
+	Format        string             `json:"format,omitempty" bson:"format,omitempty" validate:"omitempty,oneof=csv xlsx json"` // Kosong berarti dari ekstensi berkas atau Content-Type
+	Header        map[string]string  `json:"header,omitempty" bson:"header,omitempty"`                                          // Header HTTP feed url, misalnya Authorization
+	Mapping       map[string]string  `json:"mapping" bson:"mapping"`                                                            // Kolom/key feed ke field user
+	Interval      string             `json:"interval,omitempty" bson:"interval,omitempty"`                                      // Jarak sync terjadwal, misalnya "6h". Kosong berarti hanya manual
+	SuspendHilang bool               `json:"suspend_hilang" bson:"suspend_hilang"`                                              // Suspend user dari sumber ini yang tidak ada lagi di feed
+	AmbilAlih     bool               `json:"ambil_alih" bson:"ambil_alih"`                                                      // User yang cocok tapi belum dikelola sumber ini (tanpa sumber atau dari sumber lain) ikut dikelola
+	RoleDiizinkan []string           `json:"role_diizinkan,omitempty" bson:"role_diizinkan,omitempty"`                          // Role yang boleh dikirim feed selain role bawaan user, admin selalu ditolak
+	Aktif         bool               `json:"aktif" bson:"aktif"`
+	TerakhirJalan *time.Time         `json:"terakhir_jalan,omitempty" bson:"terakhir_jalan,omitempty"`
+	CreatedBy     string             `json:"created_by" bson:"created_by"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// SyncRun adalah laporan satu kali sync, termasuk dry run
+type SyncRun struct {
+	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	SumberID     primitive.ObjectID `json:"sumber_id" bson:"sumber_id"`
+	Sumber       string             `json:"sumber" bson:"sumber"`
+	DryRun       bool               `json:"dry_run" bson:"dry_run"`
+	Status       string             `json:"status" bson:"status"`                     // running, completed, failed
+	Berkas       string             `json:"berkas,omitempty" bson:"berkas,omitempty"` // Berkas atau URL yang dibaca
+	Total        int                `json:"total" bson:"total"`                       // Jumlah record di feed
+	Dibuat       int                `json:"dibuat" bson:"dibuat"`
+	Diubah       int                `json:"diubah" bson:"diubah"`
+	Disuspend    int                `json:"disuspend" bson:"disuspend"`
+	TidakBerubah int                `json:"tidak_berubah" bson:"tidak_berubah"`
+	Gagal        int                `json:"gagal" bson:"gagal"`
+	Perubahan    []SyncPerubahan    `json:"perubahan,omitempty" bson:"perubahan"`
+	Pesan        string             `json:"pesan,omitempty" bson:"pesan,omitempty"` // Penyebab sync gagal
+	Actor        string             `json:"actor" bson:"actor"`
+	DimulaiPada  time.Time          `json:"dimulai_pada" bson:"dimulai_pada"`
+	SelesaiPada  *time.Time         `json:"selesai_pada,omitempty" bson:"selesai_pada,omitempty"`
+}
+
+// SyncPerubahan adalah perubahan (atau rencana perubahan pada dry run) untuk satu user
+type SyncPerubahan struct {
+	Aksi       string              `json:"aksi" bson:"aksi"`                         // create, update, suspend atau error
+	Record     int                 `json:"record,omitempty" bson:"record,omitempty"` // Nomor record di feed, baris untuk CSV/XLSX
+	UserID     *primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Username   string              `json:"username,omitempty" bson:"username,omitempty"`
+	ExternalID string              `json:"external_id,omitempty" bson:"external_id,omitempty"`
+	Diff       []HistoriDiff       `json:"diff,omitempty" bson:"diff,omitempty"`
+	Pesan      string              `json:"pesan,omitempty" bson:"pesan,omitempty"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransisiJenisUser adalah aturan perpindahan jenis_user satu kohort pada waktu tertentu,
+// misalnya mahasiswa angkatan 2021 yang sudah lulus menjadi alumni saat wisuda
+type TransisiJenisUser struct {
+	ID               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Nama             string             `json:"nama" bson:"nama" validate:"required"`
+	Dari             string             `json:"dari" bson:"dari" validate:"required"`          // jenis_user asal
+	Ke               string             `json:"ke" bson:"ke" validate:"required,nefield=Dari"` // jenis_user tujuan
+	Kohort           KohortTransisi     `json:"kohort" bson:"kohort"`                          // User asal yang ikut dipindahkan
+	Jadwal           time.Time          `json:"jadwal" bson:"jadwal" validate:"required"`      // Waktu transisi dijalankan otomatis
+	Status           string             `json:"status" bson:"status"`                          // scheduled, running, completed, failed, rolling_back, rolled_back
+	Total            int                `json:"total" bson:"total"`                            // Jumlah user kohort saat transisi dimulai
+	Diproses         int                `json:"diproses" bson:"diproses"`                      // Jumlah user yang sudah diproses
+	Berhasil         int                `json:"berhasil" bson:"berhasil"`                      // Dipindahkan
+	Dilewati         int                `json:"dilewati" bson:"dilewati"`                      // Sudah dihapus atau jenis_user-nya berubah sebelum diproses
+	Gagal            int                `json:"gagal" bson:"gagal"`                            // Error saat dipindahkan, bisa dicoba lagi
+	DiRollback       int                `json:"dirollback" bson:"dirollback"`                  // Dikembalikan ke jenis_user asal
+	RollbackDilewati int                `json:"rollback_dilewati" bson:"rollback_dilewati"`    // Jenis_user-nya sudah diubah lagi setelah transisi
+	RollbackGagal    int                `json:"rollback_gagal" bson:"rollback_gagal"`          // Error saat dikembalikan, bisa dicoba lagi
+	Pesan            string             `json:"pesan,omitempty" bson:"pesan,omitempty"`        // Penyebab transisi berhenti
+	CreatedBy        string             `json:"created_by" bson:"created_by"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" bson:"updated_at"`
+	DimulaiPada      *time.Time         `json:"dimulai_pada,omitempty" bson:"dimulai_pada,omitempty"` // Terisi setelah daftar user kohort disimpan
+	SelesaiPada      *time.Time         `json:"selesai_pada,omitempty" bson:"selesai_pada,omitempty"`
+	RollbackBy       string             `json:"rollback_by,omitempty" bson:"rollback_by,omitempty"`
+	RollbackPada     *time.Time         `json:"rollback_pada,omitempty" bson:"rollback_pada,omitempty"`
+}
+
+// KohortTransisi memilih user yang dipindahkan. Semua kriteria yang diisi harus terpenuhi.
+type KohortTransisi struct {
+	Angkatan   []int                `json:"angkatan,omitempty" bson:"angkatan,omitempty"`       // Tahun masuk user
+	Status     []string             `json:"status,omitempty" bson:"status,omitempty"`           // Status akun, misalnya graduated
+	SumberSync string               `json:"sumber_sync,omitempty" bson:"sumber_sync,omitempty"` // Hanya user dari sumber sync ini
+	UserIDs    []primitive.ObjectID `json:"user_ids,omitempty" bson:"user_ids,omitempty"`       // Daftar user tertentu
+}
+
+// HasilTransisi adalah hasil transisi satu user, disimpan per user agar progress bisa dilanjutkan
+// dan rollback tahu dokumen usermodul mana yang perlu dikembalikan
+type HasilTransisi struct {
+	ID            primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
+	TransisiID    primitive.ObjectID   `json:"transisi_id" bson:"transisi_id"`
+	UserID        primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	Username      string               `json:"username" bson:"username"`
+	Status        string               `json:"status" bson:"status"`                                     // pending, moved, skipped, failed, rolled_back, rollback_skipped, rollback_failed
+	Pesan         string               `json:"pesan,omitempty" bson:"pesan,omitempty"`                   // Alasan dilewati atau error
+	ModulDilepas  []primitive.ObjectID `json:"modul_dilepas,omitempty" bson:"modul_dilepas,omitempty"`   // Dokumen usermodul yang ditinggalkan user
+	ModulDitambah []primitive.ObjectID `json:"modul_ditambah,omitempty" bson:"modul_ditambah,omitempty"` // Dokumen usermodul yang baru diikuti user
+	DiprosesPada  *time.Time           `json:"diproses_pada,omitempty" bson:"diproses_pada,omitempty"`
+	RollbackPada  *time.Time           `json:"rollback_pada,omitempty" bson:"rollback_pada,omitempty"`
+}
@@ -4,17 +4,26 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 
 // User struct represents a user in the MongoDB database
 type User struct {
-	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`                      // ID unik dari MongoDB
-	Username     string             `json:"username" bson:"username" validate:"required"`           // Nama pengguna
-	NmUser       string             `json:"nm_user" bson:"nm_user" validate:"required"`             // Nama lengkap pengguna
-	Password     string             `json:"password" bson:"pass" validate:"required"`               // Password yang di-hash
-	Email        string             `json:"email" bson:"email" validate:"required,email"`           // Email pengguna
-	Role         string             `json:"role" bson:"role" validate:"required"`                   // Peran pengguna, misalnya civitas
-	CreatedAt    primitive.DateTime `json:"created_at" bson:"created_at,omitempty"`                 // Tanggal pembuatan akun
-	JenisKelamin int                `json:"jenis_kelamin" bson:"jenis_kelamin" validate:"required"` // 1 untuk laki-laki, 2 untuk perempuan
-	Photo        string             `json:"photo,omitempty" bson:"photo,omitempty"`                 // Path atau URL gambar profil
-	Phone        string             `json:"phone" bson:"phone" validate:"required"`                 // Nomor telepon pengguna
-	Token        string             `json:"token,omitempty" bson:"token,omitempty"`                 // Token autentikasi (opsional)
-	JenisUser    string             `json:"jenis_user" bson:"jenis_user" validate:"required"`       // Jenis pengguna, misalnya Mahasiswa
-	Pass_2       string             `json:"pass_2,omitempty" bson:"pass_2,omitempty"`               // Field tambahan (opsional)
+	ID           primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`                      // ID unik dari MongoDB
+	Username     string              `json:"username" bson:"username" validate:"required"`           // Nama pengguna
+	NmUser       string              `json:"nm_user" bson:"nm_user" validate:"required"`             // Nama lengkap pengguna
+	Password     string              `json:"password" bson:"pass" validate:"required"`               // Password yang di-hash
+	Email        string              `json:"email" bson:"email" validate:"required,email"`           // Email pengguna
+	Role         string              `json:"role" bson:"role" validate:"required"`                   // Peran pengguna, misalnya civitas
+	CreatedAt    primitive.DateTime  `json:"created_at" bson:"created_at,omitempty"`                 // Tanggal pembuatan akun
+	JenisKelamin int                 `json:"jenis_kelamin" bson:"jenis_kelamin" validate:"required"` // 1 untuk laki-laki, 2 untuk perempuan
+	Photo        string              `json:"photo,omitempty" bson:"photo,omitempty"`                 // Path atau URL gambar profil
+	Phone        string              `json:"phone" bson:"phone" validate:"required"`                 // Nomor telepon pengguna
+	Token        string              `json:"token,omitempty" bson:"token,omitempty"`                 // Token autentikasi (opsional)
+	JenisUser    string              `json:"jenis_user" bson:"jenis_user" validate:"required"`       // Jenis pengguna, misalnya Mahasiswa
+	Angkatan     int                 `json:"angkatan,omitempty" bson:"angkatan,omitempty"`           // Tahun masuk, dipakai untuk transisi jenis_user per kohort
+	Pass_2       string              `json:"pass_2,omitempty" bson:"pass_2,omitempty"`               // Field tambahan (opsional)
+	Status       string              `json:"status" bson:"status,omitempty"`                         // Status akun, kosong berarti active
+	SearchNgram  []string            `json:"-" bson:"search_ngram,omitempty"`                        // Trigram untuk pencarian user
+	DeletedAt    *primitive.DateTime `json:"deleted_at,omitempty" bson:"deleted_at"`                 // Waktu soft delete, null untuk user aktif
+	DeletedBy    string              `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`       // Admin yang menghapus
+	MergedInto   *primitive.ObjectID `json:"merged_into,omitempty" bson:"merged_into,omitempty"`     // User yang menggantikan akun ini setelah merge
+	Version      int64               `json:"version" bson:"version"`                                 // Naik setiap kali user diubah, dipakai untuk ETag
+	ExternalID   string              `json:"external_id,omitempty" bson:"external_id,omitempty"`     // ID user di sistem sumber (SCIM externalId)
+	SumberSync   string              `json:"sumber_sync,omitempty" bson:"sumber_sync,omitempty"`     // Nama sumber sync yang mengelola user ini
 }
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status akun user
+const (
+	StatusPending   = "pending"   // Akun dibuat tetapi belum diaktifkan
+	StatusActive    = "active"    // Akun normal
+	StatusSuspended = "suspended" // Dinonaktifkan sementara oleh admin
+	StatusLocked    = "locked"    // Dikunci, misalnya karena alasan keamanan
+	StatusGraduated = "graduated" // Sudah lulus, tetap bisa login
+)
+
+// TransisiStatusUser berisi status tujuan yang diizinkan dari setiap status
+var TransisiStatusUser = map[string][]string{
+	StatusPending:   {StatusActive, StatusSuspended},
+	StatusActive:    {StatusSuspended, StatusLocked, StatusGraduated},
+	StatusSuspended: {StatusActive, StatusGraduated},
+	StatusLocked:    {StatusActive, StatusSuspended},
+	StatusGraduated: {StatusActive, StatusSuspended},
+}
+
+// StatusAkun mengembalikan status user, data lama tanpa status dianggap active
+func (u User) StatusAkun() string {
+	if u.Status == "" {
+		return StatusActive
+	}
+	return u.Status
+}
+
+// StatusBolehLogin menentukan status yang boleh login dan mengakses modul
+func StatusBolehLogin(status string) bool {
+	return status == StatusActive || status == StatusGraduated
+}
+
+// TransisiStatusValid memeriksa apakah perpindahan status diizinkan
+func TransisiStatusValid(dari string, ke string) bool {
+	for _, tujuan := range TransisiStatusUser[dari] {
+		if tujuan == ke {
+			return true
+		}
+	}
+	return false
+}
+
+// UserStatusLog mencatat satu perubahan status akun
+type UserStatusLog struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Dari      string             `json:"dari" bson:"dari"`
+	Ke        string             `json:"ke" bson:"ke"`
+	Alasan    string             `json:"alasan" bson:"alasan"`
+	Actor     string             `json:"actor" bson:"actor"` // Username admin atau nama proses otomatis
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
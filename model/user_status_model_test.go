@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestTransisiStatusValid(t *testing.T) {
+	tests := []struct {
+		nama string
+		dari string
+		ke   string
+		want bool
+	}{
+		{"pending ke active", StatusPending, StatusActive, true},
+		{"pending ke suspended", StatusPending, StatusSuspended, true},
+		{"pending langsung graduated", StatusPending, StatusGraduated, false},
+		{"active ke locked", StatusActive, StatusLocked, true},
+		{"active ke graduated", StatusActive, StatusGraduated, true},
+		{"active kembali ke pending", StatusActive, StatusPending, false},
+		{"suspended ke active", StatusSuspended, StatusActive, true},
+		{"suspended ke locked", StatusSuspended, StatusLocked, false},
+		{"locked ke active", StatusLocked, StatusActive, true},
+		{"locked ke graduated", StatusLocked, StatusGraduated, false},
+		{"graduated ke active", StatusGraduated, StatusActive, true},
+		{"status yang sama", StatusActive, StatusActive, false},
+		{"status asal tidak dikenal", "deleted", StatusActive, false},
+		{"status tujuan tidak dikenal", StatusActive, "deleted", false},
+		{"status kosong", "", StatusActive, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := TransisiStatusValid(tt.dari, tt.ke); got != tt.want {
+				t.Errorf("TransisiStatusValid(%q, %q) = %v, want %v", tt.dari, tt.ke, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserModulDeny adalah pengecualian eksplisit: user tidak boleh mengakses modul
+// walaupun modul tersebut diberikan oleh bundle jenis_user atau grant lain
+type UserModulDeny struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id" validate:"required"`
+	ModulID   primitive.ObjectID `json:"modul_id" bson:"modul_id" validate:"required"`
+	Alasan    string             `json:"alasan,omitempty" bson:"alasan,omitempty"`         // Alasan pengecualian
+	CreatedBy string             `json:"created_by,omitempty" bson:"created_by,omitempty"` // Username admin pembuat
+	CreatedAt time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	Version   int64              `json:"version" bson:"version"` // Naik setiap kali deny diubah
+}
@@ -13,5 +13,7 @@ type UserModul struct {
 	UserID    []primitive.ObjectID `json:"user_id" bson:"user_id" validate:"required"`       // Array Referensi ke User
 	ModulID   []primitive.ObjectID `json:"modul_id" bson:"modul_id" validate:"required"`     // Array Referensi ke Modul
 	Catatan   string               `json:"catatan,omitempty" bson:"catatan,omitempty"`       // Catatan opsional
+	ExpiredAt *time.Time           `json:"expired_at,omitempty" bson:"expired_at,omitempty"` // Batas berlaku grant (opsional)
 	CreatedAt time.Time            `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	Version   int64                `json:"version" bson:"version"` // Naik setiap kali dokumen diubah, dipakai untuk ETag
 }
\ No newline at end of file
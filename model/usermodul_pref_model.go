@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserModulPref menyimpan modul favorit dan modul yang terakhir dibuka oleh user
+type UserModulPref struct {
+	ID        primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	Favorit   []primitive.ObjectID `json:"favorit" bson:"favorit"`   // Urutan sesuai pilihan user
+	Terakhir  []ModulTerakhir      `json:"terakhir" bson:"terakhir"` // Terbaru di depan
+	UpdatedAt time.Time            `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// ModulTerakhir adalah satu entri riwayat modul yang dibuka user
+type ModulTerakhir struct {
+	ModulID    primitive.ObjectID `json:"modul_id" bson:"modul_id"`
+	DibukaPada time.Time          `json:"dibuka_pada" bson:"dibuka_pada"`
+}
@@ -0,0 +1,84 @@
+package responses
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Fieldset adalah daftar field yang diminta lewat ?fields=, kosong berarti semua field
+type Fieldset []string
+
+// Nama field JSON dari sebuah struct DTO
+func fieldJSON(dto interface{}) map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(dto)
+	for i := 0; i < t.NumField(); i++ {
+		nama := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if nama != "" && nama != "-" {
+			fields[nama] = true
+		}
+	}
+	return fields
+}
+
+// ParseFieldset membaca ?fields=a,b,c dan menolak field yang tidak dimiliki dto.
+// Field id selalu ikut agar resource tetap bisa dirujuk.
+func ParseFieldset(nilai string, dto interface{}) (Fieldset, error) {
+	if strings.TrimSpace(nilai) == "" {
+		return nil, nil
+	}
+	diizinkan := fieldJSON(dto)
+
+	fields := Fieldset{"id"}
+	sudah := map[string]bool{"id": true}
+	for _, field := range strings.Split(nilai, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || sudah[field] {
+			continue
+		}
+		if !diizinkan[field] {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+		sudah[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// Tambah field ke fieldset yang tidak kosong, dipakai untuk relasi dari ?include=
+func (f Fieldset) Tambah(field string) Fieldset {
+	if len(f) == 0 {
+		return f
+	}
+	for _, ada := range f {
+		if ada == field {
+			return f
+		}
+	}
+	return append(f, field)
+}
+
+// Terapkan mengembalikan dto apa adanya jika fieldset kosong,
+// selain itu hanya field yang diminta yang dikirim
+func (f Fieldset) Terapkan(dto interface{}) (interface{}, error) {
+	if len(f) == 0 {
+		return dto, nil
+	}
+	data, err := json.Marshal(dto)
+	if err != nil {
+		return nil, err
+	}
+	var semua map[string]json.RawMessage
+	if err := json.Unmarshal(data, &semua); err != nil {
+		return nil, err
+	}
+	hasil := make(map[string]json.RawMessage, len(f))
+	for _, field := range f {
+		if nilai, ok := semua[field]; ok {
+			hasil[field] = nilai
+		}
+	}
+	return hasil, nil
+}
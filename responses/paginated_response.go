@@ -0,0 +1,29 @@
+package responses
+
+// PaginatedResponse adalah envelope standar untuk endpoint daftar berhalaman
+type PaginatedResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+	Meta    PageMeta    `json:"meta"`
+	Links   PageLinks   `json:"links"`
+}
+
+// PageMeta berisi informasi halaman yang sedang dikirim
+type PageMeta struct {
+	Total      int64    `json:"total"`
+	Count      int      `json:"count"`
+	Limit      int      `json:"limit"`
+	Page       int      `json:"page,omitempty"`        // Hanya pada mode offset
+	TotalPages int      `json:"total_pages,omitempty"` // Hanya pada mode offset
+	NextCursor string   `json:"next_cursor,omitempty"` // Hanya pada mode cursor
+	PrevCursor string   `json:"prev_cursor,omitempty"` // Hanya pada mode cursor
+	Sort       []string `json:"sort"`
+}
+
+// PageLinks berisi URL lengkap ke halaman ini, berikutnya dan sebelumnya
+type PageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
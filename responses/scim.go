@@ -0,0 +1,99 @@
+package responses
+
+// Schema URN SCIM 2.0 (RFC 7643 dan RFC 7644)
+const (
+	ScimSchemaUser            = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ScimSchemaGroup           = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ScimSchemaUserExt         = "urn:demoapp:params:scim:schemas:extension:2.0:User"  // jenis_kelamin dan status akun
+	ScimSchemaGroupExt        = "urn:demoapp:params:scim:schemas:extension:2.0:Group" // Bundle modul jenis_user
+	ScimSchemaListResponse    = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimSchemaError           = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ScimSchemaPatchOp         = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ScimSchemaSearchRequest   = "urn:ietf:params:scim:api:messages:2.0:SearchRequest"
+	ScimSchemaBulkRequest     = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	ScimSchemaBulkResponse    = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	ScimSchemaServiceProvider = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ScimSchemaResourceType    = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	ScimSchemaSchema          = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+// ScimMeta adalah atribut meta pada setiap resource SCIM
+type ScimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Version      string `json:"version,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+// ScimMultiValue adalah satu nilai atribut multi-valued seperti emails atau members
+type ScimMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// ScimName adalah atribut name pada User
+type ScimName struct {
+	Formatted string `json:"formatted"`
+}
+
+// ScimUserExt berisi atribut user yang tidak ada di schema inti SCIM
+type ScimUserExt struct {
+	JenisKelamin int    `json:"jenisKelamin,omitempty"` // 1 laki-laki, 2 perempuan
+	Status       string `json:"status"`                 // Status akun lengkap, active di schema inti hanya boolean
+}
+
+// ScimUser adalah representasi model.User sebagai resource SCIM User
+type ScimUser struct {
+	Schemas      []string         `json:"schemas"`
+	ID           string           `json:"id"`
+	ExternalID   string           `json:"externalId,omitempty"`
+	UserName     string           `json:"userName"`
+	Name         ScimName         `json:"name"`
+	DisplayName  string           `json:"displayName"`
+	UserType     string           `json:"userType"`
+	Active       bool             `json:"active"`
+	Emails       []ScimMultiValue `json:"emails"`
+	PhoneNumbers []ScimMultiValue `json:"phoneNumbers,omitempty"`
+	Photos       []ScimMultiValue `json:"photos,omitempty"`
+	Roles        []ScimMultiValue `json:"roles,omitempty"`
+	Groups       []ScimMultiValue `json:"groups,omitempty"`
+	Ext          ScimUserExt      `json:"urn:demoapp:params:scim:schemas:extension:2.0:User"`
+	Meta         ScimMeta         `json:"meta"`
+}
+
+// ScimGroupExt berisi modul bawaan jenis_user yang diberikan ke semua anggota grup
+type ScimGroupExt struct {
+	Modules []ScimMultiValue `json:"modules"`
+	Parent  []string         `json:"parent,omitempty"`
+}
+
+// ScimGroup adalah representasi model.JenisUser sebagai resource SCIM Group
+type ScimGroup struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id"`
+	DisplayName string           `json:"displayName"`
+	Members     []ScimMultiValue `json:"members"`
+	Ext         ScimGroupExt     `json:"urn:demoapp:params:scim:schemas:extension:2.0:Group"`
+	Meta        ScimMeta         `json:"meta"`
+}
+
+// ScimListResponse adalah hasil query resource SCIM
+type ScimListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int64         `json:"totalResults"`
+	StartIndex   int64         `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// ScimError adalah body response error SCIM
+type ScimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
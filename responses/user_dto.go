@@ -0,0 +1,95 @@
+package responses
+
+import (
+	"demoapp/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserDTO adalah representasi publik user. Password, token, pass_2 dan field internal
+// seperti search_ngram tidak pernah ikut terkirim.
+type UserDTO struct {
+	ID           primitive.ObjectID  `json:"id"`
+	Username     string              `json:"username"`
+	NmUser       string              `json:"nm_user"`
+	Email        string              `json:"email"`
+	Role         string              `json:"role"`
+	Status       string              `json:"status"`
+	JenisUser    string              `json:"jenis_user"`
+	Angkatan     int                 `json:"angkatan,omitempty"`
+	JenisKelamin int                 `json:"jenis_kelamin"`
+	Phone        string              `json:"phone"`
+	Photo        string              `json:"photo"`
+	CreatedAt    primitive.DateTime  `json:"created_at"`
+	DeletedAt    *primitive.DateTime `json:"deleted_at,omitempty"`
+	DeletedBy    string              `json:"deleted_by,omitempty"`
+	MergedInto   *primitive.ObjectID `json:"merged_into,omitempty"` // Akun pengganti jika user ini hasil merge
+	Version      int64               `json:"version"`
+	ExternalID   string              `json:"external_id,omitempty"`
+	SumberSync   string              `json:"sumber_sync,omitempty"` // Sumber sync yang mengelola user ini
+	Moduls       *[]ModulDTO         `json:"moduls,omitempty"`      // Hanya terisi dengan ?include=moduls
+}
+
+// NewUserDTO membuat UserDTO dari model.User
+func NewUserDTO(user model.User) UserDTO {
+	return UserDTO{
+		ID:           user.ID,
+		Username:     user.Username,
+		NmUser:       user.NmUser,
+		Email:        user.Email,
+		Role:         user.Role,
+		Status:       user.StatusAkun(),
+		JenisUser:    user.JenisUser,
+		Angkatan:     user.Angkatan,
+		JenisKelamin: user.JenisKelamin,
+		Phone:        user.Phone,
+		Photo:        user.Photo,
+		CreatedAt:    user.CreatedAt,
+		DeletedAt:    user.DeletedAt,
+		DeletedBy:    user.DeletedBy,
+		MergedInto:   user.MergedInto,
+		ExternalID:   user.ExternalID,
+		SumberSync:   user.SumberSync,
+		Version:      user.Version,
+	}
+}
+
+// ModulDTO adalah representasi publik modul
+type ModulDTO struct {
+	ID        primitive.ObjectID `json:"id"`
+	NmModul   string             `json:"nm_modul"`
+	KetModul  string             `json:"ket_modul"`
+	IsAktif   bool               `json:"is_aktif"`
+	Alamat    string             `json:"alamat"`
+	Urutan    int                `json:"urutan"`
+	GbrIcon   string             `json:"gbr_icon"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Version   int64              `json:"version"`
+}
+
+// NewModulDTO membuat ModulDTO dari model.Modul
+func NewModulDTO(modul model.Modul) ModulDTO {
+	return ModulDTO{
+		ID:        modul.ID,
+		NmModul:   modul.NmModul,
+		KetModul:  modul.KetModul,
+		IsAktif:   modul.IsAktif,
+		Alamat:    modul.Alamat,
+		Urutan:    modul.Urutan,
+		GbrIcon:   modul.Gbr_Icon,
+		CreatedAt: modul.CreatedAt,
+		UpdatedAt: modul.UpdatedAt,
+		Version:   modul.Version,
+	}
+}
+
+// NewModulDTOs membuat daftar ModulDTO, tidak pernah nil agar terkirim sebagai []
+func NewModulDTOs(moduls []model.Modul) []ModulDTO {
+	hasil := make([]ModulDTO, 0, len(moduls))
+	for _, modul := range moduls {
+		hasil = append(hasil, NewModulDTO(modul))
+	}
+	return hasil
+}
@@ -11,26 +11,134 @@ func AdminRoute(app *fiber.App) {
 	// Route login tidak memerlukan autentikasi JWT
 	app.Post("/login", controllers.LoginHandler)
 
-
-	//BUATKAN ROUTE UNUTUK USER SAJA
 	app.Post("/register", controllers.RegisterHandler)
 
+	// Grup untuk user yang sudah login, tidak memerlukan role admin
+	meGroup := app.Group("/me", middlewares.JWTMiddleware)
+	meGroup.Get("/", controllers.GetMe)
+	meGroup.Put("/", controllers.EditMe)
+	meGroup.Patch("/", controllers.PatchMe)
+	meGroup.Put("/edit-password", controllers.EditMyPassword)
+	meGroup.Put("/upload-photo", controllers.UploadMyPhoto)
+	meGroup.Get("/moduls", controllers.GetMyModules)
+	meGroup.Get("/moduls/:modulId/launch", controllers.LaunchMyModule)
+	meGroup.Post("/moduls/:modulId/launch", controllers.RecordMyModuleLaunch)
+	meGroup.Get("/dashboard", controllers.GetMyDashboard)
+	meGroup.Get("/favorit", controllers.GetMyFavorites)
+	meGroup.Put("/favorit", controllers.ReorderMyFavorites)
+	meGroup.Post("/favorit/:modulId", controllers.PinMyModule)
+	meGroup.Delete("/favorit/:modulId", controllers.UnpinMyModule)
+	meGroup.Get("/terakhir", controllers.GetMyRecentModules)
+	meGroup.Get("/pengumuman", controllers.GetMyPengumuman)
+	meGroup.Post("/pengumuman/:pengumumanId/read", controllers.ReadMyPengumuman)
+	meGroup.Post("/pengumuman/:pengumumanId/dismiss", controllers.DismissMyPengumuman)
+	meGroup.Get("/preferences", controllers.GetMyPreferences)
+	meGroup.Put("/preferences", controllers.UpdateMyPreferences)
+	meGroup.Delete("/preferences", controllers.ResetMyPreferences)
+
 	// Grup pengguna dengan autentikasi JWT
 	adminGroup := app.Group("/admin", middlewares.JWTMiddleware, middlewares.CheckRole("admin"))
 	adminGroup.Get("/users", controllers.GetUsers)
+	adminGroup.Get("/users/search", controllers.SearchUsers)
+	adminGroup.Post("/users/search/reindex", controllers.ReindexUserSearch)
+
+	// Import user massal dari CSV/XLSX
+	adminGroup.Post("/users/import", controllers.ImportUsers)
+	adminGroup.Get("/users/import", controllers.GetImportJobs)
+	adminGroup.Get("/users/import/:jobId", controllers.GetImportJob)
+	adminGroup.Post("/users/import/:jobId/resume", controllers.ResumeImportJob)
+	adminGroup.Get("/users/import/:jobId/credentials", controllers.GetImportCredentials)
+	adminGroup.Get("/users/export", controllers.ExportUsers)
+
+	// Akun ganda: laporan kemungkinan duplikat dan penggabungan dua akun
+	adminGroup.Get("/users/duplicates", controllers.GetDuplicateUsers)
+	adminGroup.Post("/users/merge", controllers.MergeUsers)
+
+	// Riwayat perubahan users, modul dan usermodul
+	adminGroup.Get("/histori/:koleksi/:id", controllers.GetHistori)
+	adminGroup.Get("/histori/:koleksi/:id/as-of", controllers.GetHistoriAsOf)
+	adminGroup.Post("/histori/:koleksi/:id/revert", controllers.RevertHistori)
+
+	// Migrasi database: status dan menjalankan migrasi yang tertunda
+	adminGroup.Get("/migrations", controllers.GetMigrations)
+	adminGroup.Post("/migrations/run", controllers.RunMigrations)
+
+	// Purge permanen user yang sudah dihapus
+	adminGroup.Post("/users/purge", controllers.PurgeUsers)
+	adminGroup.Get("/purge-log", controllers.GetPurgeLog)
+
+	// Sync user dari feed akademik/HR
+	adminGroup.Get("/sync/sources", controllers.GetSyncSources)
+	adminGroup.Post("/sync/sources", controllers.CreateSyncSource)
+	adminGroup.Put("/sync/sources/:sumberId", controllers.UpdateSyncSource)
+	adminGroup.Delete("/sync/sources/:sumberId", controllers.DeleteSyncSource)
+	adminGroup.Post("/sync/sources/:sumberId/run", controllers.RunSyncSource)
+	adminGroup.Get("/sync/runs", controllers.GetSyncRuns)
+	adminGroup.Get("/sync/runs/:runId", controllers.GetSyncRun)
+
+	// Transisi jenis_user terjadwal per kohort, misalnya mahasiswa menjadi alumni saat wisuda
+	adminGroup.Get("/transisi", controllers.GetTransisi)
+	adminGroup.Post("/transisi", controllers.CreateTransisi)
+	adminGroup.Get("/transisi/:transisiId", controllers.GetTransisiByID)
+	adminGroup.Put("/transisi/:transisiId", controllers.UpdateTransisi)
+	adminGroup.Delete("/transisi/:transisiId", controllers.DeleteTransisi)
+	adminGroup.Get("/transisi/:transisiId/preview", controllers.PreviewTransisi)
+	adminGroup.Get("/transisi/:transisiId/hasil", controllers.GetHasilTransisi)
+	adminGroup.Post("/transisi/:transisiId/run", controllers.RunTransisi)
+	adminGroup.Post("/transisi/:transisiId/rollback", controllers.RollbackTransisi)
+
 	adminGroup.Get("/allmoduls", controllers.GetAllModuls)
 	adminGroup.Get("/modul/:modulId", controllers.GetModulByID)
 	adminGroup.Get("/usermodul", controllers.GetAllUserModuls)
 
 	adminGroup.Get("/usermodul/:user_id", controllers.GetUserModules)
+	adminGroup.Get("/users/:userId/access", controllers.GetUserAccess)
+
+	// Pengecualian (deny) modul per user
+	adminGroup.Get("/deny", controllers.GetAllDenies)
+	adminGroup.Get("/users/:userId/deny", controllers.GetUserDenies)
+	adminGroup.Post("/users/:userId/deny", controllers.CreateUserDeny)
+	adminGroup.Delete("/users/:userId/deny/:modulId", controllers.DeleteUserDeny)
+
+	// Grup user lintas jenis_user
+	adminGroup.Get("/grup", controllers.GetAllGrup)
+	adminGroup.Post("/grup", controllers.CreateGrup)
+	adminGroup.Get("/grup/:grupId", controllers.GetGrupByID)
+	adminGroup.Put("/grup/:grupId", controllers.UpdateGrup)
+	adminGroup.Delete("/grup/:grupId", controllers.DeleteGrup)
+	adminGroup.Post("/grup/:grupId/anggota", controllers.AddGrupMembers)
+	adminGroup.Delete("/grup/:grupId/anggota", controllers.RemoveGrupMembers)
+	adminGroup.Post("/grup/:grupId/modul", controllers.AddGrupModules)
+	adminGroup.Delete("/grup/:grupId/modul", controllers.RemoveGrupModules)
+	adminGroup.Get("/users/:userId/grup", controllers.GetUserGrups)
+
+	// Pengumuman yang ditargetkan ke jenis_user, grup, atau modul
+	adminGroup.Get("/pengumuman", controllers.GetAllPengumuman)
+	adminGroup.Post("/pengumuman", controllers.CreatePengumuman)
+	adminGroup.Get("/pengumuman/:pengumumanId", controllers.GetPengumumanByID)
+	adminGroup.Put("/pengumuman/:pengumumanId", controllers.UpdatePengumuman)
+	adminGroup.Delete("/pengumuman/:pengumumanId", controllers.DeletePengumuman)
 
 	adminGroup.Put("/changeusertype", controllers.ChangeUserType)
 
+	// Katalog jenis_user beserta bundle modul bawaannya
+	adminGroup.Get("/jenisuser", controllers.GetAllJenisUser)
+	adminGroup.Post("/jenisuser", controllers.CreateJenisUser)
+	adminGroup.Get("/jenisuser/bundles", controllers.GetAllJenisUserBundles)
+	adminGroup.Get("/jenisuser/:kode/bundle", controllers.GetJenisUserBundle)
+	adminGroup.Get("/jenisuser/:kode", controllers.GetJenisUser)
+	adminGroup.Put("/jenisuser/:kode", controllers.UpdateJenisUser)
+	adminGroup.Delete("/jenisuser/:kode", controllers.DeleteJenisUser)
+
 
 	adminGroup.Post("/create", controllers.CreateUser)
 	adminGroup.Get("/:userId", controllers.GetAUser)
 	adminGroup.Put("/:userId", controllers.EditAUser)
+	adminGroup.Patch("/:userId", controllers.PatchAUser)
 	adminGroup.Delete("/:userId", controllers.DeleteAUser)
+	adminGroup.Post("/:userId/restore", controllers.RestoreAUser)
+	adminGroup.Put("/:userId/status", controllers.ChangeUserStatus)
+	adminGroup.Get("/:userId/status-history", controllers.GetUserStatusHistory)
 
 	// Route khusus untuk upload foto
 	adminGroup.Put("/:userId/upload-photo", controllers.UploadPhoto)
@@ -42,6 +150,7 @@ func AdminRoute(app *fiber.App) {
 	adminGroup.Post("/modul", controllers.CreateModul)
 	adminGroup.Get("/modul/:modulId", controllers.GetModulByID)
 	adminGroup.Put("/modul/:modulId", controllers.UpdateModul)
+	adminGroup.Patch("/modul/:modulId", controllers.PatchModul)
 	adminGroup.Delete("/modul/:modulId", controllers.DeleteModul)
 
 
@@ -63,3 +172,33 @@ func AdminRoute(app *fiber.App) {
 
 }
 
+
+// SCIMRoute mendaftarkan endpoint SCIM 2.0 untuk provisioning dari sistem HR dan akademik.
+// Autentikasi memakai kredensial service, bukan JWT user.
+func SCIMRoute(app *fiber.App) {
+	scim := app.Group("/scim/v2", middlewares.SCIMAuth)
+
+	scim.Get("/ServiceProviderConfig", controllers.GetScimServiceProviderConfig)
+	scim.Get("/ResourceTypes", controllers.GetScimResourceTypes)
+	scim.Get("/ResourceTypes/:name", controllers.GetScimResourceTypes)
+	scim.Get("/Schemas", controllers.GetScimSchemas)
+	scim.Get("/Schemas/:id", controllers.GetScimSchemas)
+
+	scim.Get("/Users", controllers.ScimGetUsers)
+	scim.Post("/Users/.search", controllers.ScimGetUsers)
+	scim.Post("/Users", controllers.ScimCreateUser)
+	scim.Get("/Users/:id", controllers.ScimGetUser)
+	scim.Put("/Users/:id", controllers.ScimReplaceUser)
+	scim.Patch("/Users/:id", controllers.ScimPatchUser)
+	scim.Delete("/Users/:id", controllers.ScimDeleteUser)
+
+	scim.Get("/Groups", controllers.ScimGetGroups)
+	scim.Post("/Groups/.search", controllers.ScimGetGroups)
+	scim.Post("/Groups", controllers.ScimCreateGroup)
+	scim.Get("/Groups/:id", controllers.ScimGetGroup)
+	scim.Put("/Groups/:id", controllers.ScimReplaceGroup)
+	scim.Patch("/Groups/:id", controllers.ScimPatchGroup)
+	scim.Delete("/Groups/:id", controllers.ScimDeleteGroup)
+
+	scim.Post("/Bulk", controllers.ScimBulk)
+}